@@ -0,0 +1,29 @@
+package jrpc2
+
+import (
+	"net/http"
+)
+
+// AcceptedStatus defines status member of accepted result.
+const AcceptedStatus = "accepted"
+
+// AcceptedResult represents result of method that accepted work for asynchronous processing.
+type AcceptedResult struct {
+	// Status equals to "accepted"
+	Status string `json:"status"`
+	// Token is used to poll for final result via another method
+	Token string `json:"token"`
+}
+
+// Accepted marks call as accepted for asynchronous processing, response is sent with 202 HTTP status code,
+// JSON-RPC response body still carries result with poll token:
+//
+//	return data.Accepted(jobID)
+func (p ParametersObject) Accepted(token string) (interface{}, *ErrorObject) {
+	p.SetHTTPStatusCode(http.StatusAccepted)
+
+	return AcceptedResult{
+		Status: AcceptedStatus,
+		Token:  token,
+	}, nil
+}
@@ -0,0 +1,151 @@
+package jrpc2
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+)
+
+// AccessLogFormat defines format of access log lines.
+type AccessLogFormat int
+
+// Access log formats.
+const (
+	// AccessLogDisabled disables access log
+	AccessLogDisabled AccessLogFormat = iota
+	// CommonLogFormat defines Apache Common Log Format: %h %l %u %t "%r" %>s %b %D
+	CommonLogFormat
+	// CombinedLogFormat defines Apache Combined Log Format: %h %l %u %t "%r" %>s %b "%{Referer}i" "%{User-agent}i" %D
+	CombinedLogFormat
+)
+
+// SetAccessLog enables access log lines per request in provided format, nil writer defaults to os.Stderr.
+// Every line ends with request duration in microseconds (%D), pre-dispatch failures are logged too.
+func (s *Service) SetAccessLog(w io.Writer, format AccessLogFormat) {
+	if format == AccessLogDisabled {
+		s.accessLog = nil
+
+		return
+	}
+
+	if w == nil {
+		w = os.Stderr
+	}
+
+	s.accessLog = log.New(w, "", 0)
+	s.accessLogFormat = format
+}
+
+// accessLogWriter wraps HTTP response writer, records status code and number of written bytes.
+type accessLogWriter struct {
+	http.ResponseWriter
+
+	status int
+	bytes  int64
+}
+
+// WriteHeader records status code.
+func (w *accessLogWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+
+	w.ResponseWriter.WriteHeader(code)
+}
+
+// Write records number of written bytes.
+func (w *accessLogWriter) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+
+	return n, err
+}
+
+// accessLogFlusher is accessLogWriter of response writer that supports flushing.
+type accessLogFlusher struct {
+	*accessLogWriter
+}
+
+// Flush implements http.Flusher interface.
+func (w accessLogFlusher) Flush() {
+	w.ResponseWriter.(http.Flusher).Flush()
+}
+
+// wrap returns writer used for request processing, it implements http.Flusher
+// only when wrapped writer does, so streaming responses detect writers that can not flush.
+func (w *accessLogWriter) wrap() http.ResponseWriter {
+	if _, ok := w.ResponseWriter.(http.Flusher); ok {
+		return accessLogFlusher{w}
+	}
+
+	return w
+}
+
+// accessLogValue returns '-' for empty values.
+func accessLogValue(v string) string {
+	if v == "" {
+		return "-"
+	}
+
+	return v
+}
+
+// accessLogEscape escapes quotes, backslashes and control characters of client provided value,
+// so value can not forge access log lines.
+func accessLogEscape(v string) string {
+	q := strconv.Quote(v)
+
+	return q[1 : len(q)-1]
+}
+
+// writeAccessLog writes single access log line for processed request.
+func (s *Service) writeAccessLog(w *accessLogWriter, r *http.Request, start time.Time) {
+	var host string
+
+	if s.behindReverseProxy {
+		host = GetClientAddressFromHeader(r).String()
+	} else {
+		host = GetClientAddressFromRequest(r).String()
+	}
+
+	user, _, _ := r.BasicAuth()
+	if principal := principalFromContext(r.Context()); principal != "" {
+		user = principal
+	}
+
+	status := w.status
+	if status == 0 {
+		status = http.StatusOK
+	}
+
+	size := "-"
+	if w.bytes > 0 {
+		size = fmt.Sprintf("%d", w.bytes)
+	}
+
+	line := fmt.Sprintf(
+		"%s - %s [%s] \"%s %s %s\" %d %s",
+		host,
+		accessLogValue(accessLogEscape(user)),
+		start.Format("02/Jan/2006:15:04:05 -0700"),
+		accessLogEscape(r.Method),
+		accessLogEscape(r.URL.RequestURI()),
+		accessLogEscape(r.Proto),
+		status,
+		size,
+	)
+
+	if s.accessLogFormat == CombinedLogFormat {
+		line += fmt.Sprintf(" %q %q", accessLogValue(r.Referer()), accessLogValue(r.UserAgent()))
+	}
+
+	s.accessLog.Printf("%s %d", line, time.Since(start).Microseconds())
+}
@@ -0,0 +1,100 @@
+package jrpc2
+
+import (
+	"io"
+	"net/http"
+)
+
+// SetByteAccountingFlag sets flag that enables per-request accounting of bytes read from request body
+// and bytes written to response, counters are reported to logging hook after request is processed.
+func (s *Service) SetByteAccountingFlag(flag bool) {
+	s.byteAccounting = flag
+}
+
+// GetByteAccountingFlag gets byte accounting flag from service object.
+func (s *Service) GetByteAccountingFlag() bool {
+	return s.byteAccounting
+}
+
+// countingReader wraps request body, records number of read bytes.
+type countingReader struct {
+	io.ReadCloser
+
+	bytes int64
+}
+
+// Read records number of read bytes.
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.ReadCloser.Read(p)
+	cr.bytes += int64(n)
+
+	return n, err
+}
+
+// countingWriter wraps HTTP response writer, records number of written bytes.
+type countingWriter struct {
+	http.ResponseWriter
+
+	bytes int64
+}
+
+// Write records number of written bytes.
+func (cw *countingWriter) Write(b []byte) (int, error) {
+	n, err := cw.ResponseWriter.Write(b)
+	cw.bytes += int64(n)
+
+	return n, err
+}
+
+// countingFlusher is countingWriter of response writer that supports flushing.
+type countingFlusher struct {
+	*countingWriter
+}
+
+// Flush implements http.Flusher interface.
+func (cw countingFlusher) Flush() {
+	cw.ResponseWriter.(http.Flusher).Flush()
+}
+
+// wrap returns writer used for request processing, it implements http.Flusher
+// only when wrapped writer does, so streaming responses detect writers that can not flush.
+func (cw *countingWriter) wrap() http.ResponseWriter {
+	if _, ok := cw.ResponseWriter.(http.Flusher); ok {
+		return countingFlusher{cw}
+	}
+
+	return cw
+}
+
+// byteCounter holds request/response counters of single request.
+type byteCounter struct {
+	reader *countingReader
+	writer *countingWriter
+
+	method string // name of invoked method, empty until request is decoded
+}
+
+// newByteCounter wraps request body and response writer with counting wrappers.
+func newByteCounter(w http.ResponseWriter, r *http.Request) *byteCounter {
+	bc := &byteCounter{
+		reader: &countingReader{ReadCloser: r.Body},
+		writer: &countingWriter{ResponseWriter: w},
+	}
+
+	r.Body = bc.reader
+
+	return bc
+}
+
+// logByteCounts reports request/response counters to logging hook.
+func (s *Service) logByteCounts(r *http.Request, bc *byteCounter) {
+	s.logEntry(r, LogEntry{
+		Level:   LogLevelInfo,
+		Message: "request bytes",
+		Method:  bc.method,
+		Fields: map[string]interface{}{
+			"bytes_read":    bc.reader.bytes,
+			"bytes_written": bc.writer.bytes,
+		},
+	})
+}
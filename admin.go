@@ -0,0 +1,193 @@
+package jrpc2
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+// Admin endpoint paths, relative to admin handler mount point.
+const (
+	// AdminStatusPath returns service status on GET requests
+	AdminStatusPath = "/status"
+	// AdminDrainPath starts graceful shutdown on POST requests
+	AdminDrainPath = "/drain"
+	// AdminReplayPath lists captured requests on GET requests and replays them on POST requests
+	AdminReplayPath = "/replay"
+)
+
+// AdminStatus represents status of service reported by admin endpoint.
+type AdminStatus struct {
+	// InFlight contains number of requests being processed
+	InFlight int64 `json:"in_flight"`
+	// Methods contains sorted names of enabled registered methods
+	Methods []string `json:"methods"`
+	// Uptime contains time since service was created
+	Uptime string `json:"uptime"`
+	// UptimeSeconds contains time since service was created in seconds
+	UptimeSeconds float64 `json:"uptime_seconds"`
+	// Draining reports that service rejects new RPC requests
+	Draining bool `json:"draining"`
+	// Drained reports that drain is complete
+	Drained bool `json:"drained"`
+}
+
+// Status returns current status of service.
+func (s *Service) Status() AdminStatus {
+	uptime := time.Since(s.lifecycle.started)
+
+	methods := make([]string, 0)
+
+	for name, m := range s.currentRegistry().methods {
+		if !m.isDisabled() {
+			methods = append(methods, name)
+		}
+	}
+
+	sort.Strings(methods)
+
+	return AdminStatus{
+		InFlight:      atomic.LoadInt64(&s.lifecycle.inFlight),
+		Methods:       methods,
+		Uptime:        uptime.Round(time.Second).String(),
+		UptimeSeconds: uptime.Seconds(),
+		Draining:      s.lifecycle.isDraining(),
+		Drained:       s.lifecycle.isDrained(),
+	}
+}
+
+// SetInsecureAdminFlag sets flag that allows admin drain and replay endpoints (see AdminHandler)
+// when service has no authorization configured (see AddAuthorization).
+// Enable only when admin handler is bound to listener reachable solely by trusted clients.
+func (s *Service) SetInsecureAdminFlag(flag bool) {
+	s.insecureAdmin = flag
+}
+
+// GetInsecureAdminFlag gets flag that allows admin drain and replay endpoints without configured authorization.
+func (s *Service) GetInsecureAdminFlag() bool {
+	return s.insecureAdmin
+}
+
+// isAdminControlAllowed reports whether state-changing admin endpoints are available,
+// they require configured authorization unless explicitly allowed with SetInsecureAdminFlag.
+func (s *Service) isAdminControlAllowed() bool {
+	return s.auth != nil || s.insecureAdmin
+}
+
+// AdminHandler returns HTTP handler for lifecycle management, separate from RPC endpoint:
+// GET on '/status' returns service status (see AdminStatus), POST on '/drain' starts
+// graceful shutdown (see Drain) with DefaultDrainTimeout and replies with 202 HTTP status code.
+// When request capture is enabled (see SetRequestCaptureSize), GET on '/replay' returns last captured requests
+// and POST on '/replay' replays them (see Replay), optional 'count' query parameter limits number of requests.
+// Requests are checked with service Authorization rules, failed check is answered with 403 HTTP status code.
+// Drain and replay endpoints are answered with 403 HTTP status code when service has no authorization configured,
+// unless allowed with SetInsecureAdminFlag.
+// Handler should be bound to separate (internal) listener, not exposed with RPC endpoint.
+func (s *Service) AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc(AdminStatusPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			w.Header().Set("Allow", http.MethodGet)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		writeJSONResponse(w, http.StatusOK, s.Status())
+	})
+
+	mux.HandleFunc(AdminDrainPath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+
+			return
+		}
+
+		if !s.isAdminControlAllowed() {
+			w.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
+		go func() {
+			ctx, cancel := context.WithTimeout(context.Background(), DefaultDrainTimeout)
+			defer cancel()
+
+			if err := s.Drain(ctx); err != nil {
+				s.logEntry(r, LogEntry{
+					Level:   LogLevelError,
+					Message: "drain failed",
+					Fields: map[string]interface{}{
+						"error": err.Error(),
+					},
+				})
+			}
+		}()
+
+		writeJSONResponse(w, http.StatusAccepted, s.Status())
+	})
+
+	mux.HandleFunc(AdminReplayPath, func(w http.ResponseWriter, r *http.Request) {
+		// debug replay is available only when request capture is enabled
+		if s.capture == nil {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		// captured requests contain caller data and replay runs methods again
+		if !s.isAdminControlAllowed() {
+			w.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
+		n, err := strconv.Atoi(r.URL.Query().Get("count"))
+		if err != nil {
+			n = 0
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			writeJSONResponse(w, http.StatusOK, s.CapturedRequests(n))
+		case http.MethodPost:
+			writeJSONResponse(w, http.StatusOK, s.Replay(r.Context(), n))
+		default:
+			w.Header().Set("Allow", http.MethodGet+", "+http.MethodPost)
+			w.WriteHeader(http.StatusMethodNotAllowed)
+		}
+	})
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// check Basic Authorization
+		if err := s.CheckAuthorization(r); err != nil {
+			// set response header to 403, (forbidden)
+			w.WriteHeader(http.StatusForbidden)
+
+			return
+		}
+
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// writeJSONResponse writes JSON encoded response of admin and REST handlers.
+func writeJSONResponse(w http.ResponseWriter, code int, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+
+	_, _ = w.Write(body)
+}
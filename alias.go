@@ -0,0 +1,58 @@
+package jrpc2
+
+import (
+	"fmt"
+)
+
+// Alias routes calls of old method name to method registered under new name, used when renaming methods.
+// New name can be registered method or another alias, alias cycles and conflicts with registered methods are rejected.
+// Alias name can not be registered with RegisterE later, Register replaces alias with method.
+// Aliases are resolved before dispatch, so per-method settings of canonical method apply to alias calls.
+func (s *Service) Alias(oldName, newName string) error {
+	if oldName == newName {
+		return fmt.Errorf("alias '%s' points to itself", oldName)
+	}
+
+	s.registryMu.Lock()
+	defer s.registryMu.Unlock()
+
+	if _, ok := s.methods[oldName]; ok {
+		return fmt.Errorf("alias '%s' conflicts with registered method", oldName)
+	}
+
+	if err := s.checkMethodName(oldName); err != nil {
+		return err
+	}
+
+	// follow alias chain to canonical method, detecting cycles
+	name := newName
+
+	for i := 0; i <= len(s.aliases); i++ {
+		if name == oldName {
+			return fmt.Errorf("alias '%s' -> '%s' creates alias cycle", oldName, newName)
+		}
+
+		next, ok := s.aliases[name]
+		if !ok {
+			break
+		}
+
+		name = next
+	}
+
+	if _, ok := s.methods[name]; !ok {
+		return fmt.Errorf("method '%s' is not registered", newName)
+	}
+
+	// aliases are copied on write, calls in flight keep using aliases they started with
+	aliases := make(map[string]string, len(s.aliases)+1)
+
+	for k, v := range s.aliases {
+		aliases[k] = v
+	}
+
+	aliases[oldName] = newName
+	s.aliases = aliases
+
+	return nil
+}
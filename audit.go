@@ -0,0 +1,116 @@
+package jrpc2
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+)
+
+// Audit entry statuses.
+const (
+	// AuditStatusOK indicates that method call succeeded
+	AuditStatusOK = "ok"
+	// AuditStatusError indicates that method call returned error object
+	AuditStatusError = "error"
+)
+
+// AuditEntry represents audit trail record of state-changing method call.
+type AuditEntry struct {
+	// Timestamp contains time when method call finished
+	Timestamp time.Time
+	// RequestID contains request correlation ID (X-Request-ID)
+	RequestID string
+	// Principal contains authenticated principal, empty when not set by trusted gateway
+	Principal string
+	// ClientIP contains remote address of request source
+	ClientIP string
+	// Method contains the name of invoked method
+	Method string
+	// Params contains params of call with redacted fields (see SetRedactedFields)
+	Params json.RawMessage
+	// Status equals to AuditStatusOK or AuditStatusError
+	Status string
+	// ErrorCode contains code of returned error object, zero for successful calls
+	ErrorCode int
+}
+
+// SetAuditHook defines function that receives audit entry after each call of state-changing method,
+// methods marked as read-only (see SetMethodReadOnly) and dry-run calls are not audited.
+// Hook is intended for immutable audit storage (database, append-only log), unlike logging hook
+// it runs synchronously before response is written, so entry is stored before client sees result.
+func (s *Service) SetAuditHook(f func(r *http.Request, entry AuditEntry)) {
+	s.auditHook = f
+}
+
+// SetMethodReadOnly marks registered method as read-only (no state changes), read-only methods are not audited.
+// Clearing flag also disables call coalescing and result cache of method (see SetMethodCoalescing, SetMethodCache).
+func (s *Service) SetMethodReadOnly(name string, flag bool) error {
+	return s.updateMethod(name, func(m *method) {
+		m.ReadOnly = flag
+
+		if !flag {
+			m.Coalesce = false
+			m.CacheTTL = 0
+		}
+	})
+}
+
+// audit passes audit entry of finished method call to audit hook.
+func (s *Service) audit(data ParametersObject, errObj *ErrorObject) {
+	if s.auditHook == nil || data.r == nil || dryRunFlagFromContext(data.r.Context()) {
+		return
+	}
+
+	m, ok := s.lookupMethod(data.r, data.method)
+	if !ok || m.ReadOnly {
+		return
+	}
+
+	entry := AuditEntry{
+		Timestamp: time.Now(),
+		RequestID: data.GetRequestID(),
+		Principal: data.GetPrincipal(),
+		ClientIP:  data.GetRemoteAddress(),
+		Method:    data.method,
+		Params:    s.redactParams(data.r, data.method, data.params),
+		Status:    AuditStatusOK,
+	}
+
+	if errObj != nil {
+		entry.Status = AuditStatusError
+		entry.ErrorCode = errObj.Code
+	}
+
+	s.auditHook(data.r, entry)
+}
+
+// redactParams redacts params of method, redacted paths start from 'params' envelope member.
+func (s *Service) redactParams(r *http.Request, name string, params json.RawMessage) json.RawMessage {
+	if len(params) == 0 || !s.isRedactionEnabled() {
+		return params
+	}
+
+	v, ok := decodeRedactable(params)
+	if !ok {
+		return params
+	}
+
+	envelope := map[string]interface{}{"params": v}
+
+	b, err := json.Marshal(envelope)
+	if err != nil {
+		return params
+	}
+
+	redacted := encodeRedacted(b, envelope, s.getRedactPaths(r, name))
+
+	var out struct {
+		Params json.RawMessage `json:"params"`
+	}
+
+	if err = json.Unmarshal(redacted, &out); err != nil {
+		return params
+	}
+
+	return out.Params
+}
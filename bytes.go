@@ -0,0 +1,90 @@
+package jrpc2
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Bytes represents binary data encoded as base64 string in JSON.
+// Marshals to standard padded base64, unmarshals any of standard or URL-safe, padded or raw base64.
+type Bytes []byte
+
+// MarshalJSON encodes binary data as base64 JSON string.
+func (b Bytes) MarshalJSON() ([]byte, error) {
+	if b == nil {
+		return []byte("null"), nil
+	}
+
+	return json.Marshal(base64.StdEncoding.EncodeToString(b))
+}
+
+// UnmarshalJSON decodes base64 JSON string to binary data.
+func (b *Bytes) UnmarshalJSON(data []byte) error {
+	var s string
+
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+
+	decoded, err := decodeBase64(s)
+	if err != nil {
+		return err
+	}
+
+	*b = decoded
+
+	return nil
+}
+
+// decodeBase64 decodes standard or URL-safe, padded or raw base64 string.
+func decodeBase64(s string) ([]byte, error) {
+	s = strings.TrimSpace(s)
+
+	encoding := base64.StdEncoding
+	if strings.ContainsAny(s, "-_") {
+		encoding = base64.URLEncoding
+	}
+
+	if !strings.HasSuffix(s, "=") && len(s)%4 != 0 {
+		encoding = encoding.WithPadding(base64.NoPadding)
+	}
+
+	return encoding.DecodeString(s)
+}
+
+// UnmarshalBytes decodes base64 encoded named param member of JSON-RPC 2.0 request.
+func (p ParametersObject) UnmarshalBytes(field string) ([]byte, *ErrorObject) {
+	params := make(map[string]json.RawMessage)
+
+	err := json.Unmarshal(p.GetRawJSONParams(), &params)
+	if err != nil {
+		return nil, &ErrorObject{
+			Code:    InvalidParamsCode,
+			Message: InvalidParamsMessage,
+			Data:    err.Error(),
+		}
+	}
+
+	raw, ok := params[field]
+	if !ok {
+		return nil, &ErrorObject{
+			Code:    InvalidParamsCode,
+			Message: InvalidParamsMessage,
+			Data:    fmt.Sprintf("param '%s' is required", field),
+		}
+	}
+
+	var b Bytes
+
+	if err = json.Unmarshal(raw, &b); err != nil {
+		return nil, &ErrorObject{
+			Code:    InvalidParamsCode,
+			Message: InvalidParamsMessage,
+			Data:    fmt.Sprintf("param '%s' must be base64 string: %s", field, err),
+		}
+	}
+
+	return b, nil
+}
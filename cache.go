@@ -0,0 +1,209 @@
+package jrpc2
+
+import (
+	"container/list"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultMaxCacheEntries defines default maximal number of cached method call results.
+const DefaultMaxCacheEntries = 10000
+
+// cacheEntry holds cached method call result with response effects of call.
+type cacheEntry struct {
+	key     string
+	result  interface{}
+	effects *callEffects
+	expires time.Time
+}
+
+// resultCache holds successful method call results by cache key,
+// least recently used entries are evicted when cache is full.
+type resultCache struct {
+	mu      sync.Mutex
+	max     int
+	entries map[string]*list.Element
+	lru     *list.List // front is most recently used
+}
+
+// newResultCache creates empty result cache with maximal number of entries.
+func newResultCache(max int) *resultCache {
+	if max <= 0 {
+		max = DefaultMaxCacheEntries
+	}
+
+	return &resultCache{
+		max:     max,
+		entries: make(map[string]*list.Element),
+		lru:     list.New(),
+	}
+}
+
+// get returns unexpired cached result with response effects.
+func (c *resultCache) get(key string, now time.Time) (interface{}, *callEffects, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, nil, false
+	}
+
+	e := el.Value.(*cacheEntry)
+
+	if !now.Before(e.expires) {
+		c.remove(el)
+
+		return nil, nil, false
+	}
+
+	c.lru.MoveToFront(el)
+
+	return e.result, e.effects, true
+}
+
+// put stores result with response effects, least recently used entries are evicted when cache is full.
+func (c *resultCache) put(key string, result interface{}, effects *callEffects, expires time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		e := el.Value.(*cacheEntry)
+		e.result, e.effects, e.expires = result, effects, expires
+
+		c.lru.MoveToFront(el)
+
+		return
+	}
+
+	c.entries[key] = c.lru.PushFront(&cacheEntry{
+		key:     key,
+		result:  result,
+		effects: effects,
+		expires: expires,
+	})
+
+	for c.lru.Len() > c.max {
+		c.remove(c.lru.Back())
+	}
+}
+
+// remove drops cache entry.
+func (c *resultCache) remove(el *list.Element) {
+	c.lru.Remove(el)
+	delete(c.entries, el.Value.(*cacheEntry).key)
+}
+
+// setMax changes maximal number of entries, excess entries are evicted.
+func (c *resultCache) setMax(max int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.max = max
+
+	for c.lru.Len() > c.max {
+		c.remove(c.lru.Back())
+	}
+}
+
+// clear drops all cached results.
+func (c *resultCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries = make(map[string]*list.Element)
+	c.lru.Init()
+}
+
+// SetMethodCache sets time to live of cached results of registered read method, successful results
+// are reused for identical calls (see SetCacheKeyFunction) until expired, zero disables cache.
+// Result is shared between callers, so method must not return values that are modified afterwards.
+// Only read-only methods (see SetMethodReadOnly) can be cached.
+func (s *Service) SetMethodCache(name string, ttl time.Duration) error {
+	if ttl > 0 {
+		if m, ok := s.currentRegistry().methods[name]; ok && !m.ReadOnly {
+			return fmt.Errorf("method '%s' is not read-only", name)
+		}
+	}
+
+	if err := s.updateMethod(name, func(m *method) {
+		m.CacheTTL = ttl
+	}); err != nil {
+		return err
+	}
+
+	if ttl > 0 && s.cache == nil {
+		s.cache = newResultCache(s.maxCacheEntries)
+	}
+
+	return nil
+}
+
+// SetMaxCacheEntries sets maximal number of cached method call results, least recently used results
+// are evicted when cache is full. Zero or negative size restores DefaultMaxCacheEntries.
+func (s *Service) SetMaxCacheEntries(n int) {
+	if n <= 0 {
+		n = DefaultMaxCacheEntries
+	}
+
+	s.maxCacheEntries = n
+
+	if s.cache != nil {
+		s.cache.setMax(n)
+	}
+}
+
+// GetMaxCacheEntries gets maximal number of cached method call results.
+func (s *Service) GetMaxCacheEntries() int {
+	if s.maxCacheEntries <= 0 {
+		return DefaultMaxCacheEntries
+	}
+
+	return s.maxCacheEntries
+}
+
+// SetCacheKeyFunction defines function that derives cache key of cacheable method call, e.g. from principal
+// (see ParametersObject.GetPrincipal), locale header and params. Keys are scoped by method name.
+// By default calls are identical when params and caller identity match: authenticated principal or,
+// when no principal is known, hash of Authorization header; anonymous calls are cached separately.
+func (s *Service) SetCacheKeyFunction(f func(name string, data ParametersObject) string) {
+	s.cacheKey = f
+}
+
+// cached returns cached result of method call, method is called on cache miss. Response headers,
+// HTTP status code and warnings set by method are cached with result and applied to every cached response.
+func (s *Service) cached(name string, data ParametersObject, ttl time.Duration, h Handler) (interface{}, *ErrorObject) {
+	var key string
+
+	if s.cacheKey != nil {
+		key = name + "\x00" + s.cacheKey(name, data)
+	} else {
+		key = coalescingKey(name, data)
+	}
+
+	if result, effects, ok := s.cache.get(key, time.Now()); ok {
+		effects.apply(data.r)
+
+		return result, nil
+	}
+
+	effects := newCallEffects()
+
+	call := data
+	if data.r != nil {
+		call.r = data.r.WithContext(effects.context(data.r.Context()))
+	}
+
+	result, errObj := h(call)
+
+	effects.apply(data.r)
+
+	if errObj != nil {
+		return result, errObj
+	}
+
+	s.cache.put(key, result, effects, time.Now().Add(ttl))
+
+	return result, nil
+}
@@ -0,0 +1,79 @@
+package jrpc2
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// CacheControlNoStore defines Cache-Control directive of responses that must not be cached.
+const CacheControlNoStore = "no-store"
+
+// RegisterReadCacheable registers read method (see SetMethodReadOnly) with successful responses cacheable
+// for maxAge, intended for reads served over GET (see AddRESTRoute) and cached by CDN or proxies.
+// Anonymous responses are sent with 'Cache-Control: public, max-age=<seconds>', responses of authenticated
+// requests (known principal or Authorization header) are 'private', so shared caches never store them.
+// Once any method has cache directives, responses of other methods and failed calls get 'Cache-Control: no-store'.
+// Returns error when method name is in reserved namespace, the same way as RegisterE.
+func (s *Service) RegisterReadCacheable(name string, maxAge time.Duration, f Handler, mws ...Middleware) error {
+	if err := s.checkMethodName(name); err != nil {
+		return err
+	}
+
+	m := newMethod(f, mws)
+	m.ReadOnly = true
+	m.CacheControl = fmt.Sprintf("max-age=%d", int64(maxAge/time.Second))
+
+	return s.registerMethod(name, m, false)
+}
+
+// SetMethodCacheControl sets Cache-Control directives (e.g. 'private, max-age=60' or 'public, s-maxage=300')
+// sent with successful responses of registered method, empty directives restore 'no-store'.
+// Directives without 'public' or 'private' get visibility by request, as with RegisterReadCacheable.
+func (s *Service) SetMethodCacheControl(name, directives string) error {
+	return s.updateMethod(name, func(m *method) {
+		m.CacheControl = directives
+
+		// cache directives are enabled before method is published
+		if directives != "" {
+			atomic.StoreInt32(&s.methodCacheControl, 1)
+		}
+	})
+}
+
+// setCacheControl sets Cache-Control response header of method call when some methods have cache directives.
+func (s *Service) setCacheControl(r *http.Request, name string, errObj *ErrorObject) {
+	if atomic.LoadInt32(&s.methodCacheControl) == 0 {
+		return
+	}
+
+	headers := headersFromContext(r.Context())
+	if headers == nil {
+		return
+	}
+
+	headers["Cache-Control"] = CacheControlNoStore
+
+	if m, ok := s.lookupMethod(r, name); ok && m.CacheControl != "" && errObj == nil {
+		headers["Cache-Control"] = cacheControlVisibility(r, m.CacheControl)
+	}
+}
+
+// cacheControlVisibility prefixes directives with 'private' for authenticated requests and 'public' otherwise,
+// unless directives already set visibility.
+func cacheControlVisibility(r *http.Request, directives string) string {
+	for _, v := range strings.Split(directives, ",") {
+		switch strings.ToLower(strings.TrimSpace(v)) {
+		case "public", "private":
+			return directives
+		}
+	}
+
+	if callerIdentity(r) != "" {
+		return "private, " + directives
+	}
+
+	return "public, " + directives
+}
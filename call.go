@@ -1,6 +1,7 @@
 package jrpc2
 
 import (
+	"net/http"
 	"strings"
 )
 
@@ -15,8 +16,21 @@ func (s *Service) Call(name string, data ParametersObject) (interface{}, *ErrorO
 		}
 	}
 
+	// built-in ping method
+	if name == PingMethod {
+		if !s.ping {
+			return nil, &ErrorObject{
+				Code:    MethodNotFoundCode,
+				Message: MethodNotFoundMessage,
+				Data:    "ping method is disabled",
+			}
+		}
+
+		return "pong", nil
+	}
+
 	// check that request method member is not rpc-internal method
-	if strings.HasPrefix(strings.ToLower(name), "rpc.") && !s.proxy {
+	if strings.HasPrefix(strings.ToLower(name), "rpc.") && !s.proxy && !s.allowReserved {
 		return nil, &ErrorObject{
 			Code:    InvalidRequestCode,
 			Message: InvalidRequestMessage,
@@ -24,17 +38,24 @@ func (s *Service) Call(name string, data ParametersObject) (interface{}, *ErrorO
 		}
 	}
 
+	// method set used by request
+	reg := s.registryFor(data.r)
+
+	// resolve method alias to canonical method name
+	name = reg.resolveAlias(name)
+
 	// route to internal proxy method
 	if s.proxy {
 		name = "rpc.proxy"
 	}
 
 	// lookup method inside methods map
-	f, ok := s.methods[name]
-	if !ok {
+	f, ok := reg.methods[name]
+	if !ok || f.isDisabled() {
 		return nil, &ErrorObject{
 			Code:    MethodNotFoundCode,
 			Message: MethodNotFoundMessage,
+			Data:    s.methodNotFoundData(reg, name),
 		}
 	}
 
@@ -47,5 +68,59 @@ func (s *Service) Call(name string, data ParametersObject) (interface{}, *ErrorO
 		}
 	}
 
-	return f.Method(data)
+	// merge default named params under client provided params
+	data.params = mergeDefaults(f.Defaults, data.params)
+
+	h := f.Method
+
+	// in dry-run mode only validation function is invoked
+	if data.r != nil && dryRunFlagFromContext(data.r.Context()) {
+		h = dryRunHandler(f)
+	} else {
+		if f.Coalesce && s.flight != nil {
+			// identical concurrent calls share single method execution, middlewares run per call
+			fn := h
+
+			h = func(data ParametersObject) (interface{}, *ErrorObject) {
+				return s.coalesce(name, data, fn)
+			}
+		}
+
+		if f.CacheTTL > 0 && s.cache != nil {
+			// successful results are reused until expired, middlewares run per call
+			fn := h
+
+			h = func(data ParametersObject) (interface{}, *ErrorObject) {
+				return s.cached(name, data, f.CacheTTL, fn)
+			}
+		}
+	}
+
+	h = chain(h, f.Middlewares...)
+
+	// required scope is checked after service-wide middlewares authenticated caller
+	if f.Scope != "" {
+		h = s.requireScope(f.Scope)(h)
+	}
+
+	// service-wide middlewares wrap per-method middlewares
+	return chain(h, s.mws...)(data)
+}
+
+// lookupMethod returns method registered by name in method set used by request,
+// routes to internal proxy method in proxy mode.
+func (s *Service) lookupMethod(r *http.Request, name string) (method, bool) {
+	reg := s.registryFor(r)
+
+	// resolve method alias to canonical method name
+	name = reg.resolveAlias(name)
+
+	// route to internal proxy method
+	if s.proxy {
+		name = "rpc.proxy"
+	}
+
+	m, ok := reg.methods[name]
+
+	return m, ok
 }
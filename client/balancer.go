@@ -0,0 +1,295 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Balancer health check defaults.
+const (
+	balancerFailureThreshold = 3
+	balancerProbeInterval    = 5 * time.Second
+)
+
+// BalancePolicy defines selection of endpoint for next call.
+type BalancePolicy int
+
+const (
+	// RoundRobin selects healthy endpoints in turn.
+	RoundRobin BalancePolicy = iota
+	// LeastPending selects healthy endpoint with the least number of in-flight calls.
+	LeastPending
+)
+
+// EndpointStatus describes endpoint state of balancer.
+type EndpointStatus struct {
+	// URI contains JSON-RPC endpoint URI
+	URI string
+	// Healthy is false when endpoint failed consecutive calls and awaits successful probe
+	Healthy bool
+	// Pending contains number of in-flight calls
+	Pending int64
+}
+
+// balancerEndpoint holds runtime state of balancer endpoint.
+type balancerEndpoint struct {
+	pending int64 // atomic, first field keeps 64-bit alignment
+	config  *Config
+
+	failures int
+	healthy  bool
+}
+
+// Balancer is health-aware client-side load balancer over multiple JSON-RPC endpoints.
+// Endpoint is marked unhealthy after consecutive failures (connection failures, unexpected HTTP status codes, invalid responses),
+// JSON-RPC error responses do not count as failures. Call that was not sent (or call of idempotent method) is retried
+// on next healthy endpoint, so calls land on healthy endpoints transparently, when all endpoints are unhealthy calls
+// are distributed over all of them.
+// Unhealthy endpoints are re-probed with ping method by Run.
+type Balancer struct {
+	mu        sync.Mutex
+	endpoints []*balancerEndpoint
+	policy    BalancePolicy
+	next      int
+
+	failureThreshold int
+	probeInterval    time.Duration
+}
+
+// compile time check of interface implementation
+var _ Caller = (*Balancer)(nil)
+
+// NewBalancer creates load balancer over endpoint configs with selection policy, all endpoints start healthy.
+func NewBalancer(policy BalancePolicy, configs ...*Config) *Balancer {
+	b := &Balancer{
+		endpoints:        make([]*balancerEndpoint, 0, len(configs)),
+		policy:           policy,
+		failureThreshold: balancerFailureThreshold,
+		probeInterval:    balancerProbeInterval,
+	}
+
+	for _, c := range configs {
+		b.endpoints = append(b.endpoints, &balancerEndpoint{
+			config:  c,
+			healthy: true,
+		})
+	}
+
+	return b
+}
+
+// SetHealthCheck sets number of consecutive failures marking endpoint unhealthy and interval of unhealthy endpoints probes.
+func (b *Balancer) SetHealthCheck(failureThreshold int, probeInterval time.Duration) {
+	if failureThreshold < 1 {
+		failureThreshold = 1
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failureThreshold = failureThreshold
+	b.probeInterval = probeInterval
+}
+
+// Status returns state of endpoints, in order endpoints were passed to NewBalancer.
+func (b *Balancer) Status() []EndpointStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	status := make([]EndpointStatus, 0, len(b.endpoints))
+
+	for _, e := range b.endpoints {
+		status = append(status, EndpointStatus{
+			URI:     e.config.uri,
+			Healthy: e.healthy,
+			Pending: atomic.LoadInt64(&e.pending),
+		})
+	}
+
+	return status
+}
+
+// pick selects endpoint for next call according to policy, skipping already tried endpoints.
+func (b *Balancer) pick(tried map[*balancerEndpoint]bool) *balancerEndpoint {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	candidates := make([]*balancerEndpoint, 0, len(b.endpoints))
+
+	for _, e := range b.endpoints {
+		if e.healthy && !tried[e] {
+			candidates = append(candidates, e)
+		}
+	}
+
+	// no healthy endpoints left, try unhealthy ones
+	if len(candidates) == 0 {
+		for _, e := range b.endpoints {
+			if !tried[e] {
+				candidates = append(candidates, e)
+			}
+		}
+	}
+
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	if b.policy == LeastPending {
+		selected := candidates[0]
+
+		for _, e := range candidates[1:] {
+			if atomic.LoadInt64(&e.pending) < atomic.LoadInt64(&selected.pending) {
+				selected = e
+			}
+		}
+
+		return selected
+	}
+
+	b.next++
+
+	return candidates[b.next%len(candidates)]
+}
+
+// record updates endpoint health with call outcome.
+func (b *Balancer) record(e *balancerEndpoint, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !isEndpointFailure(err) {
+		e.failures = 0
+		e.healthy = true
+
+		return
+	}
+
+	if e.failures++; e.failures >= b.failureThreshold {
+		e.healthy = false
+	}
+}
+
+// isEndpointFailure reports whether call error is caused by endpoint, JSON-RPC errors are valid responses.
+func isEndpointFailure(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	var errObj *ErrorObject
+
+	return !errors.As(err, &errObj)
+}
+
+// do runs call on selected endpoints until one of them responds, returns error of last tried endpoint.
+// Failed call is replayed on next endpoint only when request was not sent or method is idempotent
+// (see Config.SetIdempotentMethods), so non-idempotent calls are never executed twice.
+func (b *Balancer) do(ctx context.Context, method string, call func(c *Config) error) error {
+	var err error
+
+	tried := make(map[*balancerEndpoint]bool, len(b.endpoints))
+
+	for {
+		e := b.pick(tried)
+		if e == nil {
+			if err == nil {
+				err = NewInternalError(ErrorPrefix, errors.New("no endpoints configured"))
+			}
+
+			return err
+		}
+
+		tried[e] = true
+
+		atomic.AddInt64(&e.pending, 1)
+		err = call(e.config)
+		atomic.AddInt64(&e.pending, -1)
+
+		b.record(e, err)
+
+		if !isEndpointFailure(err) || ctx.Err() != nil || !(isNotSent(err) || e.config.isIdempotent(method)) {
+			return err
+		}
+	}
+}
+
+// Call wraps JSON-RPC client call on balanced endpoint.
+func (b *Balancer) Call(method string, params json.RawMessage) (json.RawMessage, error) {
+	return b.CallContext(context.Background(), method, params)
+}
+
+// CallContext wraps JSON-RPC client call on balanced endpoint with parent context.
+func (b *Balancer) CallContext(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+	var result json.RawMessage
+
+	err := b.do(ctx, method, func(c *Config) error {
+		var err error
+
+		result, err = c.CallContext(ctx, method, params)
+
+		return err
+	})
+
+	return result, err
+}
+
+// Notify wraps JSON-RPC client notification on balanced endpoint.
+func (b *Balancer) Notify(method string, params json.RawMessage) error {
+	return b.NotifyContext(context.Background(), method, params)
+}
+
+// NotifyContext wraps JSON-RPC client notification on balanced endpoint with parent context.
+func (b *Balancer) NotifyContext(ctx context.Context, method string, params json.RawMessage) error {
+	return b.do(ctx, method, func(c *Config) error {
+		return c.NotifyContext(ctx, method, params)
+	})
+}
+
+// probe pings unhealthy endpoints, endpoints responding with JSON-RPC error (ping method is disabled) are reachable.
+func (b *Balancer) probe(ctx context.Context) {
+	b.mu.Lock()
+
+	unhealthy := make([]*balancerEndpoint, 0, len(b.endpoints))
+
+	for _, e := range b.endpoints {
+		if !e.healthy {
+			unhealthy = append(unhealthy, e)
+		}
+	}
+
+	b.mu.Unlock()
+
+	for _, e := range unhealthy {
+		_, err := e.config.Ping(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if !isEndpointFailure(err) {
+			b.record(e, nil)
+		}
+	}
+}
+
+// Run re-probes unhealthy endpoints until context is done, returns context error.
+// Must be called once per balancer, usually in separate goroutine.
+func (b *Balancer) Run(ctx context.Context) error {
+	b.mu.Lock()
+	interval := b.probeInterval
+	b.mu.Unlock()
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			b.probe(ctx)
+		}
+	}
+}
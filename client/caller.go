@@ -0,0 +1,64 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+)
+
+// Caller defines JSON-RPC client interface implemented by *Config,
+// consumers can depend on it to inject fake clients in tests (see clienttest package).
+type Caller interface {
+	Call(method string, params json.RawMessage) (json.RawMessage, error)
+	CallContext(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error)
+	Notify(method string, params json.RawMessage) error
+	NotifyContext(ctx context.Context, method string, params json.RawMessage) error
+}
+
+// compile time check of interface implementation
+var _ Caller = (*Config)(nil)
+
+// Notify wraps JSON-RPC client notification, server does not reply to notifications.
+func (c *Config) Notify(method string, params json.RawMessage) error {
+	return c.NotifyContext(context.Background(), method, params)
+}
+
+// NotifyContext wraps JSON-RPC client notification with parent context, config timeout is applied on top of parent context.
+// Notifications pass through interceptors the same way as calls, see IsNotification.
+func (c *Config) NotifyContext(ctx context.Context, method string, params json.RawMessage) error {
+	_, err := chain(c.notifyRoundTrip, c.interceptors...)(contextWithNotification(ctx), method, params)
+
+	return err
+}
+
+// notifyRoundTrip sends JSON-RPC notification, result is always nil.
+func (c *Config) notifyRoundTrip(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+	// prepare request object without ID
+	reqObj := getRequestObject(method, params, nil)
+
+	// convert request object to bytes
+	reqData, err := json.Marshal(reqObj)
+	if err != nil {
+		return nil, NewInternalError(ErrorPrefix, err)
+	}
+
+	// set timeout
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	// send request
+	resp, err := c.send(ctx, method, reqData, nil)
+	if err != nil {
+		return nil, NewInternalError(ErrorPrefix, err)
+	}
+
+	// close response body
+	defer resp.Body.Close()
+
+	// fail when HTTP status code is different from 204 (or 200 for servers that acknowledge notifications)
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return nil, NewInternalError(ErrorPrefix, nil).SetHTTPStatusCodes(resp.StatusCode, http.StatusNoContent)
+	}
+
+	return nil, nil
+}
@@ -4,43 +4,33 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
-	"io/ioutil"
 	"net/http"
-	"strings"
+	"net/http/httptrace"
+	"sync/atomic"
+	"time"
 
 	"golang.org/x/net/context/ctxhttp"
 )
 
 // getRequestObject creates JSON-RPC request object.
-func getRequestObject(method string, params json.RawMessage) *RequestObject {
-	return &RequestObject{
+func getRequestObject(method string, params, id json.RawMessage) *requestObject {
+	return &requestObject{
 		Jsonrpc: "2.0",
 		Method:  method,
 		Params:  params,
-		ID:      genUUID(),
+		ID:      id,
 	}
 }
 
-// Call wraps JSON-RPC client call.
-func (c *Config) Call(method string, params json.RawMessage) (json.RawMessage, error) {
-	var rerr, err error
-
-	// prepare request object
-	reqObj := getRequestObject(method, params)
-
-	// convert request object to bytes
-	reqData, err := json.Marshal(reqObj)
-	if err != nil {
-		return nil, NewInternalError(ErrorPrefix, err)
-	}
-
+// getHTTPRequest creates HTTP request with configured headers, extra headers override configured ones.
+func (c *Config) getHTTPRequest(reqData []byte, requestID string, extra map[string]string) (*http.Request, error) {
 	// prepare request data buffer
 	buf := bytes.NewBuffer(reqData)
 
 	// set request type to POST
 	req, err := http.NewRequest("POST", c.uri, buf)
 	if err != nil {
-		return nil, NewInternalError(ErrorPrefix, err)
+		return nil, err
 	}
 
 	// setting specified headers
@@ -48,68 +38,219 @@ func (c *Config) Call(method string, params json.RawMessage) (json.RawMessage, e
 		req.Header.Set(k, v)
 	}
 
+	for k, v := range extra {
+		req.Header.Set(k, v)
+	}
+
 	// set compression header
 	if !c.disableCompression {
 		req.Header.Set("Content-Encoding", "gzip")
 	}
 
+	// set request correlation header
+	if requestID != "" {
+		req.Header.Set("X-Request-ID", requestID)
+	}
+
+	// sign request body, signed per attempt to keep timestamp fresh
+	if c.signHash != nil {
+		timestamp, signature := c.signRequest(reqData)
+
+		req.Header.Set(SignatureTimestampHeader, timestamp)
+		req.Header.Set(SignatureHeader, signature)
+	}
+
 	// add X-Real-IP, X-Client-IP, when using unix sockets mode
 	if c.socketPath != nil {
 		req.Header.Set("X-Real-IP", "127.0.0.1")
 		req.Header.Set("X-Client-IP", "127.0.0.1")
 	}
 
-	// prepare response
-	var resp *http.Response
+	return req, nil
+}
+
+// send sends HTTP request of method call, connection level failures are retried when enabled,
+// requests that were sent are retried only for idempotent methods.
+func (c *Config) send(ctx context.Context, method string, reqData []byte, extra map[string]string) (*http.Response, error) {
+	var requestID string
+
+	// retries share same request correlation ID
+	if c.generateRequestID {
+		requestID = genUUID()
+	}
+
+	// cooperative rate limiting, wait for window reset
+	if err := c.waitRateLimit(ctx); err != nil {
+		return nil, err
+	}
+
+	for attempt := 0; ; attempt++ {
+		// request body is consumed by transport, prepare new request per attempt
+		req, err := c.getHTTPRequest(reqData, requestID, extra)
+		if err != nil {
+			return nil, err
+		}
+
+		// fetch possibly refreshed token per attempt
+		if c.tokenProvider != nil {
+			auth, err := c.authorizationHeader(ctx)
+			if err != nil {
+				return nil, err
+			}
+
+			req.Header.Set("Authorization", auth)
+		}
+
+		if c.dump != nil {
+			c.dump.request(req, reqData)
+		}
+
+		// connection is obtained before request is written
+		var gotConn int32
+
+		trace := &httptrace.ClientTrace{
+			GotConn: func(httptrace.GotConnInfo) {
+				atomic.StoreInt32(&gotConn, 1)
+			},
+		}
+
+		resp, err := ctxhttp.Do(httptrace.WithClientTrace(ctx, trace), c.httpClient, req)
+		if err == nil {
+			if c.dump != nil {
+				c.dump.response(resp, c.getMaxResponseBytes())
+			}
+
+			c.recordRateLimit(resp)
+
+			return resp, nil
+		}
+
+		sent := atomic.LoadInt32(&gotConn) == 1
+		if !sent {
+			err = &notSentError{err: err}
+		}
+
+		if attempt >= c.retryCount || ctx.Err() != nil || !c.isRetriable(err) || (sent && !c.isIdempotent(method)) {
+			return nil, err
+		}
+
+		// drop pooled connections, next attempt re-establishes connection
+		c.httpClient.CloseIdleConnections()
+
+		if sleepContext(ctx, retryBackoff(attempt)) != nil {
+			return nil, err
+		}
+	}
+}
+
+// Call wraps JSON-RPC client call.
+func (c *Config) Call(method string, params json.RawMessage) (json.RawMessage, error) {
+	return c.CallContext(context.Background(), method, params)
+}
+
+// CallContext wraps JSON-RPC client call with parent context, config timeout is applied on top of parent context.
+// Call passes through configured interceptors chain.
+func (c *Config) CallContext(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+	return chain(c.roundTrip, c.interceptors...)(ctx, method, params)
+}
+
+// roundTrip sends JSON-RPC request, waits for response and validates it,
+// failed responses are retried when custom retry classifier considers them retriable.
+func (c *Config) roundTrip(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+	for attempt := 0; ; attempt++ {
+		result, statusCode, wait, err := c.exchange(ctx, method, params)
+
+		// connection level failures (no status code) are retried by send
+		if err == nil || statusCode == 0 || c.retryClassifier == nil ||
+			attempt >= c.retryCount || ctx.Err() != nil || !c.retryClassifier(err, statusCode) {
+			return result, err
+		}
+
+		// server asked to wait longer than acceptable
+		if wait > retryAfterMax {
+			return result, err
+		}
+
+		if d := retryBackoff(attempt); d > wait {
+			wait = d
+		}
+
+		if sleepContext(ctx, wait) != nil {
+			return result, err
+		}
+	}
+}
+
+// exchange sends single JSON-RPC request and validates response, returns HTTP status code of response,
+// zero when no response was received or response was produced by followed redirect,
+// and retry delay requested by server (Retry-After header).
+func (c *Config) exchange(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, int, time.Duration, error) {
+	var rerr, err error
+
+	// prepare request object
+	reqObj := getRequestObject(method, params, c.nextID())
+
+	// convert request object to bytes
+	reqData, err := json.Marshal(reqObj)
+	if err != nil {
+		return nil, 0, 0, NewInternalError(ErrorPrefix, err)
+	}
 
 	// set timeout
-	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
 	defer cancel()
 
 	// send request
-	resp, err = ctxhttp.Do(ctx, c.httpClient, req)
+	resp, err := c.send(ctx, method, reqData, nil)
 	if err != nil {
-		return nil, NewInternalError(ErrorPrefix, err)
+		return nil, 0, 0, NewInternalError(ErrorPrefix, err)
 	}
 
 	// close response body
 	defer resp.Body.Close()
 
-	// fail when HTTP status code is different from 200
-	if resp.StatusCode != http.StatusOK {
-		return nil, NewInternalError(ErrorPrefix, nil).SetHTTPStatusCodes(resp.StatusCode, http.StatusOK)
+	// fail when HTTP status code is different from 200 (or 202 for asynchronously processed calls)
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusAccepted {
+		return nil, resp.StatusCode, retryAfter(resp), NewInternalError(ErrorPrefix, nil).SetHTTPStatusCodes(resp.StatusCode, http.StatusOK)
 	}
 
 	// read response raw bytes data
-	respData, err := ioutil.ReadAll(resp.Body)
+	respData, err := c.readResponseBody(resp.Body)
 	if err != nil {
-		return nil, NewInternalError(ErrorPrefix, err)
+		return nil, resp.StatusCode, retryAfter(resp), NewInternalError(ErrorPrefix, err)
 	}
 
 	// prepare response object
-	respObj := new(ResponseObject)
+	respObj := new(responseObject)
 
 	// convert response data to object
 	err = json.Unmarshal(respData, respObj)
 	if err != nil {
-		return nil, NewInternalError(ErrorPrefix, err)
+		return nil, resp.StatusCode, retryAfter(resp), NewInternalError(ErrorPrefix, err)
 	}
 
 	// validate request/response IDs
-	if !strings.EqualFold(reqObj.ID, respObj.ID) {
-		return nil, NewInternalError(ErrorPrefix, nil).SetRPCIDs(respObj.ID, reqObj.ID)
+	if !c.disableIDValidation && !equalIDs(reqObj.ID, respObj.ID) {
+		return nil, resp.StatusCode, retryAfter(resp), NewInternalError(ErrorPrefix, nil).SetRPCIDs(string(respObj.ID), string(reqObj.ID))
 	}
 
 	// validate request/response Jsonrpc protocol versions
-	if !strings.EqualFold(reqObj.Jsonrpc, respObj.Jsonrpc) {
-		return nil, NewInternalError(ErrorPrefix, nil).SetProtocolVersions(respObj.Jsonrpc, reqObj.Jsonrpc)
+	if expected, ok := c.checkProtocolVersion(reqObj.Jsonrpc, respObj.Jsonrpc); !ok {
+		return nil, resp.StatusCode, retryAfter(resp), NewInternalError(ErrorPrefix, nil).SetProtocolVersions(respObj.Jsonrpc, expected)
 	}
 
 	// check response error
 	if respObj.Error != nil {
-		return nil, respObj.Error
+		// retry call at new location of moved method
+		if location, ok := c.redirectLocation(resp, respObj.Error); ok {
+			result, err := c.redirect(location).CallContext(ctx, method, params)
+
+			return result, 0, 0, err
+		}
+
+		return nil, resp.StatusCode, retryAfter(resp), respObj.Error
 	}
 
 	// return response result and function-global error
-	return respObj.Result, rerr
+	return respObj.Result, resp.StatusCode, 0, rerr
 }
@@ -0,0 +1,107 @@
+// Package clienttest provides fake JSON-RPC client for testing code built on client package.
+package clienttest
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/s3rj1k/jrpc2/client"
+)
+
+// compile time check of interface implementation
+var _ client.Caller = (*FakeCaller)(nil)
+
+// Response defines canned response of fake client.
+type Response struct {
+	Result json.RawMessage
+	Err    error
+}
+
+// Invocation records single call or notification received by fake client.
+type Invocation struct {
+	Method       string
+	Params       json.RawMessage
+	Notification bool
+}
+
+// FakeCaller implements client.Caller interface, returns canned responses keyed by method name.
+// Calls of methods without canned response fail with Method not found error (-32601).
+type FakeCaller struct {
+	mu sync.Mutex
+
+	responses   map[string]Response
+	invocations []Invocation
+}
+
+// NewFakeCaller returns fake client without canned responses.
+func NewFakeCaller() *FakeCaller {
+	return &FakeCaller{
+		responses: make(map[string]Response),
+	}
+}
+
+// SetResponse sets canned response for method.
+func (f *FakeCaller) SetResponse(method string, result json.RawMessage, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.responses[method] = Response{
+		Result: result,
+		Err:    err,
+	}
+}
+
+// Invocations returns calls and notifications received by fake client, in order.
+func (f *FakeCaller) Invocations() []Invocation {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]Invocation(nil), f.invocations...)
+}
+
+// Call returns canned response for method.
+func (f *FakeCaller) Call(method string, params json.RawMessage) (json.RawMessage, error) {
+	return f.CallContext(context.Background(), method, params)
+}
+
+// CallContext returns canned response for method, fails when context is done.
+func (f *FakeCaller) CallContext(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.invocations = append(f.invocations, Invocation{Method: method, Params: params})
+
+	if err := ctx.Err(); err != nil {
+		return nil, client.NewInternalError(client.ErrorPrefix, err)
+	}
+
+	resp, ok := f.responses[method]
+	if !ok {
+		return nil, &client.ErrorObject{
+			Code:    -32601,
+			Message: "Method not found",
+		}
+	}
+
+	return resp.Result, resp.Err
+}
+
+// Notify records notification.
+func (f *FakeCaller) Notify(method string, params json.RawMessage) error {
+	return f.NotifyContext(context.Background(), method, params)
+}
+
+// NotifyContext records notification, fails when context is done.
+func (f *FakeCaller) NotifyContext(ctx context.Context, method string, params json.RawMessage) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.invocations = append(f.invocations, Invocation{Method: method, Params: params, Notification: true})
+
+	if err := ctx.Err(); err != nil {
+		return client.NewInternalError(client.ErrorPrefix, err)
+	}
+
+	return nil
+}
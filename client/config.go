@@ -98,3 +98,14 @@ func (c *Config) DisableCompression(t bool) {
 func (c *Config) SkipSSLCertificateCheck(t bool) {
 	c.insecureSkipVerify = t
 }
+
+// GenerateRequestID enables sending of random X-Request-ID header per call, used for request correlation.
+func (c *Config) GenerateRequestID(t bool) {
+	c.generateRequestID = t
+}
+
+// DisableIDValidation disables check that response ID matches request ID, for servers that do not echo ID correctly.
+// UNSAFE! Responses are no longer correlated with requests, mismatched responses are silently accepted.
+func (c *Config) DisableIDValidation(t bool) {
+	c.disableIDValidation = t
+}
@@ -0,0 +1,51 @@
+package client
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// ErrorDetail represents typed detail object of error, see server side ErrorObject.WithDetails.
+type ErrorDetail struct {
+	// Type identifies failure subtype
+	Type string `json:"type"`
+	// Value holds raw subtype specific data
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Details extracts typed detail objects from error data member, returns error when data is not details array.
+func (errObj *ErrorObject) Details() ([]ErrorDetail, error) {
+	if errObj == nil || len(errObj.Data) == 0 {
+		return nil, errors.New("error data is empty")
+	}
+
+	var details []ErrorDetail
+
+	if err := json.Unmarshal(errObj.Data, &details); err != nil {
+		return nil, err
+	}
+
+	for _, detail := range details {
+		if detail.Type == "" {
+			return nil, errors.New("error detail type is empty")
+		}
+	}
+
+	return details, nil
+}
+
+// HasDetail checks that error carries detail object of provided type.
+func (errObj *ErrorObject) HasDetail(detailType string) bool {
+	details, err := errObj.Details()
+	if err != nil {
+		return false
+	}
+
+	for _, detail := range details {
+		if detail.Type == detailType {
+			return true
+		}
+	}
+
+	return false
+}
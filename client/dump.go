@@ -0,0 +1,127 @@
+package client
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// dumper writes outgoing requests and incoming responses for debugging.
+type dumper struct {
+	mu     sync.Mutex
+	w      io.Writer
+	redact map[string]bool
+}
+
+// dumpRedactedHeaders lists headers with credentials, values are always redacted in dump.
+var dumpRedactedHeaders = append([]string{"Set-Cookie", SignatureHeader}, credentialHeaders...)
+
+// SetDump enables debug dump of exact outgoing request bytes and incoming response bytes with headers
+// to writer. Values of credential headers (Authorization, Proxy-Authorization, Cookie, Set-Cookie, X-Signature)
+// are always redacted, values of listed headers are redacted additionally. Event stream response bodies
+// are not dumped. Nil writer disables dump (default). Dump may contain sensitive data, use only for debugging.
+func (c *Config) SetDump(w io.Writer, redactHeaders ...string) {
+	if w == nil {
+		c.dump = nil
+
+		return
+	}
+
+	d := &dumper{
+		w:      w,
+		redact: make(map[string]bool, len(dumpRedactedHeaders)+len(redactHeaders)),
+	}
+
+	for _, h := range dumpRedactedHeaders {
+		d.redact[http.CanonicalHeaderKey(h)] = true
+	}
+
+	for _, h := range redactHeaders {
+		d.redact[http.CanonicalHeaderKey(h)] = true
+	}
+
+	c.dump = d
+}
+
+// writeHeaders writes sorted headers, redacting configured ones.
+func (d *dumper) writeHeaders(buf *bytes.Buffer, headers http.Header) {
+	keys := make([]string, 0, len(headers))
+
+	for k := range headers {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v := strings.Join(headers[k], ", ")
+
+		if d.redact[http.CanonicalHeaderKey(k)] {
+			v = "***"
+		}
+
+		fmt.Fprintf(buf, "%s: %s\n", k, v)
+	}
+}
+
+// request writes outgoing request, body is written as sent.
+func (d *dumper) request(req *http.Request, body []byte) {
+	buf := new(bytes.Buffer)
+
+	fmt.Fprintf(buf, "--> %s %s %s\n", req.Method, req.URL, req.Proto)
+	d.writeHeaders(buf, req.Header)
+	fmt.Fprintf(buf, "\n%s\n", body)
+
+	d.write(buf.Bytes())
+}
+
+// response writes incoming response, body is peeked up to limit and left readable for caller.
+func (d *dumper) response(resp *http.Response, limit int64) {
+	buf := new(bytes.Buffer)
+
+	fmt.Fprintf(buf, "<-- %s %s\n", resp.Proto, resp.Status)
+	d.writeHeaders(buf, resp.Header)
+
+	// body compressed by server was transparently decompressed by transport
+	if resp.Uncompressed {
+		fmt.Fprintf(buf, "(body decompressed by transport)\n")
+	}
+
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), EventStreamContentType) {
+		fmt.Fprintf(buf, "\n(event stream body is not dumped)\n")
+		d.write(buf.Bytes())
+
+		return
+	}
+
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, limit))
+
+	// keep body readable, including bytes over limit and read error
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{
+		Reader: io.MultiReader(bytes.NewReader(data), resp.Body),
+		Closer: resp.Body,
+	}
+
+	fmt.Fprintf(buf, "\n%s\n", data)
+
+	if err != nil {
+		fmt.Fprintf(buf, "(body read error: %s)\n", err)
+	}
+
+	d.write(buf.Bytes())
+}
+
+func (d *dumper) write(b []byte) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	_, _ = d.w.Write(b)
+}
@@ -0,0 +1,86 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// FanOutCall describes single JSON-RPC call of fan-out, each call can target its own endpoint.
+type FanOutCall struct {
+	// Config defines endpoint and call options
+	Config *Config
+	// Method contains the name of the method to be invoked
+	Method string
+	// Params holds Raw JSON parameter data to be used during the invocation of the method
+	Params json.RawMessage
+}
+
+// FanOut runs calls concurrently, returns results keyed by caller-supplied label.
+// First failed call cancels all other calls, its error is returned.
+func FanOut(ctx context.Context, calls map[string]FanOutCall) (map[string]json.RawMessage, error) {
+	var mu sync.Mutex
+
+	results := make(map[string]json.RawMessage, len(calls))
+
+	g, ctx := errgroup.WithContext(ctx)
+
+	for label, call := range calls {
+		label, call := label, call
+
+		g.Go(func() error {
+			result, err := call.Config.CallContext(ctx, call.Method, call.Params)
+			if err != nil {
+				return err
+			}
+
+			mu.Lock()
+			results[label] = result
+			mu.Unlock()
+
+			return nil
+		})
+	}
+
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	return results, nil
+}
+
+// FanOutAll runs calls concurrently until all of them complete, returns results and errors keyed by caller-supplied label.
+// Failed calls do not cancel other calls, only parent context does.
+func FanOutAll(ctx context.Context, calls map[string]FanOutCall) (map[string]json.RawMessage, map[string]error) {
+	var mu sync.Mutex
+
+	results := make(map[string]json.RawMessage, len(calls))
+	errs := make(map[string]error)
+
+	var g errgroup.Group
+
+	for label, call := range calls {
+		label, call := label, call
+
+		g.Go(func() error {
+			result, err := call.Config.CallContext(ctx, call.Method, call.Params)
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			if err != nil {
+				errs[label] = err
+			} else {
+				results[label] = result
+			}
+
+			return nil
+		})
+	}
+
+	_ = g.Wait()
+
+	return results, errs
+}
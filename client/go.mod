@@ -2,4 +2,7 @@ module github.com/s3rj1k/jrpc2/client
 
 go 1.13
 
-require golang.org/x/net v0.0.0-20191014212845-da9a3fd4c582
+require (
+	golang.org/x/net v0.0.0-20191014212845-da9a3fd4c582
+	golang.org/x/sync v0.0.0-20190911185100-cd5d95a43a6e
+)
@@ -0,0 +1,128 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// IDGenerator defines generator of JSON-RPC request IDs, generated ID must be valid JSON string or number.
+type IDGenerator interface {
+	NextID() json.RawMessage
+}
+
+// UUIDGenerator generates random UUIDv4 request IDs encoded as JSON strings, used by default.
+type UUIDGenerator struct{}
+
+// NextID returns new random UUIDv4 request ID.
+func (UUIDGenerator) NextID() json.RawMessage {
+	return json.RawMessage(strconv.Quote(genUUID()))
+}
+
+// SequentialIDGenerator generates thread-safe monotonically increasing request IDs encoded as JSON numbers.
+type SequentialIDGenerator struct {
+	n uint64
+}
+
+// NewSequentialIDGenerator returns sequential ID generator, first generated ID is 1.
+func NewSequentialIDGenerator() *SequentialIDGenerator {
+	return new(SequentialIDGenerator)
+}
+
+// NextID returns next request ID in sequence.
+func (g *SequentialIDGenerator) NextID() json.RawMessage {
+	return json.RawMessage(strconv.FormatUint(atomic.AddUint64(&g.n, 1), 10))
+}
+
+// SetIDGenerator sets generator of request IDs, nil restores default UUIDv4 generator.
+func (c *Config) SetIDGenerator(g IDGenerator) {
+	c.idGenerator = g
+}
+
+// nextID returns request ID from configured generator.
+func (c *Config) nextID() json.RawMessage {
+	if c.idGenerator == nil {
+		return UUIDGenerator{}.NextID()
+	}
+
+	return c.idGenerator.NextID()
+}
+
+// equalIDs compares request/response IDs, string IDs are compared case-insensitively,
+// numbers by normalized decimal text, so differently written equal numbers (1, 1.0, 1e0) match without float precision loss.
+func equalIDs(expected, returned json.RawMessage) bool {
+	decode := func(v json.RawMessage) interface{} {
+		var out interface{}
+
+		d := json.NewDecoder(bytes.NewReader(v))
+		d.UseNumber()
+
+		if err := d.Decode(&out); err != nil {
+			return nil
+		}
+
+		return out
+	}
+
+	switch e := decode(expected).(type) {
+	case string:
+		r, ok := decode(returned).(string)
+
+		return ok && strings.EqualFold(e, r)
+	case json.Number:
+		r, ok := decode(returned).(json.Number)
+		if !ok {
+			return false
+		}
+
+		en, ok := normalizeNumber(e.String())
+		if !ok {
+			return false
+		}
+
+		rn, ok := normalizeNumber(r.String())
+
+		return ok && en == rn
+	default:
+		return false
+	}
+}
+
+// normalizeNumber returns canonical form of JSON number text: sign, significant digits and decimal exponent.
+func normalizeNumber(s string) (string, bool) {
+	var sign string
+
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+
+	exp := 0
+
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		n, err := strconv.Atoi(strings.TrimPrefix(s[i+1:], "+"))
+		if err != nil {
+			return "", false
+		}
+
+		exp, s = n, s[:i]
+	}
+
+	digits := s
+
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		digits = s[:i] + s[i+1:]
+		exp -= len(s) - i - 1
+	}
+
+	digits = strings.TrimLeft(digits, "0")
+	if digits == "" {
+		return "0", true
+	}
+
+	trimmed := strings.TrimRight(digits, "0")
+	exp += len(digits) - len(trimmed)
+
+	return sign + trimmed + "e" + strconv.Itoa(exp), true
+}
@@ -0,0 +1,83 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+)
+
+// RoundTrip defines single JSON-RPC call.
+type RoundTrip func(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error)
+
+// Interceptor defines function that wraps RoundTrip with additional logic (logging, metrics, token refresh, retries),
+// interceptor can modify method, params and result or short-circuit call without sending request.
+type Interceptor func(next RoundTrip) RoundTrip
+
+// ctxKeyNotification marks context of notification passed to interceptors.
+type ctxKeyNotification struct{}
+
+// contextWithNotification marks context as context of notification.
+func contextWithNotification(ctx context.Context) context.Context {
+	return context.WithValue(ctx, ctxKeyNotification{}, true)
+}
+
+// IsNotification reports whether interceptor wraps notification, result of notification is always nil.
+func IsNotification(ctx context.Context) bool {
+	v, _ := ctx.Value(ctxKeyNotification{}).(bool)
+
+	return v
+}
+
+// AddInterceptor appends interceptors that wrap every call and notification,
+// interceptors are applied in order they were added, first one is the outermost.
+func (c *Config) AddInterceptor(interceptors ...Interceptor) {
+	c.interceptors = append(c.interceptors, interceptors...)
+}
+
+// chain wraps round trip with provided interceptors, first interceptor is the outermost one.
+func chain(rt RoundTrip, interceptors ...Interceptor) RoundTrip {
+	for i := len(interceptors) - 1; i >= 0; i-- {
+		rt = interceptors[i](rt)
+	}
+
+	return rt
+}
+
+// LoggingInterceptor returns Interceptor that logs method name, duration and error of every call,
+// nil logger defaults to standard logger.
+func LoggingInterceptor(logger *log.Logger) Interceptor {
+	if logger == nil {
+		logger = log.New(log.Writer(), "", log.LstdFlags)
+	}
+
+	return func(next RoundTrip) RoundTrip {
+		return func(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+			start := time.Now()
+
+			result, err := next(ctx, method, params)
+			if err != nil {
+				logger.Printf("JSON-RPC call '%s' failed after %s: %s", method, time.Since(start), err)
+			} else {
+				logger.Printf("JSON-RPC call '%s' succeeded after %s", method, time.Since(start))
+			}
+
+			return result, err
+		}
+	}
+}
+
+// MetricsInterceptor returns Interceptor that reports method name, duration and error of every call to observe function.
+func MetricsInterceptor(observe func(method string, d time.Duration, err error)) Interceptor {
+	return func(next RoundTrip) RoundTrip {
+		return func(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+			start := time.Now()
+
+			result, err := next(ctx, method, params)
+
+			observe(method, time.Since(start), err)
+
+			return result, err
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package client
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+)
+
+// DefaultMaxResponseBytes defines default limit of response body size.
+const DefaultMaxResponseBytes int64 = 64 << 20 // 64 MiB
+
+// SetMaxResponseBytes sets limit of response body size in bytes, larger responses fail with error
+// instead of being loaded into memory, zero or negative value restores default limit.
+func (c *Config) SetMaxResponseBytes(n int64) {
+	c.maxResponseBytes = n
+}
+
+// getMaxResponseBytes returns limit of response body size.
+func (c *Config) getMaxResponseBytes() int64 {
+	if c.maxResponseBytes <= 0 {
+		return DefaultMaxResponseBytes
+	}
+
+	return c.maxResponseBytes
+}
+
+// readResponseBody reads response body up to configured limit.
+func (c *Config) readResponseBody(r io.Reader) ([]byte, error) {
+	limit := c.getMaxResponseBytes()
+
+	// read one byte over limit to detect oversized body
+	data, err := ioutil.ReadAll(io.LimitReader(r, limit+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("response body exceeds %d bytes limit", limit)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,246 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// Outbox delivery backoff and attempts limits.
+const (
+	outboxBackoffMin  = 100 * time.Millisecond
+	outboxBackoffMax  = 30 * time.Second
+	outboxMaxAttempts = 20
+)
+
+// OutboxMessage represents notification queued for delivery.
+type OutboxMessage struct {
+	// ID identifies message in outbox store
+	ID string `json:"id"`
+	// Method contains the name of the notified method
+	Method string `json:"method"`
+	// Params holds Raw JSON parameter data of notification
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// OutboxStore defines storage of queued notifications, implementations can persist messages
+// so notifications survive process restarts. Implementations must be safe for concurrent use.
+type OutboxStore interface {
+	// Append adds message to the end of outbox
+	Append(msg OutboxMessage) error
+	// First returns oldest message, false when outbox is empty
+	First() (OutboxMessage, bool, error)
+	// Remove deletes delivered message
+	Remove(id string) error
+}
+
+// MemoryOutboxStore is in-memory outbox store, messages are lost on process exit.
+type MemoryOutboxStore struct {
+	mu       sync.Mutex
+	messages []OutboxMessage
+}
+
+// NewMemoryOutboxStore creates empty in-memory outbox store.
+func NewMemoryOutboxStore() *MemoryOutboxStore {
+	return new(MemoryOutboxStore)
+}
+
+// Append adds message to the end of outbox.
+func (m *MemoryOutboxStore) Append(msg OutboxMessage) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.messages = append(m.messages, msg)
+
+	return nil
+}
+
+// First returns oldest message, false when outbox is empty.
+func (m *MemoryOutboxStore) First() (OutboxMessage, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.messages) == 0 {
+		return OutboxMessage{}, false, nil
+	}
+
+	return m.messages[0], true, nil
+}
+
+// Remove deletes delivered message.
+func (m *MemoryOutboxStore) Remove(id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, msg := range m.messages {
+		if msg.ID == id {
+			m.messages = append(m.messages[:i], m.messages[i+1:]...)
+
+			break
+		}
+	}
+
+	return nil
+}
+
+// Len returns number of queued messages.
+func (m *MemoryOutboxStore) Len() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return len(m.messages)
+}
+
+// Outbox queues notifications and delivers them in background (see Run), failed deliveries
+// are retried with exponential backoff until server acknowledges notification, so emission
+// of notifications is decoupled from transient server availability.
+// Notifications are delivered one by one in order they were queued. Notification rejected by server
+// with JSON-RPC error or not delivered after maximal number of attempts (see SetMaxAttempts) is removed
+// from outbox and passed to dead-letter function (see SetDeadLetter), so it does not block queue.
+type Outbox struct {
+	caller Caller
+	store  OutboxStore
+	wake   chan struct{}
+
+	backoffMin  time.Duration
+	backoffMax  time.Duration
+	maxAttempts int
+
+	deadLetter func(msg OutboxMessage, err error)
+}
+
+// NewOutbox creates outbox delivering notifications with caller, in-memory store is used when store is nil.
+func NewOutbox(caller Caller, store OutboxStore) *Outbox {
+	if store == nil {
+		store = NewMemoryOutboxStore()
+	}
+
+	return &Outbox{
+		caller:      caller,
+		store:       store,
+		wake:        make(chan struct{}, 1),
+		backoffMin:  outboxBackoffMin,
+		backoffMax:  outboxBackoffMax,
+		maxAttempts: outboxMaxAttempts,
+	}
+}
+
+// SetBackoff sets minimal and maximal delay between delivery attempts.
+func (o *Outbox) SetBackoff(min, max time.Duration) {
+	if max < min {
+		max = min
+	}
+
+	o.backoffMin, o.backoffMax = min, max
+}
+
+// SetMaxAttempts sets maximal number of delivery attempts of notification, defaults to 20, zero disables limit.
+// Must be set before outbox is started.
+func (o *Outbox) SetMaxAttempts(n int) {
+	if n < 0 {
+		n = 0
+	}
+
+	o.maxAttempts = n
+}
+
+// SetDeadLetter sets function that receives undeliverable notifications with last delivery error,
+// notifications are dropped when function is not set. Must be set before outbox is started.
+func (o *Outbox) SetDeadLetter(f func(msg OutboxMessage, err error)) {
+	o.deadLetter = f
+}
+
+// isUndeliverable reports whether failed notification must be dropped, JSON-RPC errors are not retried.
+func (o *Outbox) isUndeliverable(err error, attempts int) bool {
+	return !isEndpointFailure(err) || (o.maxAttempts > 0 && attempts >= o.maxAttempts)
+}
+
+// Notify queues JSON-RPC notification for delivery, returns error when notification can not be stored.
+func (o *Outbox) Notify(method string, params json.RawMessage) error {
+	err := o.store.Append(OutboxMessage{
+		ID:     genUUID(),
+		Method: method,
+		Params: params,
+	})
+	if err != nil {
+		return NewInternalError(ErrorPrefix, err)
+	}
+
+	// wake up sender, pending wake up is enough
+	select {
+	case o.wake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+// Run delivers queued notifications until context is done, returns context error.
+// Must be called once per outbox, usually in separate goroutine.
+func (o *Outbox) Run(ctx context.Context) error {
+	var (
+		current  string // ID of message being delivered
+		attempts int    // delivery attempts of current message
+		dropped  string // ID of undeliverable message that is not yet removed from store
+	)
+
+	backoff := o.backoffMin
+
+	for {
+		msg, ok, err := o.store.First()
+
+		// wait for new notifications
+		if err == nil && !ok {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-o.wake:
+			}
+
+			continue
+		}
+
+		if err == nil {
+			if msg.ID != current {
+				current, attempts = msg.ID, 0
+			}
+
+			if msg.ID != dropped {
+				err = o.caller.NotifyContext(ctx, msg.Method, msg.Params)
+				if err != nil && ctx.Err() == nil {
+					if attempts++; o.isUndeliverable(err, attempts) {
+						dropped = msg.ID
+
+						if o.deadLetter != nil {
+							o.deadLetter(msg, err)
+						}
+
+						err = nil
+					}
+				}
+			}
+
+			if err == nil {
+				err = o.store.Remove(msg.ID)
+			}
+		}
+
+		if err == nil {
+			backoff = o.backoffMin
+
+			continue
+		}
+
+		// failed delivery or store failure, retry later
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		if backoff *= 2; backoff > o.backoffMax {
+			backoff = o.backoffMax
+		}
+	}
+}
@@ -0,0 +1,21 @@
+package client
+
+import (
+	"context"
+	"time"
+)
+
+// PingMethod defines name of server built-in ping method.
+const PingMethod = "rpc.ping"
+
+// Ping calls server built-in ping method, returns round-trip latency.
+// When server does not implement ping method, returned error is *ErrorObject with Method not found code (-32601).
+func (c *Config) Ping(ctx context.Context) (time.Duration, error) {
+	start := time.Now()
+
+	if _, err := c.CallContext(ctx, PingMethod, nil); err != nil {
+		return 0, err
+	}
+
+	return time.Since(start), nil
+}
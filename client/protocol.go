@@ -0,0 +1,67 @@
+package client
+
+import (
+	"strconv"
+	"strings"
+)
+
+// SetExactProtocolVersion requires exact JSON-RPC protocol version in responses,
+// empty version restores default check (response version equals request version).
+func (c *Config) SetExactProtocolVersion(version string) {
+	c.exactProtocolVersion = strings.TrimSpace(version)
+}
+
+// SetMinProtocolVersion requires minimal JSON-RPC protocol version in responses,
+// empty version restores default check (response version equals request version).
+func (c *Config) SetMinProtocolVersion(version string) {
+	c.minProtocolVersion = strings.TrimSpace(version)
+}
+
+// parseProtocolVersion parses 'major.minor' protocol version,
+// missing version member is treated as JSON-RPC 1.0.
+func parseProtocolVersion(version string) (major, minor int, ok bool) {
+	version = strings.TrimSpace(version)
+	if version == "" {
+		return 1, 0, true
+	}
+
+	parts := strings.SplitN(version, ".", 2)
+
+	major, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, false
+	}
+
+	if len(parts) == 2 {
+		if minor, err = strconv.Atoi(parts[1]); err != nil {
+			return 0, 0, false
+		}
+	}
+
+	return major, minor, true
+}
+
+// checkProtocolVersion validates response protocol version against configured expectation,
+// returns expected version description when validation fails.
+func (c *Config) checkProtocolVersion(requested, returned string) (string, bool) {
+	switch {
+	case c.exactProtocolVersion != "":
+		return c.exactProtocolVersion, strings.EqualFold(c.exactProtocolVersion, returned)
+	case c.minProtocolVersion != "":
+		expected := ">=" + c.minProtocolVersion
+
+		minMajor, minMinor, ok := parseProtocolVersion(c.minProtocolVersion)
+		if !ok {
+			return expected, false
+		}
+
+		major, minor, ok := parseProtocolVersion(returned)
+		if !ok {
+			return expected, false
+		}
+
+		return expected, major > minMajor || (major == minMajor && minor >= minMinor)
+	default:
+		return requested, strings.EqualFold(requested, returned)
+	}
+}
@@ -0,0 +1,112 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Rate limit headers advertised by server.
+const (
+	RateLimitLimitHeader     = "X-RateLimit-Limit"
+	RateLimitRemainingHeader = "X-RateLimit-Remaining"
+	RateLimitResetHeader     = "X-RateLimit-Reset"
+)
+
+// RateLimitInfo represents rate limit state advertised by server.
+type RateLimitInfo struct {
+	// Limit contains maximal number of requests in current window
+	Limit int
+	// Remaining contains number of requests left in current window
+	Remaining int
+	// Reset contains time when current window resets
+	Reset time.Time
+}
+
+// rateLimitState holds last rate limit state advertised by server.
+type rateLimitState struct {
+	mu   sync.Mutex
+	info *RateLimitInfo
+}
+
+// RespectRateLimit enables cooperative rate limiting, rate limit headers of responses are recorded
+// and calls are delayed until reset time when server advertised that no requests are remaining.
+func (c *Config) RespectRateLimit(t bool) {
+	if !t {
+		c.rateLimit = nil
+
+		return
+	}
+
+	if c.rateLimit == nil {
+		c.rateLimit = new(rateLimitState)
+	}
+}
+
+// RateLimit returns last rate limit state advertised by server, false when rate limiting is not respected
+// or server did not send rate limit headers.
+func (c *Config) RateLimit() (RateLimitInfo, bool) {
+	if c.rateLimit == nil {
+		return RateLimitInfo{}, false
+	}
+
+	c.rateLimit.mu.Lock()
+	defer c.rateLimit.mu.Unlock()
+
+	if c.rateLimit.info == nil {
+		return RateLimitInfo{}, false
+	}
+
+	return *c.rateLimit.info, true
+}
+
+// waitRateLimit waits until rate limit window resets when no requests are remaining.
+func (c *Config) waitRateLimit(ctx context.Context) error {
+	info, ok := c.RateLimit()
+	if !ok || info.Remaining > 0 {
+		return nil
+	}
+
+	d := time.Until(info.Reset)
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// recordRateLimit records rate limit headers of response.
+func (c *Config) recordRateLimit(resp *http.Response) {
+	if c.rateLimit == nil {
+		return
+	}
+
+	remaining, err := strconv.Atoi(resp.Header.Get(RateLimitRemainingHeader))
+	if err != nil {
+		return
+	}
+
+	info := &RateLimitInfo{
+		Remaining: remaining,
+	}
+
+	info.Limit, _ = strconv.Atoi(resp.Header.Get(RateLimitLimitHeader))
+
+	if reset, err := strconv.ParseInt(resp.Header.Get(RateLimitResetHeader), 10, 64); err == nil {
+		info.Reset = time.Unix(reset, 0)
+	}
+
+	c.rateLimit.mu.Lock()
+	c.rateLimit.info = info
+	c.rateLimit.mu.Unlock()
+}
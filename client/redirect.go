@@ -0,0 +1,106 @@
+package client
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// MethodMovedCode defines server error code of method that moved to another service.
+const MethodMovedCode = -32005
+
+// FollowRedirects sets maximal number of followed method redirects (method moved errors) per call,
+// call is retried at location sent by server, zero disables following (default).
+// Redirects are not followed for Unix socket configs, credentials are not sent to location of other origin
+// (scheme and host), followed calls pass through configured interceptors chain again.
+func (c *Config) FollowRedirects(max int) {
+	if max < 0 {
+		max = 0
+	}
+
+	c.maxRedirects = max
+}
+
+// redirectLocation returns location of moved method when redirect should be followed.
+func (c *Config) redirectLocation(resp *http.Response, errObj *ErrorObject) (string, bool) {
+	if c.maxRedirects <= 0 || c.socketPath != nil || errObj.Code != MethodMovedCode {
+		return "", false
+	}
+
+	location := resp.Header.Get("Location")
+
+	if location == "" {
+		var data struct {
+			Location string `json:"location"`
+		}
+
+		if err := json.Unmarshal(errObj.Data, &data); err != nil {
+			return "", false
+		}
+
+		location = data.Location
+	}
+
+	base, err := url.Parse(c.uri)
+	if err != nil {
+		return "", false
+	}
+
+	// relative locations are resolved against current URI
+	ref, err := url.Parse(location)
+	if err != nil || location == "" {
+		return "", false
+	}
+
+	return base.ResolveReference(ref).String(), true
+}
+
+// credentialHeaders lists request headers that are not sent to location of other origin.
+var credentialHeaders = []string{"Authorization", "Proxy-Authorization", "Cookie"}
+
+// redirect returns copy of config that sends calls to new location, credentials (Authorization headers,
+// token provider, HMAC signing secret) are dropped when location has different scheme or host.
+func (c *Config) redirect(location string) *Config {
+	next := *c
+
+	next.uri = location
+	next.maxRedirects--
+
+	// keep configured headers of original config intact
+	next.headers = make(map[string]string, len(c.headers))
+	for k, v := range c.headers {
+		next.headers[k] = v
+	}
+
+	if !sameOrigin(c.uri, location) {
+		for k := range next.headers {
+			for _, h := range credentialHeaders {
+				if http.CanonicalHeaderKey(k) == h {
+					delete(next.headers, k)
+				}
+			}
+		}
+
+		next.tokenProvider = nil
+		next.signSecret = nil
+		next.signHash = nil
+	}
+
+	return &next
+}
+
+// sameOrigin reports whether URIs have same scheme and host (including port).
+func sameOrigin(a, b string) bool {
+	ua, err := url.Parse(a)
+	if err != nil {
+		return false
+	}
+
+	ub, err := url.Parse(b)
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(ua.Scheme, ub.Scheme) && strings.EqualFold(ua.Host, ub.Host)
+}
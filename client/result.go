@@ -0,0 +1,48 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+)
+
+// Result represents result of JSON-RPC call, keeps raw result bytes for lazy decoding.
+type Result struct {
+	raw json.RawMessage
+}
+
+// Raw returns raw result bytes, nil when result member is missing.
+func (r *Result) Raw() json.RawMessage {
+	return r.raw
+}
+
+// IsNull checks that result is JSON null or result member is missing.
+func (r *Result) IsNull() bool {
+	trimmed := bytes.TrimSpace(r.raw)
+
+	return len(trimmed) == 0 || bytes.Equal(trimmed, []byte("null"))
+}
+
+// Decode decodes result into value, null result is decoded the same way as JSON null.
+func (r *Result) Decode(v interface{}) error {
+	if r.IsNull() {
+		return json.Unmarshal([]byte("null"), v)
+	}
+
+	return json.Unmarshal(r.raw, v)
+}
+
+// CallTyped wraps JSON-RPC client call, returns result wrapper instead of raw bytes.
+func (c *Config) CallTyped(method string, params json.RawMessage) (*Result, error) {
+	return c.CallTypedContext(context.Background(), method, params)
+}
+
+// CallTypedContext wraps JSON-RPC client call with parent context, returns result wrapper instead of raw bytes.
+func (c *Config) CallTypedContext(ctx context.Context, method string, params json.RawMessage) (*Result, error) {
+	raw, err := c.CallContext(ctx, method, params)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Result{raw: raw}, nil
+}
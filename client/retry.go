@@ -0,0 +1,202 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Retry backoff limits, delay grows exponentially with attempts and is randomized (jitter).
+const (
+	retryBackoffMin = 50 * time.Millisecond
+	retryBackoffMax = 2 * time.Second
+	// longer delays requested by server with Retry-After header are not waited for, call fails instead
+	retryAfterMax = 30 * time.Second
+)
+
+// jitter source, seeded per process so clients do not retry in lockstep
+// nolint:gochecknoglobals
+var (
+	jitterMu   sync.Mutex
+	jitterRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+)
+
+// SetRetryCount sets number of transparent retries for connection level failures
+// (HTTP/2 GOAWAY, stream resets, connection resets), zero disables retries.
+// Failed request is retried only when it was provably not sent (no connection was obtained),
+// or when method is idempotent (see SetIdempotentMethods), so non-idempotent calls are never executed twice.
+func (c *Config) SetRetryCount(n int) {
+	if n < 0 {
+		n = 0
+	}
+
+	c.retryCount = n
+}
+
+// SetIdempotentMethods marks methods safe to execute more than once, their calls are retried (and failed over
+// by Balancer) after connection level failures even when request was already sent.
+func (c *Config) SetIdempotentMethods(names ...string) {
+	c.idempotentMethods = make(map[string]bool, len(names))
+
+	for _, name := range names {
+		c.idempotentMethods[name] = true
+	}
+}
+
+// isIdempotent checks that method is marked idempotent.
+func (c *Config) isIdempotent(method string) bool {
+	return c.idempotentMethods[method]
+}
+
+// notSentError wraps transport error of request that was not sent, no connection was obtained for it.
+type notSentError struct {
+	err error
+}
+
+func (e *notSentError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap returns underlying transport error.
+func (e *notSentError) Unwrap() error {
+	return e.err
+}
+
+// isNotSent reports whether failed request provably did not reach server.
+func isNotSent(err error) bool {
+	var e *notSentError
+
+	return errors.As(err, &e)
+}
+
+// RetryClassifier decides whether failed call is retried, statusCode is HTTP status code of response,
+// zero for connection level failures when no response was received.
+type RetryClassifier func(err error, statusCode int) bool
+
+// SetRetryClassifier sets classifier of retriable failures, overriding default classification
+// (see DefaultRetryClassifier), so failed responses (HTTP status codes, JSON-RPC error objects) can be retried too.
+// Number of retries is limited by retry count (see SetRetryCount), nil restores default classification.
+// Retries are delayed with randomized exponential backoff, or by Retry-After response header when it asks for longer delay.
+func (c *Config) SetRetryClassifier(f RetryClassifier) {
+	c.retryClassifier = f
+}
+
+// DefaultRetryClassifier retries connection level failures only (HTTP/2 GOAWAY, stream resets, connection resets),
+// custom classifiers can fall back to it.
+func DefaultRetryClassifier(err error, statusCode int) bool {
+	return statusCode == 0 && isRetriableError(err)
+}
+
+// isRetriable classifies connection level failure with configured classifier.
+func (c *Config) isRetriable(err error) bool {
+	if c.retryClassifier != nil {
+		return c.retryClassifier(err, 0)
+	}
+
+	return isRetriableError(err)
+}
+
+// retryBackoff returns randomized delay before retry, attempt is number of failed attempts counted from zero.
+func retryBackoff(attempt int) time.Duration {
+	d := retryBackoffMax
+
+	if attempt < 16 {
+		if v := retryBackoffMin << uint(attempt); v < d {
+			d = v
+		}
+	}
+
+	jitterMu.Lock()
+	defer jitterMu.Unlock()
+
+	// equal jitter, half of delay is random
+	return d/2 + time.Duration(jitterRand.Int63n(int64(d/2)+1))
+}
+
+// retryAfter returns delay requested by server with Retry-After header (seconds or HTTP date), zero when not set.
+func retryAfter(resp *http.Response) time.Duration {
+	v := strings.TrimSpace(resp.Header.Get("Retry-After"))
+	if v == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(v); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+
+		return time.Duration(seconds) * time.Second
+	}
+
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+
+	return 0
+}
+
+// sleepContext waits for duration, returns context error when context is done first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// SetTransport sets custom HTTP transport (RoundTripper) used for requests.
+func (c *Config) SetTransport(rt http.RoundTripper) {
+	c.httpClient.Transport = rt
+}
+
+// nolint:gochecknoglobals
+var retriableErrorMessages = []string{
+	"http2: server sent GOAWAY",     // graceful HTTP/2 connection shutdown
+	"http2: client connection lost", // HTTP/2 connection dropped
+	"stream error:",                 // HTTP/2 stream reset (RST_STREAM)
+	"server closed idle connection", // HTTP/1.1 keep-alive race
+	"connection reset by peer",
+	"broken pipe",
+}
+
+// isRetriableError reports whether transport error is caused by dropped connection,
+// such as HTTP/2 GOAWAY or reset, as opposed to genuine server error.
+func isRetriableError(err error) bool {
+	if err == nil {
+		return false
+	}
+
+	// context cancellation or deadline is never retried
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) ||
+		errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	// HTTP/2 errors of bundled net/http implementation are unexported types
+	msg := err.Error()
+
+	for _, v := range retriableErrorMessages {
+		if strings.Contains(msg, v) {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,40 @@
+package client
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"strconv"
+	"time"
+)
+
+// Request signature headers, see server side VerifySignature middleware.
+const (
+	SignatureHeader          = "X-Signature"
+	SignatureTimestampHeader = "X-Signature-Timestamp"
+)
+
+// SignRequests enables HMAC signing of request body with shared secret, nil hash function defaults to sha256.New.
+// Signature of '<timestamp>.<body>' message is sent in X-Signature header, timestamp in X-Signature-Timestamp header.
+func (c *Config) SignRequests(secret []byte, h func() hash.Hash) {
+	if h == nil {
+		h = sha256.New
+	}
+
+	c.signSecret = secret
+	c.signHash = h
+}
+
+// signRequest returns timestamp and hex encoded HMAC signature of request body.
+func (c *Config) signRequest(body []byte) (string, string) {
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	mac := hmac.New(c.signHash, c.signSecret)
+
+	_, _ = mac.Write([]byte(timestamp))
+	_, _ = mac.Write([]byte("."))
+	_, _ = mac.Write(body)
+
+	return timestamp, hex.EncodeToString(mac.Sum(nil))
+}
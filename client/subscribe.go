@@ -0,0 +1,307 @@
+package client
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Server-sent events stream definitions, match server side.
+const (
+	// EventStreamContentType defines Content-Type of server-sent events stream
+	EventStreamContentType = "text/event-stream"
+	// StatusTrailer defines HTTP trailer with final status of stream
+	StatusTrailer = "X-RPC-Status"
+)
+
+// Subscription reconnection backoff limits.
+const (
+	subscribeBackoffMin = 100 * time.Millisecond
+	subscribeBackoffMax = 5 * time.Second
+)
+
+// errEventTooLarge is returned when server-sent event exceeds response size limit.
+var errEventTooLarge = errors.New("event exceeds size limit")
+
+// subscriptionNotification represents JSON-RPC 2.0 notification pushed to subscriber.
+type subscriptionNotification struct {
+	Method string `json:"method"`
+	Params struct {
+		Subscription string          `json:"subscription"`
+		Result       json.RawMessage `json:"result"`
+	} `json:"params"`
+}
+
+// readEvent reads data of single server-sent event, multiple data lines are joined with new line,
+// events larger than limit fail with error.
+func readEvent(r *bufio.Reader, limit int64) ([]byte, error) {
+	var (
+		data [][]byte
+		size int64
+	)
+
+	for {
+		line, err := readLine(r, limit-size)
+		size += int64(len(line))
+
+		if err != nil {
+			if err == io.EOF && len(data) != 0 {
+				return bytes.Join(data, []byte("\n")), nil
+			}
+
+			return nil, err
+		}
+
+		line = bytes.TrimRight(line, "\r\n")
+
+		// blank line dispatches event
+		if len(line) == 0 {
+			if len(data) != 0 {
+				return bytes.Join(data, []byte("\n")), nil
+			}
+
+			continue
+		}
+
+		// other fields (event, id, retry) and comments are ignored
+		if bytes.HasPrefix(line, []byte("data:")) {
+			data = append(data, bytes.TrimPrefix(bytes.TrimPrefix(line, []byte("data:")), []byte(" ")))
+		}
+	}
+}
+
+// readLine reads single line, lines longer than limit fail with error before being fully buffered.
+func readLine(r *bufio.Reader, limit int64) ([]byte, error) {
+	var line []byte
+
+	for {
+		chunk, err := r.ReadSlice('\n')
+		if int64(len(line)+len(chunk)) > limit {
+			return nil, errEventTooLarge
+		}
+
+		line = append(line, chunk...)
+
+		if err != bufio.ErrBufferFull {
+			return line, err
+		}
+	}
+}
+
+// openSubscription sends subscribe request and reads first event with subscription ID.
+func (c *Config) openSubscription(ctx context.Context, method string, params json.RawMessage) (*http.Response, *bufio.Reader, string, error) {
+	// prepare request object
+	reqObj := getRequestObject(method, params, c.nextID())
+
+	// convert request object to bytes
+	reqData, err := json.Marshal(reqObj)
+	if err != nil {
+		return nil, nil, "", NewInternalError(ErrorPrefix, err)
+	}
+
+	// send request, stream is not limited by config timeout
+	resp, err := c.send(ctx, method, reqData, map[string]string{"Accept": EventStreamContentType})
+	if err != nil {
+		return nil, nil, "", NewInternalError(ErrorPrefix, err)
+	}
+
+	// fail when HTTP status code is different from 200
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+
+		return nil, nil, "", NewInternalError(ErrorPrefix, nil).SetHTTPStatusCodes(resp.StatusCode, http.StatusOK)
+	}
+
+	var data []byte
+
+	br := bufio.NewReader(resp.Body)
+
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), EventStreamContentType) {
+		// first event contains response object
+		data, err = readEvent(br, c.getMaxResponseBytes())
+	} else {
+		// method failed or did not open stream, plain response object
+		data, err = c.readResponseBody(br)
+	}
+
+	if err != nil {
+		resp.Body.Close()
+
+		return nil, nil, "", NewInternalError(ErrorPrefix, err)
+	}
+
+	// prepare response object
+	respObj := new(responseObject)
+
+	// convert response data to object
+	err = json.Unmarshal(data, respObj)
+	if err != nil {
+		resp.Body.Close()
+
+		return nil, nil, "", NewInternalError(ErrorPrefix, err)
+	}
+
+	// validate request/response IDs
+	if !c.disableIDValidation && !equalIDs(reqObj.ID, respObj.ID) {
+		resp.Body.Close()
+
+		return nil, nil, "", NewInternalError(ErrorPrefix, nil).SetRPCIDs(string(respObj.ID), string(reqObj.ID))
+	}
+
+	// check response error
+	if respObj.Error != nil {
+		resp.Body.Close()
+
+		return nil, nil, "", respObj.Error
+	}
+
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), EventStreamContentType) {
+		resp.Body.Close()
+
+		return nil, nil, "", NewInternalError(ErrorPrefix, fmt.Errorf("method '%s' did not open event stream", method))
+	}
+
+	var id string
+
+	// subscription ID is returned as result
+	if err = json.Unmarshal(respObj.Result, &id); err != nil {
+		resp.Body.Close()
+
+		return nil, nil, "", NewInternalError(ErrorPrefix, err)
+	}
+
+	return resp, br, id, nil
+}
+
+// SetSubscriptionErrorHandler sets function that receives error ending subscription (event exceeding
+// response size limit, stream that could not be re-subscribed), subscription channel is closed after function returns.
+func (c *Config) SetSubscriptionErrorHandler(f func(method string, err error)) {
+	c.subscriptionErrorHandler = f
+}
+
+// subscriptionError passes error ending subscription to subscription error handler.
+func (c *Config) subscriptionError(method string, err error) {
+	if c.subscriptionErrorHandler != nil {
+		c.subscriptionErrorHandler(method, err)
+	}
+}
+
+// Subscribe invokes method that opens server-sent events subscription and delivers result of every pushed
+// notification on returned channel, channel is closed when context is cancelled or stream ends.
+// Config timeout and interceptors are not applied to stream. Stream that breaks without final status trailer
+// (X-RPC-Status) is re-subscribed with exponential backoff up to retry count times (SetRetryCount),
+// re-subscription gets new subscription ID from server. Event exceeding response size limit (SetMaxResponseBytes)
+// ends subscription with error, see SetSubscriptionErrorHandler.
+func (c *Config) Subscribe(ctx context.Context, method string, params json.RawMessage) (<-chan json.RawMessage, error) {
+	resp, br, id, err := c.openSubscription(ctx, method, params)
+	if err != nil {
+		return nil, err
+	}
+
+	ch := make(chan json.RawMessage)
+
+	go func() {
+		defer close(ch)
+
+		for {
+			err = stream(ctx, br, id, ch, c.getMaxResponseBytes())
+
+			// stream is not read to the end, remaining body of endless stream is not drained
+			if err != io.EOF {
+				resp.Body.Close()
+
+				if err == errEventTooLarge {
+					c.subscriptionError(method, NewInternalError(ErrorPrefix, err))
+
+					return
+				}
+			} else {
+				// drain remaining body, trailers are available only after body is fully read
+				_, _ = io.Copy(ioutil.Discard, resp.Body)
+				resp.Body.Close()
+			}
+
+			// stream ended normally or consumer is gone
+			if ctx.Err() != nil || resp.Trailer.Get(StatusTrailer) != "" {
+				return
+			}
+
+			if resp, br, id, err = c.resubscribe(ctx, method, params); err != nil {
+				if ctx.Err() == nil {
+					c.subscriptionError(method, err)
+				}
+
+				return
+			}
+		}
+	}()
+
+	return ch, nil
+}
+
+// resubscribe re-opens broken subscription with exponential backoff, up to retry count attempts.
+func (c *Config) resubscribe(ctx context.Context, method string, params json.RawMessage) (*http.Response, *bufio.Reader, string, error) {
+	var (
+		resp *http.Response
+		br   *bufio.Reader
+		id   string
+		err  error
+	)
+
+	backoff := subscribeBackoffMin
+
+	for attempt := 0; attempt < c.retryCount; attempt++ {
+		select {
+		case <-ctx.Done():
+			return nil, nil, "", ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		if backoff *= 2; backoff > subscribeBackoffMax {
+			backoff = subscribeBackoffMax
+		}
+
+		resp, br, id, err = c.openSubscription(ctx, method, params)
+		if err == nil {
+			return resp, br, id, nil
+		}
+	}
+
+	if err == nil {
+		err = NewInternalError(ErrorPrefix, fmt.Errorf("subscription to method '%s' is lost", method))
+	}
+
+	return nil, nil, "", err
+}
+
+// stream reads subscription notifications and pushes their results to channel until stream ends or context is cancelled,
+// returns read error, io.EOF when stream ended cleanly.
+func stream(ctx context.Context, br *bufio.Reader, id string, ch chan<- json.RawMessage, limit int64) error {
+	for {
+		data, err := readEvent(br, limit)
+		if err != nil {
+			return err
+		}
+
+		var notification subscriptionNotification
+
+		// skip events that are not notifications of this subscription
+		if err = json.Unmarshal(data, &notification); err != nil || notification.Params.Subscription != id {
+			continue
+		}
+
+		select {
+		case ch <- notification.Params.Result:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package client
+
+import (
+	"context"
+	"fmt"
+)
+
+// TokenProvider returns bearer token for next request, provider can refresh expired tokens (e.g. OAuth).
+type TokenProvider func(ctx context.Context) (string, error)
+
+// SetTokenProvider sets provider of bearer token, called before each request (including retries)
+// to set 'Authorization: Bearer <token>' header, overrides static Authorization header.
+// Provider error aborts call, nil provider disables it.
+func (c *Config) SetTokenProvider(p TokenProvider) {
+	c.tokenProvider = p
+}
+
+// authorizationHeader returns Authorization header value from token provider.
+func (c *Config) authorizationHeader(ctx context.Context) (string, error) {
+	token, err := c.tokenProvider(ctx)
+	if err != nil {
+		return "", fmt.Errorf("token provider failed: %w", err)
+	}
+
+	return "Bearer " + token, nil
+}
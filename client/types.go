@@ -3,6 +3,7 @@ package client
 import (
 	"encoding/json"
 	"fmt"
+	"hash"
 	"net/http"
 	"strings"
 	"time"
@@ -29,6 +30,51 @@ type Config struct {
 	// Ignore invalid HTTPS certificates
 	insecureSkipVerify bool
 
+	// Generate and send X-Request-ID header per call
+	generateRequestID bool
+
+	// Required JSON-RPC protocol version of responses, exact match or minimal version
+	exactProtocolVersion string
+	minProtocolVersion   string
+
+	// Number of retries for connection level failures (GOAWAY, resets)
+	retryCount int
+	// Classifier of retriable failures, default classification when not set
+	retryClassifier RetryClassifier
+	// Methods safe to execute more than once, retried after request was sent
+	idempotentMethods map[string]bool
+
+	// Generator of request IDs, UUIDv4 strings when not set
+	idGenerator IDGenerator
+
+	// Skip request/response ID match check, UNSAFE!
+	disableIDValidation bool
+
+	// Maximal number of followed method redirects per call, zero disables following
+	maxRedirects int
+
+	// Last rate limit state advertised by server, nil when rate limit is not respected
+	rateLimit *rateLimitState
+
+	// Limit of response body size in bytes, default limit when not set
+	maxResponseBytes int64
+
+	// Debug dump of requests and responses, nil when disabled
+	dump *dumper
+
+	// Interceptors wrap every call, first one is the outermost
+	interceptors []Interceptor
+
+	// Receives errors that end subscriptions, subscription errors are dropped when not set
+	subscriptionErrorHandler func(method string, err error)
+
+	// Provider of bearer token set per request, static Authorization header is used when not set
+	tokenProvider TokenProvider
+
+	// HMAC request signing, disabled when hash function is not set
+	signSecret []byte
+	signHash   func() hash.Hash
+
 	// Custom HTTP client config
 	httpClient *http.Client
 }
@@ -57,6 +103,22 @@ type ResponseObject struct {
 	ID string `json:"id"`
 }
 
+// requestObject is wire form of request object, ID holds raw JSON string or number, omitted for notifications.
+type requestObject struct {
+	Jsonrpc string          `json:"jsonrpc"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+	ID      json.RawMessage `json:"id,omitempty"`
+}
+
+// responseObject is wire form of response object, ID holds raw JSON string or number.
+type responseObject struct {
+	Jsonrpc string          `json:"jsonrpc"`
+	Error   *ErrorObject    `json:"error,omitempty"`
+	Result  json.RawMessage `json:"result,omitempty"`
+	ID      json.RawMessage `json:"id"`
+}
+
 // ErrorObject represents a response error object.
 type ErrorObject struct {
 	// Code indicates the error type that occurred
@@ -148,6 +210,11 @@ func (e *InternalError) SetProtocolVersions(returned, expected string) *Internal
 	return e
 }
 
+// Unwrap returns underlying error.
+func (e *InternalError) Unwrap() error {
+	return e.Err
+}
+
 func (e *InternalError) Error() string {
 	msg := make([]string, 0)
 
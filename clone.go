@@ -0,0 +1,107 @@
+package jrpc2
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Clone creates copy of service object, intended for per-tenant configuration of shared methods.
+// Method map is copied, so methods registered or reconfigured on clone do not affect original service
+// and vice versa, but method functions (and any state captured by them) are shared between services.
+// Headers, authorization, middlewares and limits are copied and can be overridden independently,
+// concurrency limit state is not shared, clone gets its own limiter.
+func (s *Service) Clone() *Service {
+	c := *s
+
+	// registry replacement of clone is independent
+	c.registryMu = new(sync.RWMutex)
+
+	// lifecycle of clone is independent
+	c.lifecycle = newLifecycle()
+
+	if s.methods != nil {
+		c.methods = make(map[string]method, len(s.methods))
+
+		for k, v := range s.methods {
+			// runtime enabled state is not shared
+			if v.Disabled != nil {
+				disabled := atomic.LoadInt32(v.Disabled)
+				v.Disabled = &disabled
+			}
+
+			// concurrent calls are not shared
+			if v.Semaphore != nil {
+				v.Semaphore = make(chan struct{}, cap(v.Semaphore))
+			}
+
+			c.methods[k] = v
+		}
+	}
+
+	if s.aliases != nil {
+		c.aliases = make(map[string]string, len(s.aliases))
+
+		for k, v := range s.aliases {
+			c.aliases[k] = v
+		}
+	}
+
+	if s.headers != nil {
+		c.headers = make(map[string]string, len(s.headers))
+
+		for k, v := range s.headers {
+			c.headers[k] = v
+		}
+	}
+
+	if s.auth != nil {
+		c.auth = make(map[string]authorization, len(s.auth))
+
+		for k, v := range s.auth {
+			c.auth[k] = v
+		}
+	}
+
+	if s.decoders != nil {
+		c.decoders = make(map[string]ContentDecoder, len(s.decoders))
+
+		for k, v := range s.decoders {
+			c.decoders[k] = v
+		}
+	}
+
+	if s.provided != nil {
+		c.provided = make(map[string]struct{}, len(s.provided))
+
+		for k := range s.provided {
+			c.provided[k] = struct{}{}
+		}
+	}
+
+	c.providers = append([]MethodProvider(nil), s.providers...)
+	c.restRoutes = append([]restRoute(nil), s.restRoutes...)
+	c.mws = append([]Middleware(nil), s.mws...)
+	c.onStart = append(s.onStart[:0:0], s.onStart...)
+	c.onShutdown = append(s.onShutdown[:0:0], s.onShutdown...)
+	c.trustedProxies = append(c.trustedProxies[:0:0], s.trustedProxies...)
+
+	if s.flight != nil {
+		c.flight = new(singleflight.Group)
+	}
+
+	if s.cache != nil {
+		c.cache = newResultCache(s.cache.max)
+	}
+
+	if s.capture != nil {
+		c.capture = newRequestCapture(len(s.capture.entries))
+	}
+
+	if s.limiter != nil {
+		c.limiter = newLimiter(s.limiter.max)
+	}
+
+	return &c
+}
@@ -0,0 +1,168 @@
+package jrpc2
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// coalescedResult holds method call result shared between coalesced calls.
+type coalescedResult struct {
+	result  interface{}
+	errObj  *ErrorObject
+	effects *callEffects
+}
+
+// callEffects holds response effects (headers, HTTP status code, warnings) set by shared method execution,
+// effects are applied to response of every coalesced call (or call served from cache).
+type callEffects struct {
+	headers  map[string]string
+	status   int
+	warnings warnings
+}
+
+// newCallEffects creates empty response effects.
+func newCallEffects() *callEffects {
+	return &callEffects{
+		headers: make(map[string]string),
+	}
+}
+
+// context returns context of shared method execution that collects response effects.
+func (e *callEffects) context(ctx context.Context) context.Context {
+	ctx = contextWithHeaders(ctx, e.headers)
+	ctx = contextWithHTTPStatusOverride(ctx, &e.status)
+
+	return contextWithWarnings(ctx, &e.warnings)
+}
+
+// apply sets collected response effects on response of coalesced call.
+func (e *callEffects) apply(r *http.Request) {
+	if r == nil {
+		return
+	}
+
+	if headers := headersFromContext(r.Context()); headers != nil {
+		for header, value := range e.headers {
+			headers[header] = value
+		}
+	}
+
+	if v := httpStatusOverrideFromContext(r.Context()); v != nil && e.status != 0 {
+		*v = e.status
+	}
+
+	if w := warningsFromContext(r.Context()); w != nil {
+		for _, msg := range e.warnings.get() {
+			w.add(msg)
+		}
+	}
+}
+
+// detachedContext carries values of parent context but is never canceled, shared method execution
+// must not be aborted when call that started it is canceled.
+type detachedContext struct {
+	parent context.Context
+}
+
+func (detachedContext) Deadline() (time.Time, bool) { return time.Time{}, false }
+func (detachedContext) Done() <-chan struct{}       { return nil }
+func (detachedContext) Err() error                  { return nil }
+
+func (c detachedContext) Value(key interface{}) interface{} { return c.parent.Value(key) }
+
+// SetMethodCoalescing sets flag that makes identical concurrent calls of registered read method
+// share single method execution, calls are identical when method name, params and caller identity match.
+// Only concurrent duplicates are collapsed, sequential calls are executed as usual (this is not a cache).
+// Result is shared between callers, so method must not return values that are modified afterwards.
+// Only read-only methods (see SetMethodReadOnly) can be coalesced. Shared execution is not canceled
+// with call that started it, response headers, HTTP status code and warnings set by method are applied
+// to every coalesced call response.
+func (s *Service) SetMethodCoalescing(name string, flag bool) error {
+	if flag {
+		if m, ok := s.currentRegistry().methods[name]; ok && !m.ReadOnly {
+			return fmt.Errorf("method '%s' is not read-only", name)
+		}
+	}
+
+	if err := s.updateMethod(name, func(m *method) {
+		m.Coalesce = flag
+	}); err != nil {
+		return err
+	}
+
+	if flag && s.flight == nil {
+		s.flight = new(singleflight.Group)
+	}
+
+	return nil
+}
+
+// coalescingKey returns key of method call, derived from method name, params hash and caller identity.
+func coalescingKey(name string, data ParametersObject) string {
+	sum := sha256.Sum256(data.params)
+
+	return name + "\x00" + hex.EncodeToString(sum[:]) + "\x00" + callerIdentity(data.r)
+}
+
+// callerIdentity returns authenticated principal of request or, when no principal is known,
+// hash of Authorization header (Basic credentials, bearer token), empty for anonymous requests.
+func callerIdentity(r *http.Request) string {
+	if r == nil {
+		return ""
+	}
+
+	if principal := principalFromContext(r.Context()); principal != "" {
+		return "principal:" + principal
+	}
+
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		sum := sha256.Sum256([]byte(auth))
+
+		return "authorization:" + hex.EncodeToString(sum[:])
+	}
+
+	return ""
+}
+
+// coalesce runs method call once for all identical concurrent calls, every call waits for shared
+// execution until its own request context is done.
+func (s *Service) coalesce(name string, data ParametersObject, h Handler) (interface{}, *ErrorObject) {
+	ctx := context.Background()
+	if data.r != nil {
+		ctx = data.r.Context()
+	}
+
+	ch := s.flight.DoChan(coalescingKey(name, data), func() (interface{}, error) {
+		effects := newCallEffects()
+
+		shared := data
+		if data.r != nil {
+			// progress events can only be streamed to single caller
+			shared.r = data.r.WithContext(contextWithProgress(effects.context(detachedContext{parent: ctx}), nil))
+		}
+
+		result, errObj := h(shared)
+
+		return coalescedResult{result: result, errObj: errObj, effects: effects}, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, &ErrorObject{
+			Code:    InternalErrorCode,
+			Message: InternalErrorMessage,
+			Data:    ctx.Err().Error(),
+		}
+	case v := <-ch:
+		res := v.Val.(coalescedResult)
+		res.effects.apply(data.r)
+
+		return res.result, res.errObj
+	}
+}
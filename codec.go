@@ -0,0 +1,110 @@
+package jrpc2
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// NewRequestObject creates JSON-RPC 2.0 request object, enforces same invariants as HTTP server:
+// method must be non-empty and must not match 'rpc.*', params must encode to JSON object or array (or be nil),
+// id must be string, integer number or nil (notification).
+func NewRequestObject(method string, params interface{}, id interface{}) (*RequestObject, error) {
+	if strings.TrimSpace(method) == "" {
+		return nil, errors.New("method name is invalid")
+	}
+
+	if strings.HasPrefix(strings.ToLower(method), "rpc.") {
+		return nil, errors.New("method cannot match the pattern rpc.*")
+	}
+
+	reqObj := &RequestObject{
+		Jsonrpc: JSONRPCVersion,
+		Method:  method,
+	}
+
+	if params != nil {
+		b, err := json.Marshal(params)
+		if err != nil {
+			return nil, fmt.Errorf("params encoding failed: %w", err)
+		}
+
+		if !isStructuredJSON(b) {
+			return nil, errors.New("params must be an object or an array")
+		}
+
+		reqObj.Params = b
+	}
+
+	if id != nil {
+		b, err := json.Marshal(id)
+		if err != nil {
+			return nil, fmt.Errorf("id encoding failed: %w", err)
+		}
+
+		raw := json.RawMessage(b)
+
+		if _, errObj := ConvertIDtoString(&raw); errObj != nil || bytes.Equal(b, []byte("null")) {
+			return nil, errors.New("ID must be one of string, number or undefined")
+		}
+
+		reqObj.ID = &raw
+	}
+
+	return reqObj, nil
+}
+
+// ParseResponseObject decodes JSON-RPC 2.0 response object, enforces same invariants as HTTP server:
+// jsonrpc member must be exactly '2.0', exactly one of result and error members must be present,
+// id member must be present and be string, integer number or null.
+func ParseResponseObject(data []byte) (*ResponseObject, error) {
+	var raw struct {
+		Jsonrpc string          `json:"jsonrpc"`
+		Error   *ErrorObject    `json:"error"`
+		Result  json.RawMessage `json:"result"`
+		ID      json.RawMessage `json:"id"`
+	}
+
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, err
+	}
+
+	if raw.Jsonrpc != JSONRPCVersion {
+		return nil, fmt.Errorf("jsonrpc response member must be exactly '%s'", JSONRPCVersion)
+	}
+
+	hasResult := len(raw.Result) > 0
+
+	if hasResult == (raw.Error != nil) {
+		return nil, errors.New("exactly one of result and error response members must be present")
+	}
+
+	if len(raw.ID) == 0 {
+		return nil, errors.New("id response member must be present")
+	}
+
+	if _, errObj := ConvertIDtoString(&raw.ID); errObj != nil {
+		return nil, errors.New("ID must be one of string, number or null")
+	}
+
+	respObj := DefaultResponseObject()
+	respObj.Error = raw.Error
+	respObj.ID = &raw.ID
+
+	if hasResult {
+		if err := json.Unmarshal(raw.Result, &respObj.Result); err != nil {
+			return nil, err
+		}
+	}
+
+	return respObj, nil
+}
+
+// isStructuredJSON checks that JSON value is an object or an array.
+func isStructuredJSON(b []byte) bool {
+	b = bytes.TrimSpace(b)
+
+	return len(b) > 0 && (b[0] == '{' || b[0] == '[')
+}
@@ -3,6 +3,9 @@ package jrpc2
 // JSONRPCVersion specifies the version of the JSON-RPC protocol.
 const JSONRPCVersion string = "2.0"
 
+// PingMethod specifies name of built-in ping method.
+const PingMethod string = "rpc.ping"
+
 // DefaultUnixSocketMode specifies default permissions for unix socket.
 const DefaultUnixSocketMode = 0777
 
@@ -16,6 +19,13 @@ const (
 	NotImplementedCode int = -32000
 	InvalidIDCode      int = -32001
 	InvalidMethodCode  int = -32002
+	MissingHeaderCode  int = -32003
+	InvalidSignCode    int = -32004
+	MethodMovedCode    int = -32005
+	ServerBusyCode     int = -32006
+	TLSRequiredCode    int = -32007
+	InvalidTokenCode   int = -32008
+	PermissionCode     int = -32009
 )
 
 // Error message.
@@ -28,4 +38,11 @@ const (
 	NotImplementedMessage string = "Not implemented"
 	InvalidIDMessage      string = "Invalid ID"
 	InvalidMethodMessage  string = "Invalid method"
+	MissingHeaderMessage  string = "Missing header"
+	InvalidSignMessage    string = "Invalid signature"
+	MethodMovedMessage    string = "Method moved"
+	ServerBusyMessage     string = "Server busy"
+	TLSRequiredMessage    string = "TLS required"
+	InvalidTokenMessage   string = "Invalid token"
+	PermissionMessage     string = "Permission denied"
 )
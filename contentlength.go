@@ -0,0 +1,36 @@
+package jrpc2
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// SetStrictContentLengthFlag sets flag that enables strict check of declared 'Content-Length' header
+// against number of actually read body bytes, mismatching requests are rejected with parse error.
+// Requests without declared length (chunked transfer encoding) are not checked.
+func (s *Service) SetStrictContentLengthFlag(flag bool) {
+	s.strictContentLength = flag
+}
+
+// GetStrictContentLengthFlag gets strict content length flag from service object.
+func (s *Service) GetStrictContentLengthFlag() bool {
+	return s.strictContentLength
+}
+
+// checkContentLength checks declared request body length against number of read bytes.
+func (s *Service) checkContentLength(r *http.Request, n int) *ErrorObject {
+	// multipart request body is not read at once
+	if uploadFromContext(r.Context()) != nil {
+		return nil
+	}
+
+	if !s.strictContentLength || r.ContentLength < 0 || r.ContentLength == int64(n) {
+		return nil
+	}
+
+	return &ErrorObject{
+		Code:    ParseErrorCode,
+		Message: ParseErrorMessage,
+		Data:    fmt.Sprintf("content length mismatch, declared %d bytes, read %d bytes", r.ContentLength, n),
+	}
+}
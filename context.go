@@ -20,6 +20,20 @@ const (
 	ctxKeyNotificationFlag
 	ctxKeyHTTPStatusCode
 	ctxKeyHeaders
+	ctxKeyRequestID
+	ctxKeySubscription
+	ctxKeyDryRunFlag
+	ctxKeyPrincipal
+	ctxKeyHTTPStatusOverride
+	ctxKeyWarnings
+	ctxKeyRequestBody
+	ctxKeyMethodName
+	ctxKeyRegistry
+	ctxKeyUseNumberFlag
+	ctxKeyUpload
+	ctxKeyReplayFlag
+	ctxKeyProgress
+	ctxKeyJWTClaims
 )
 
 func contextWithBehindReverseProxyFlag(ctx context.Context, flag bool) context.Context {
@@ -226,6 +240,244 @@ func headersFromContext(ctx context.Context) map[string]string {
 	}
 }
 
+func contextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKeyRequestID, id)
+}
+
+func requestIDFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	switch v := ctx.Value(ctxKeyRequestID).(type) {
+	case string:
+		return v
+	default:
+		return ""
+	}
+}
+
+func contextWithSubscription(ctx context.Context, sub *subscription) context.Context {
+	return context.WithValue(ctx, ctxKeySubscription, sub)
+}
+
+func subscriptionFromContext(ctx context.Context) *subscription {
+	if ctx == nil {
+		return nil
+	}
+
+	switch v := ctx.Value(ctxKeySubscription).(type) {
+	case *subscription:
+		return v
+	default:
+		return nil
+	}
+}
+
+func contextWithDryRunFlag(ctx context.Context, flag bool) context.Context {
+	return context.WithValue(ctx, ctxKeyDryRunFlag, flag)
+}
+
+func dryRunFlagFromContext(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+
+	switch v := ctx.Value(ctxKeyDryRunFlag).(type) {
+	case bool:
+		return v
+	default:
+		return false
+	}
+}
+
+func contextWithUseNumberFlag(ctx context.Context, flag bool) context.Context {
+	return context.WithValue(ctx, ctxKeyUseNumberFlag, flag)
+}
+
+func useNumberFlagFromContext(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+
+	switch v := ctx.Value(ctxKeyUseNumberFlag).(type) {
+	case bool:
+		return v
+	default:
+		return false
+	}
+}
+
+func contextWithUpload(ctx context.Context, u *upload) context.Context {
+	return context.WithValue(ctx, ctxKeyUpload, u)
+}
+
+func uploadFromContext(ctx context.Context) *upload {
+	if ctx == nil {
+		return nil
+	}
+
+	switch v := ctx.Value(ctxKeyUpload).(type) {
+	case *upload:
+		return v
+	default:
+		return nil
+	}
+}
+
+func contextWithReplayFlag(ctx context.Context, flag bool) context.Context {
+	return context.WithValue(ctx, ctxKeyReplayFlag, flag)
+}
+
+func replayFlagFromContext(ctx context.Context) bool {
+	if ctx == nil {
+		return false
+	}
+
+	switch v := ctx.Value(ctxKeyReplayFlag).(type) {
+	case bool:
+		return v
+	default:
+		return false
+	}
+}
+
+func contextWithProgress(ctx context.Context, pr *progress) context.Context {
+	return context.WithValue(ctx, ctxKeyProgress, pr)
+}
+
+func progressFromContext(ctx context.Context) *progress {
+	if ctx == nil {
+		return nil
+	}
+
+	switch v := ctx.Value(ctxKeyProgress).(type) {
+	case *progress:
+		return v
+	default:
+		return nil
+	}
+}
+
+func contextWithJWTClaims(ctx context.Context, claims JWTClaims) context.Context {
+	return context.WithValue(ctx, ctxKeyJWTClaims, claims)
+}
+
+func jwtClaimsFromContext(ctx context.Context) JWTClaims {
+	if ctx == nil {
+		return nil
+	}
+
+	switch v := ctx.Value(ctxKeyJWTClaims).(type) {
+	case JWTClaims:
+		return v
+	default:
+		return nil
+	}
+}
+
+func contextWithPrincipal(ctx context.Context, principal string) context.Context {
+	return context.WithValue(ctx, ctxKeyPrincipal, principal)
+}
+
+func principalFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	switch v := ctx.Value(ctxKeyPrincipal).(type) {
+	case string:
+		return v
+	default:
+		return ""
+	}
+}
+
+func contextWithHTTPStatusOverride(ctx context.Context, code *int) context.Context {
+	return context.WithValue(ctx, ctxKeyHTTPStatusOverride, code)
+}
+
+func httpStatusOverrideFromContext(ctx context.Context) *int {
+	if ctx == nil {
+		return nil
+	}
+
+	switch v := ctx.Value(ctxKeyHTTPStatusOverride).(type) {
+	case *int:
+		return v
+	default:
+		return nil
+	}
+}
+
+func contextWithWarnings(ctx context.Context, w *warnings) context.Context {
+	return context.WithValue(ctx, ctxKeyWarnings, w)
+}
+
+func warningsFromContext(ctx context.Context) *warnings {
+	if ctx == nil {
+		return nil
+	}
+
+	switch v := ctx.Value(ctxKeyWarnings).(type) {
+	case *warnings:
+		return v
+	default:
+		return nil
+	}
+}
+
+func contextWithRequestBody(ctx context.Context, body []byte) context.Context {
+	return context.WithValue(ctx, ctxKeyRequestBody, body)
+}
+
+func requestBodyFromContext(ctx context.Context) []byte {
+	if ctx == nil {
+		return nil
+	}
+
+	switch v := ctx.Value(ctxKeyRequestBody).(type) {
+	case []byte:
+		return v
+	default:
+		return nil
+	}
+}
+
+func contextWithMethodName(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, ctxKeyMethodName, name)
+}
+
+func methodNameFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	switch v := ctx.Value(ctxKeyMethodName).(type) {
+	case string:
+		return v
+	default:
+		return ""
+	}
+}
+
+func contextWithRegistry(ctx context.Context, reg *Registry) context.Context {
+	return context.WithValue(ctx, ctxKeyRegistry, reg)
+}
+
+func registryFromContext(ctx context.Context) *Registry {
+	if ctx == nil {
+		return nil
+	}
+
+	switch v := ctx.Value(ctxKeyRegistry).(type) {
+	case *Registry:
+		return v
+	default:
+		return nil
+	}
+}
+
 func (s *Service) setRequestContextEarly(r *http.Request) *http.Request {
 	ctx := r.Context()
 
@@ -249,6 +501,12 @@ func (s *Service) setRequestContextEarly(r *http.Request) *http.Request {
 	ctx = contextWithCertificate(ctx, s.cert)
 	ctx = contextWithProxyFlag(ctx, s.proxy)
 	ctx = contextWithAuthorization(ctx, s.auth)
+	ctx = contextWithRequestID(ctx, getRequestIDFromHeader(r))
+	ctx = contextWithDryRunFlag(ctx, isDryRunRequested(r))
+	ctx = contextWithHTTPStatusOverride(ctx, new(int))
+	ctx = contextWithWarnings(ctx, new(warnings))
+	ctx = contextWithRegistry(ctx, s.currentRegistry())
+	ctx = contextWithUseNumberFlag(ctx, s.useNumber)
 
 	return r.WithContext(ctx)
 }
@@ -0,0 +1,183 @@
+package jrpc2
+
+import (
+	"compress/gzip"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// DefaultMaxDecompressedSize defines default limit of decompressed request body size in bytes.
+const DefaultMaxDecompressedSize = 32 << 20
+
+// errDecompressedTooLarge is returned by decompressed request body reader when size limit is exceeded.
+var errDecompressedTooLarge = errors.New("decompressed request body exceeds size limit")
+
+// ContentDecoder wraps encoded request body with decompressing reader.
+type ContentDecoder func(r io.Reader) (io.ReadCloser, error)
+
+// defaultContentDecoders returns built-in request content decoders.
+func defaultContentDecoders() map[string]ContentDecoder {
+	return map[string]ContentDecoder{
+		"gzip": func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		},
+		"x-gzip": func(r io.Reader) (io.ReadCloser, error) {
+			return gzip.NewReader(r)
+		},
+		"deflate": func(r io.Reader) (io.ReadCloser, error) {
+			return zlib.NewReader(r)
+		},
+	}
+}
+
+// SetRequestDecompressionFlag sets flag that enables decompression of request body according to
+// 'Content-Encoding' header, 'gzip' and 'deflate' are supported by default, other encodings ('br')
+// can be added with SetContentDecoder, requests with unsupported encoding are rejected with parse error.
+// Note that library client sends 'Content-Encoding: gzip' header with uncompressed body,
+// unless compression is disabled in client (DisableCompression).
+func (s *Service) SetRequestDecompressionFlag(flag bool) {
+	s.decompression = flag
+}
+
+// GetRequestDecompressionFlag gets request decompression flag from service object.
+func (s *Service) GetRequestDecompressionFlag() bool {
+	return s.decompression
+}
+
+// SetMaxDecompressedSize sets limit of decompressed request body size in bytes, protects against
+// decompression bombs, requests exceeding limit are rejected with 413 HTTP status code.
+// Zero or negative size restores DefaultMaxDecompressedSize.
+func (s *Service) SetMaxDecompressedSize(n int64) {
+	s.maxDecompressed = n
+}
+
+// GetMaxDecompressedSize gets limit of decompressed request body size in bytes.
+func (s *Service) GetMaxDecompressedSize() int64 {
+	if s.maxDecompressed <= 0 {
+		return DefaultMaxDecompressedSize
+	}
+
+	return s.maxDecompressed
+}
+
+// limitedReadCloser fails reading after limit of bytes is exceeded.
+type limitedReadCloser struct {
+	io.ReadCloser
+
+	remaining int64
+}
+
+// Read reads up to limit, returns errDecompressedTooLarge when data exceeds limit.
+func (lr *limitedReadCloser) Read(p []byte) (int, error) {
+	if lr.remaining < 0 {
+		return 0, errDecompressedTooLarge
+	}
+
+	// read one byte over limit to detect exceeded limit
+	if int64(len(p)) > lr.remaining+1 {
+		p = p[:lr.remaining+1]
+	}
+
+	n, err := lr.ReadCloser.Read(p)
+
+	if int64(n) > lr.remaining {
+		n = int(lr.remaining)
+		lr.remaining = -1
+
+		return n, errDecompressedTooLarge
+	}
+
+	lr.remaining -= int64(n)
+
+	return n, err
+}
+
+// SetContentDecoder sets decoder for request content encoding, overrides built-in decoders,
+// e.g. Brotli decoder for 'br' encoding, nil decoder removes support of encoding.
+func (s *Service) SetContentDecoder(encoding string, dec ContentDecoder) {
+	encoding = strings.ToLower(strings.TrimSpace(encoding))
+
+	if s.decoders == nil {
+		s.decoders = defaultContentDecoders()
+	}
+
+	if dec == nil {
+		delete(s.decoders, encoding)
+
+		return
+	}
+
+	s.decoders[encoding] = dec
+}
+
+// getContentDecoder returns decoder for request content encoding.
+func (s *Service) getContentDecoder(encoding string) (ContentDecoder, bool) {
+	decoders := s.decoders
+	if decoders == nil {
+		decoders = defaultContentDecoders()
+	}
+
+	dec, ok := decoders[encoding]
+
+	return dec, ok
+}
+
+// decodeRequestBody wraps request body with decompressing readers, encodings are removed in reverse order
+// of application, decompressed body is limited in size (see SetMaxDecompressedSize).
+// Returns reader of raw (encoded) body, nil when body is not encoded.
+func (s *Service) decodeRequestBody(r *http.Request) (*http.Request, *countingReader, *ErrorObject) {
+	header := strings.TrimSpace(r.Header.Get("Content-Encoding"))
+	if !s.decompression || header == "" {
+		return r, nil, nil
+	}
+
+	raw := &countingReader{ReadCloser: r.Body}
+
+	var body io.ReadCloser = raw
+
+	encodings := strings.Split(header, ",")
+
+	for i := len(encodings) - 1; i >= 0; i-- {
+		encoding := strings.ToLower(strings.TrimSpace(encodings[i]))
+		if encoding == "identity" {
+			continue
+		}
+
+		dec, ok := s.getContentDecoder(encoding)
+		if !ok {
+			// set Response status code to 415 (unsupported media type)
+			r = setHTTPStatusCode(r, http.StatusUnsupportedMediaType)
+
+			return r, nil, &ErrorObject{
+				Code:    ParseErrorCode,
+				Message: ParseErrorMessage,
+				Data:    fmt.Sprintf("unsupported content encoding '%s'", encoding),
+			}
+		}
+
+		rc, err := dec(body)
+		if err != nil {
+			// set Response status code to 400 (bad request)
+			r = setHTTPStatusCode(r, http.StatusBadRequest)
+
+			return r, nil, &ErrorObject{
+				Code:    ParseErrorCode,
+				Message: ParseErrorMessage,
+				Data:    err.Error(),
+			}
+		}
+
+		body = rc
+	}
+
+	r.Body = &limitedReadCloser{
+		ReadCloser: body,
+		remaining:  s.GetMaxDecompressedSize(),
+	}
+
+	return r, raw, nil
+}
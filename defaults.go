@@ -0,0 +1,66 @@
+package jrpc2
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// RegisterWithDefaults registers method with default named params, defaults JSON object is shallowly merged
+// under client provided params before method is invoked, so omitted members get default values.
+// Client provided members always win, positional (array) params are passed unchanged.
+// Returns error when defaults are not JSON object or method name is in reserved namespace (see RegisterE),
+// optional middlewares are applied the same way as for Register.
+func (s *Service) RegisterWithDefaults(name string, defaults json.RawMessage, f Handler, mws ...Middleware) error {
+	var d map[string]json.RawMessage
+
+	if err := json.Unmarshal(defaults, &d); err != nil || d == nil {
+		return fmt.Errorf("defaults of method '%s' must be JSON object", name)
+	}
+
+	if err := s.checkMethodName(name); err != nil {
+		return err
+	}
+
+	m := newMethod(f, mws)
+	m.Defaults = d
+
+	return s.registerMethod(name, m, false)
+}
+
+// mergeDefaults merges default named params under client provided params.
+func mergeDefaults(defaults map[string]json.RawMessage, params json.RawMessage) json.RawMessage {
+	if len(defaults) == 0 {
+		return params
+	}
+
+	m := make(map[string]json.RawMessage, len(defaults))
+
+	trimmed := bytes.TrimSpace(params)
+
+	// omitted or null params get defaults only
+	if len(trimmed) != 0 && !bytes.Equal(trimmed, []byte("null")) {
+		// positional params are not merged
+		if trimmed[0] != '{' {
+			return params
+		}
+
+		// invalid params are left for method to report
+		if err := json.Unmarshal(trimmed, &m); err != nil {
+			return params
+		}
+	}
+
+	for k, v := range defaults {
+		if _, ok := m[k]; !ok {
+			m[k] = v
+		}
+	}
+
+	b, err := json.Marshal(m)
+	if err != nil {
+		return params
+	}
+
+	return b
+}
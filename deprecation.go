@@ -0,0 +1,70 @@
+package jrpc2
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Deprecation headers.
+const (
+	// DeprecationHeader signals that invoked method is deprecated
+	DeprecationHeader = "Deprecation"
+	// SunsetHeader contains date after which invoked method may be removed (RFC 8594)
+	SunsetHeader = "Sunset"
+)
+
+// deprecation describes deprecated method.
+type deprecation struct {
+	message string
+	sunset  time.Time
+}
+
+// MarkDeprecated marks registered method as deprecated, calls of method get 'Deprecation: true' header,
+// 'Sunset' header (RFC 8594) when sunset date is not zero, deprecation message in 'meta.warnings' member
+// of response and warning entry in logging hook, result of call is not changed.
+func (s *Service) MarkDeprecated(name, message string, sunset time.Time) error {
+	return s.updateMethod(name, func(m *method) {
+		m.Deprecation = &deprecation{
+			message: message,
+			sunset:  sunset,
+		}
+	})
+}
+
+// checkDeprecation surfaces deprecation of invoked method to client and logging hook.
+func (s *Service) checkDeprecation(r *http.Request, name string) {
+	m, ok := s.lookupMethod(r, name)
+	if !ok || m.Deprecation == nil {
+		return
+	}
+
+	if headers := headersFromContext(r.Context()); headers != nil {
+		headers[DeprecationHeader] = "true"
+
+		if !m.Deprecation.sunset.IsZero() {
+			headers[SunsetHeader] = m.Deprecation.sunset.UTC().Format(http.TimeFormat)
+		}
+	}
+
+	msg := fmt.Sprintf("method '%s' is deprecated", name)
+	if m.Deprecation.message != "" {
+		msg = fmt.Sprintf("%s: %s", msg, m.Deprecation.message)
+	}
+
+	if w := warningsFromContext(r.Context()); w != nil {
+		w.add(msg)
+	}
+
+	fields := map[string]interface{}{}
+	if !m.Deprecation.sunset.IsZero() {
+		fields["sunset"] = m.Deprecation.sunset
+	}
+
+	s.logEntry(r, LogEntry{
+		Level:   LogLevelWarning,
+		Message: msg,
+		Method:  name,
+		Fields:  fields,
+	})
+}
@@ -0,0 +1,53 @@
+package jrpc2
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// ErrorDetail represents typed detail object of error, carried in error data member as array of details,
+// lets clients handle specific failure subtypes (e.g. 'QuotaExceeded') while keeping error code standard.
+type ErrorDetail struct {
+	// Type identifies failure subtype
+	Type string `json:"type"`
+	// Value holds subtype specific data
+	Value interface{} `json:"value,omitempty"`
+}
+
+// WithDetails sets error data member to array of typed detail objects, previous data is replaced.
+func (e *ErrorObject) WithDetails(details ...ErrorDetail) *ErrorObject {
+	e.Data = append([]ErrorDetail(nil), details...)
+
+	return e
+}
+
+// Details extracts typed detail objects from error data member, returns error when data is not details array.
+func (e *ErrorObject) Details() ([]ErrorDetail, error) {
+	if e == nil || e.Data == nil {
+		return nil, errors.New("error data is empty")
+	}
+
+	if details, ok := e.Data.([]ErrorDetail); ok {
+		return details, nil
+	}
+
+	// decoded error object, data is generic JSON value
+	b, err := json.Marshal(e.Data)
+	if err != nil {
+		return nil, err
+	}
+
+	var details []ErrorDetail
+
+	if err = json.Unmarshal(b, &details); err != nil {
+		return nil, err
+	}
+
+	for _, detail := range details {
+		if detail.Type == "" {
+			return nil, errors.New("error detail type is empty")
+		}
+	}
+
+	return details, nil
+}
@@ -0,0 +1,119 @@
+package jrpc2
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultDrainTimeout defines time given to in-flight requests to finish when drain is started by admin endpoint.
+const DefaultDrainTimeout = 30 * time.Second
+
+// lifecycle holds runtime state of service used for draining and status reporting.
+type lifecycle struct {
+	started time.Time // time when service was created
+
+	inFlight int64 // number of requests being processed, accessed atomically
+	draining int32 // non-zero when service is draining, accessed atomically
+
+	mu      sync.Mutex
+	servers []*http.Server // servers started by service, shut down after drain
+	drained chan struct{}  // closed when drain is complete
+
+	startOnce sync.Once // guards start hooks
+}
+
+// newLifecycle creates runtime state of service.
+func newLifecycle() *lifecycle {
+	return &lifecycle{
+		started: time.Now(),
+		drained: make(chan struct{}),
+	}
+}
+
+// track counts request as in-flight, returns function that must be called when request is processed.
+func (lc *lifecycle) track() func() {
+	atomic.AddInt64(&lc.inFlight, 1)
+
+	return func() {
+		atomic.AddInt64(&lc.inFlight, -1)
+	}
+}
+
+// isDraining checks that service is draining.
+func (lc *lifecycle) isDraining() bool {
+	return atomic.LoadInt32(&lc.draining) != 0
+}
+
+// isDrained checks that drain is complete.
+func (lc *lifecycle) isDrained() bool {
+	select {
+	case <-lc.drained:
+		return true
+	default:
+		return false
+	}
+}
+
+// addServer registers HTTP server started by service, server is shut down after drain.
+func (lc *lifecycle) addServer(srv *http.Server) {
+	lc.mu.Lock()
+	defer lc.mu.Unlock()
+
+	lc.servers = append(lc.servers, srv)
+}
+
+// Drain gracefully shuts down service: new RPC requests are rejected with 503 HTTP status code,
+// in-flight requests are given time until context is done to finish, then servers started
+// by Start or StartTCPTLS are shut down and shutdown hooks are run (see OnShutdown).
+// Returns context error when in-flight requests did not finish in time.
+func (s *Service) Drain(ctx context.Context) error {
+	lc := s.lifecycle
+
+	// only first call starts drain, other calls wait for its completion
+	if !atomic.CompareAndSwapInt32(&lc.draining, 0, 1) {
+		select {
+		case <-lc.drained:
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+
+	var rerr error
+
+	for rerr == nil && atomic.LoadInt64(&lc.inFlight) > 0 {
+		select {
+		case <-ctx.Done():
+			rerr = ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	lc.mu.Lock()
+	servers := lc.servers
+	lc.mu.Unlock()
+
+	for _, srv := range servers {
+		if err := srv.Shutdown(ctx); err != nil && rerr == nil {
+			rerr = err
+		}
+	}
+
+	// close resources tied to service lifecycle
+	s.runShutdownHooks()
+
+	close(lc.drained)
+
+	return rerr
+}
+
+// IsDraining checks that service is draining and rejects new RPC requests.
+func (s *Service) IsDraining() bool {
+	return s.lifecycle.isDraining()
+}
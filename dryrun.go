@@ -0,0 +1,48 @@
+package jrpc2
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// DryRunHeader defines HTTP header that enables validation-only (dry-run) request mode.
+const DryRunHeader = "X-RPC-Dry-Run"
+
+// isDryRunRequested checks that client requested validation-only (dry-run) mode.
+func isDryRunRequested(r *http.Request) bool {
+	flag, err := strconv.ParseBool(strings.TrimSpace(r.Header.Get(DryRunHeader)))
+	if err != nil {
+		return false
+	}
+
+	return flag
+}
+
+// SetMethodValidator defines params validation function for registered method, enables dry-run mode for method.
+// In dry-run mode (X-RPC-Dry-Run: true) method itself is not invoked, only middlewares and validation function are,
+// successful validation returns 'true' as result, failed validation returns error object from validation function.
+func (s *Service) SetMethodValidator(name string, f func(ParametersObject) *ErrorObject) error {
+	return s.updateMethod(name, func(m *method) {
+		m.Validator = f
+	})
+}
+
+// dryRunHandler returns Handler that runs only validation function of method.
+func dryRunHandler(m method) Handler {
+	return func(data ParametersObject) (interface{}, *ErrorObject) {
+		if m.Validator == nil {
+			return nil, &ErrorObject{
+				Code:    InvalidRequestCode,
+				Message: InvalidRequestMessage,
+				Data:    "method does not support dry-run",
+			}
+		}
+
+		if errObj := m.Validator(data); errObj != nil {
+			return nil, errObj
+		}
+
+		return true, nil
+	}
+}
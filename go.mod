@@ -6,4 +6,5 @@ replace github.com/s3rj1k/jrpc2/client => ./client
 
 require (
 	golang.org/x/crypto v0.0.0-20191011191535-87dc89f01550
+	golang.org/x/sync v0.0.0-20190911185100-cd5d95a43a6e
 )
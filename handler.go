@@ -0,0 +1,107 @@
+package jrpc2
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Handler returns service as stdlib HTTP handler, useful for wrapping with custom middlewares
+// or mounting inside larger http.ServeMux. Requests to paths other than service route
+// (matched using http.ServeMux rules) are passed to not found handler, see SetNotFoundHandler.
+func (s *Service) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !s.isRoutePath(r.URL.Path) {
+			s.getNotFoundHandler().ServeHTTP(w, r)
+
+			return
+		}
+
+		s.ServeHTTP(w, r)
+	})
+}
+
+// SetNotFoundHandler sets handler for requests to paths other than service route,
+// nil handler restores default handler that replies with 404 HTTP status code and JSON-RPC 2.0 error object,
+// use http.NotFoundHandler() for plain 404 responses.
+func (s *Service) SetNotFoundHandler(h http.Handler) {
+	s.notFound = h
+}
+
+// getNotFoundHandler returns configured or default not found handler.
+func (s *Service) getNotFoundHandler() http.Handler {
+	if s.notFound != nil {
+		return s.notFound
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := json.Marshal(
+			ResponseObject{
+				Jsonrpc: JSONRPCVersion,
+				Error: &ErrorObject{
+					Code:    InvalidRequestCode,
+					Message: InvalidRequestMessage,
+					Data:    "endpoint not found",
+				},
+				ID: nullID(),
+			},
+		)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusNotFound)
+
+		_, _ = w.Write(body)
+	})
+}
+
+// isRoutePath checks that request path matches service route, route ending with slash matches subtree.
+// Empty path (request to exact prefix of HandlerStripPrefix) is treated as root path.
+func (s *Service) isRoutePath(path string) bool {
+	if path == "" {
+		path = "/"
+	}
+
+	route := s.route
+	if route == "" {
+		route = "/"
+	}
+
+	if strings.HasSuffix(route, "/") {
+		return strings.HasPrefix(path, route)
+	}
+
+	return path == route
+}
+
+// HandlerStripPrefix returns service as stdlib HTTP handler mounted under path prefix (e.g. '/rpc'),
+// prefix is removed from request URL path before request reaches service.
+func (s *Service) HandlerStripPrefix(prefix string) http.Handler {
+	return http.StripPrefix(strings.TrimSuffix(prefix, "/"), s.Handler())
+}
+
+// TimeoutHandler returns service as stdlib HTTP handler with hard cap on request processing time,
+// requests that take longer are answered with 503 HTTP status code and JSON-RPC 2.0 error object,
+// request context is canceled so methods can stop processing.
+func (s *Service) TimeoutHandler(d time.Duration) http.Handler {
+	body, _ := json.Marshal(
+		ResponseObject{
+			Jsonrpc: JSONRPCVersion,
+			Error: &ErrorObject{
+				Code:    InternalErrorCode,
+				Message: InternalErrorMessage,
+				Data:    "request timeout",
+			},
+			ID: nullID(),
+		},
+	)
+
+	h := http.TimeoutHandler(s, d, string(body))
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// timeout body is written with headers of wrapped writer
+		w.Header().Set("Content-Type", "application/json")
+
+		h.ServeHTTP(w, r)
+	})
+}
@@ -1,9 +1,12 @@
 package jrpc2
 
 import (
+	"bytes"
+	"context"
 	"encoding/json"
-	"io/ioutil"
+	"errors"
 	"net/http"
+	"time"
 )
 
 /*
@@ -14,18 +17,15 @@ import (
 
 // WriteRespose writes JSON-RPC 2.0 response object to HTTP response writer.
 func (s *Service) WriteRespose(w http.ResponseWriter, respObj *ResponseObject) {
-	// set custom response headers
-	var headers = s.headers
+	// method streamed progress, final response is sent as last event
+	if pr := progressFromContext(respObj.r.Context()); pr != nil && pr.isStarted() {
+		s.writeProgressResult(w, respObj, pr)
 
-	// set dynamic response headers
-	for header, value := range headersFromContext(respObj.r.Context()) {
-		headers[header] = value
+		return
 	}
 
-	// set response headers
-	for header, value := range headers {
-		w.Header().Set(header, value)
-	}
+	// set service and dynamic response headers
+	s.setResponseHeaders(w, respObj.r)
 
 	// get HTTP Status code from Request Context
 	statusCode := httpStatusCodeFlagFromContext(respObj.r.Context())
@@ -39,11 +39,29 @@ func (s *Service) WriteRespose(w http.ResponseWriter, respObj *ResponseObject) {
 		return
 	}
 
+	// status code set by method or middleware
+	if v := httpStatusOverrideFromContext(respObj.r.Context()); v != nil && *v != 0 {
+		statusCode = *v
+	}
+
+	// result and error members are mutually exclusive, error takes precedence
+	if respObj.Error != nil {
+		respObj.Result = nil
+
+		// errors are always delivered in body of 200 response in strict mode
+		if s.errorStatusMode == Always200 {
+			statusCode = http.StatusOK
+		}
+	}
+
+	// set non-fatal warnings added by method
+	setResponseMeta(respObj)
+
 	// get response bytes
-	resp := respObj.Marshal()
+	resp := s.marshalResponse(respObj)
 
 	// run response hook function
-	err := s.resp(respObj.r, resp)
+	err := s.resp(respObj.r, s.redactResponse(respObj.r, resp))
 	if err != nil { // hook failed
 		// set response header to custom HTTP code from hook error
 		// or fallback to 500, (internal server error)
@@ -67,13 +85,111 @@ func (s *Service) WriteRespose(w http.ResponseWriter, respObj *ResponseObject) {
 	}
 }
 
+// setResponseHeaders sets custom service response headers and dynamic response headers of request.
+func (s *Service) setResponseHeaders(w http.ResponseWriter, r *http.Request) {
+	// copy to keep service headers intact
+	var headers = getHeaders()
+	defer putHeaders(headers)
+
+	for header, value := range s.headers {
+		headers[header] = value
+	}
+
+	// set dynamic response headers
+	for header, value := range headersFromContext(r.Context()) {
+		headers[header] = value
+	}
+
+	// set response headers
+	for header, value := range headers {
+		w.Header().Set(header, value)
+	}
+}
+
 // ServeHTTP implements needed interface for HTTP library, handles incoming RPC client requests, generates responses.
+// Requests with 'Expect: 100-continue' header receive '100 Continue' interim response
+// only after Authorization check succeeds, then the final response follows.
 func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.ServeHTTPContext(r.Context(), w, r)
+}
+
+// ServeHTTPContext handles incoming RPC client requests the same way as ServeHTTP, but uses supplied context
+// as base of request context instead of request's own context, so values (tracing) set by embedding
+// framework reach methods (see ParametersObject.GetContext).
+func (s *Service) ServeHTTPContext(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	// process ND-JSON request stream line by line, every line is dispatched as separate request
+	if s.ndjson && r.Method == http.MethodPost && isNDJSONRequest(r) {
+		s.serveNDJSON(ctx, w, r)
+
+		return
+	}
+
+	// count request as in-flight, drain waits for in-flight requests
+	defer s.lifecycle.track()()
+
 	// update HTTP request with new context
-	r = s.setRequestContextEarly(r)
+	r = s.setRequestContextEarly(r.WithContext(ctx))
+
+	// set authenticated principal from trusted gateway header
+	r = s.setPrincipalFromHeader(r)
+
+	// record access log line after request is processed
+	if s.accessLog != nil {
+		alw := &accessLogWriter{ResponseWriter: w}
+
+		defer func(r *http.Request, start time.Time) {
+			s.writeAccessLog(alw, r, start)
+		}(r, time.Now())
+
+		w = alw.wrap()
+	}
+
+	// account bytes read and written per request, reported to logging hook
+	var bc *byteCounter
+
+	if s.byteAccounting {
+		bc = newByteCounter(w, r)
+
+		defer func(r *http.Request) {
+			s.logByteCounts(r, bc)
+		}(r)
+
+		w = bc.writer.wrap()
+	}
 
-	// check Basic Authorization
-	if err := s.CheckAuthorization(r); err != nil {
+	// echo request correlation ID, for any response including notifications
+	w.Header().Set(RequestIDHeader, GetRequestID(r))
+
+	// reply to availability probe, no authorization for probes
+	if s.headProbe && r.Method == http.MethodHead {
+		// set custom response headers
+		for header, value := range s.headers {
+			w.Header().Set(header, value)
+		}
+
+		// draining service is reported as unavailable
+		if s.lifecycle.isDraining() {
+			// set response header to 503, (service unavailable)
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		// set response header to 200, (ok)
+		w.WriteHeader(http.StatusOK)
+
+		return
+	}
+
+	// reply to capabilities discovery, no authorization for preflight requests
+	if s.options && r.Method == http.MethodOptions {
+		s.serveOptions(w)
+
+		return
+	}
+
+	// check Basic Authorization, replayed requests are authorized by admin handler
+	if err := s.CheckAuthorization(r); err != nil && !replayFlagFromContext(r.Context()) {
 		// set response header to 403, (forbidden)
 		w.WriteHeader(http.StatusForbidden)
 
@@ -83,17 +199,94 @@ func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// create empty error object
 	var errObj *ErrorObject
 
-	// create default response object
-	respObj := DefaultResponseObject()
+	// create default response object, reused after request is processed
+	respObj := getResponseObject()
+	defer putResponseObject(respObj)
 
 	// set pointer to HTTP request object
 	respObj.r = r
 
-	// read request body as early as possible
-	req, err := ioutil.ReadAll(r.Body)
+	// reject requests not received over HTTPS when required
+	r, errObj = s.checkTLS(r)
+	if errObj != nil {
+		// set pointer to HTTP request object
+		respObj.r = r
+
+		// define Error object
+		respObj.Error = errObj
+
+		// write response to HTTP writer
+		s.WriteRespose(w, respObj)
+
+		// end request processing
+		return
+	}
+
+	// reject new requests while service is draining
+	if s.lifecycle.isDraining() {
+		// set Response status code to 503 (service unavailable)
+		respObj.r = setHTTPStatusCode(r, http.StatusServiceUnavailable)
+
+		// define Error object
+		respObj.Error = &ErrorObject{
+			Code:    InternalErrorCode,
+			Message: InternalErrorMessage,
+			Data:    "service is draining",
+		}
+
+		// write response to HTTP writer
+		s.WriteRespose(w, respObj)
+
+		// end request processing
+		return
+	}
+
+	// decompress encoded request body
+	r, raw, errObj := s.decodeRequestBody(r)
+	if errObj != nil {
+		// set pointer to HTTP request object
+		respObj.r = r
+
+		// define Error object
+		respObj.Error = errObj
+
+		// write response to HTTP writer
+		s.WriteRespose(w, respObj)
+
+		// end request processing
+		return
+	}
+
+	// read request body as early as possible,
+	// for clients that sent 'Expect: 100-continue' header first read of the body
+	// makes HTTP server reply with '100 Continue', so any checks that must reject
+	// request without receiving its body (Authorization) are done before this point
+	var (
+		req []byte
+		err error
+	)
+
+	// multipart request carries JSON-RPC request in first part, file parts are streamed to method
+	if isMultipartRequest(r) {
+		r, req, err = readMultipartRequest(r)
+	} else {
+		// body is read into pooled buffer, buffer is reused after request is processed,
+		// request bytes are copied out so hooks and middlewares may retain them
+		buf := getBodyBuffer()
+		defer putBodyBuffer(buf)
+
+		_, err = buf.ReadFrom(r.Body)
+		req = append([]byte(nil), buf.Bytes()...)
+	}
+
 	if err != nil {
-		// set Response status code to 400 (bad request)
-		r = setHTTPStatusCode(r, http.StatusBadRequest)
+		if errors.Is(err, errDecompressedTooLarge) {
+			// set Response status code to 413 (request entity too large)
+			r = setHTTPStatusCode(r, http.StatusRequestEntityTooLarge)
+		} else {
+			// set Response status code to 400 (bad request)
+			r = setHTTPStatusCode(r, http.StatusBadRequest)
+		}
 
 		// set pointer to HTTP request object
 		respObj.r = r
@@ -112,8 +305,42 @@ func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// reject body that does not match declared length
+	read := len(req)
+
+	// declared length is length of encoded body
+	if raw != nil {
+		read = int(raw.bytes)
+	}
+
+	if errObj = s.checkContentLength(r, read); errObj != nil {
+		// set Response status code to 400 (bad request)
+		respObj.r = setHTTPStatusCode(r, http.StatusBadRequest)
+
+		// define Error object
+		respObj.Error = errObj
+
+		// write response to HTTP writer
+		s.WriteRespose(w, respObj)
+
+		// end request processing
+		return
+	}
+
+	// keep raw request body for middlewares (signature verification)
+	r = r.WithContext(contextWithRequestBody(r.Context(), req))
+
+	// set pointer to HTTP request object
+	respObj.r = r
+
+	// request bytes passed to hooks, redacted
+	redacted := s.redactRequest(r, req)
+
+	// capture request for debug replay
+	s.captureRequest(r, redacted)
+
 	// run request hook function
-	err = s.req(r, req)
+	err = s.req(r, redacted)
 	if err != nil { // hook failed
 		// set response header to custom HTTP code from hook error
 		// or fallback to 500, (internal server error)
@@ -150,8 +377,25 @@ func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// create placeholder for request object
-	reqObj := new(RequestObject)
+	// reject empty request body with clear error instead of parse error
+	if len(bytes.TrimSpace(req)) == 0 {
+		// define Error object
+		respObj.Error = &ErrorObject{
+			Code:    InvalidRequestCode,
+			Message: InvalidRequestMessage,
+			Data:    "empty request body",
+		}
+
+		// write response to HTTP writer
+		s.WriteRespose(w, respObj)
+
+		// end request processing
+		return
+	}
+
+	// create placeholder for request object, reused after request is processed
+	reqObj := getRequestObject()
+	defer putRequestObject(reqObj)
 
 	// decode request body
 	if err := json.Unmarshal(req, &reqObj); err != nil {
@@ -159,7 +403,7 @@ func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		respObj.Error = &ErrorObject{
 			Code:    ParseErrorCode,
 			Message: ParseErrorMessage,
-			Data:    err.Error(),
+			Data:    s.parseErrorData(err, req),
 		}
 
 		// additional error parsing
@@ -213,6 +457,17 @@ func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// set invoked method name
+	r = r.WithContext(contextWithMethodName(r.Context(), reqObj.Method))
+
+	// set pointer to HTTP request object
+	respObj.r = r
+
+	// record method name for byte accounting
+	if bc != nil {
+		bc.method = reqObj.Method
+	}
+
 	// validate JSON-RPC 2.0 request version member
 	if ok := respObj.ValidateJSONRPCVersionNumber(r, reqObj.Jsonrpc); !ok {
 		// write response to HTTP writer
@@ -222,6 +477,25 @@ func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// reject request object with unknown members
+	if r, errObj = s.checkUnknownMembers(r, req); errObj != nil {
+		// set pointer to HTTP request object
+		respObj.r = r
+
+		// define Error object
+		respObj.Error = errObj
+
+		// write response to HTTP writer
+		s.WriteRespose(w, respObj)
+
+		// end request processing
+		return
+	}
+
+	// notification is detected strictly from presence of exact 'id' member in raw request:
+	// '"id": null' is a request with null ID, members matched case-insensitively (e.g. 'ID') are ignored
+	reqObj.ID = getIDMember(req)
+
 	// parse ID member
 	_, errObj = ConvertIDtoString(reqObj.ID)
 	if errObj != nil {
@@ -238,11 +512,69 @@ func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	// set response ID or notification flag
 	if reqObj.ID != nil {
 		respObj.ID = reqObj.ID
+	} else if s.isNotificationAcknowledged(r, reqObj.Method) {
+		// compatibility mode, respond to notification with null ID
+		respObj.ID = nullID()
 	} else {
 		// set status code for notification and notification flag
 		r = setNotification(r)
 	}
 
+	// prepare placeholders for subscription and progress stream, only for requests that accept event stream
+	if reqObj.ID != nil && isEventStreamAccepted(r) {
+		ctx := contextWithSubscription(r.Context(), new(subscription))
+		ctx = contextWithProgress(ctx, &progress{w: w, id: reqObj.ID, headers: s.headers})
+
+		r = r.WithContext(ctx)
+	}
+
+	// check method params size limit
+	r, errObj = s.checkParamsSize(r, reqObj.Method, reqObj.Params)
+	if errObj != nil {
+		// set pointer to HTTP request object
+		respObj.r = r
+
+		// define Error object
+		respObj.Error = errObj
+
+		// write response to HTTP writer
+		s.WriteRespose(w, respObj)
+
+		// end request processing
+		return
+	}
+
+	// check that method accepts multipart requests
+	r, errObj = s.checkMultipart(r, reqObj.Method)
+	if errObj != nil {
+		// set pointer to HTTP request object
+		respObj.r = r
+
+		// define Error object
+		respObj.Error = errObj
+
+		// write response to HTTP writer
+		s.WriteRespose(w, respObj)
+
+		// end request processing
+		return
+	}
+
+	// check method positional params arity limit
+	if errObj = s.checkParamsArity(r, reqObj.Method, reqObj.Params); errObj != nil {
+		// set pointer to HTTP request object
+		respObj.r = r
+
+		// define Error object
+		respObj.Error = errObj
+
+		// write response to HTTP writer
+		s.WriteRespose(w, respObj)
+
+		// end request processing
+		return
+	}
+
 	// set pointer to HTTP request object
 	respObj.r = r
 
@@ -256,8 +588,66 @@ func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		r: r,
 	}
 
+	// pass request metadata when enabled
+	if s.requestMeta {
+		paramsObj.meta = reqObj.Meta
+	}
+
+	// surface deprecation of method
+	s.checkDeprecation(r, reqObj.Method)
+
+	// wait for free method call slot
+	release, errObj := s.acquireCallSlot(r, reqObj.Method)
+	if errObj != nil {
+		// set Response status code to 503 (service unavailable)
+		respObj.r = setHTTPStatusCode(r, http.StatusServiceUnavailable)
+
+		// define Error object
+		respObj.Error = errObj
+
+		// write response to HTTP writer
+		s.WriteRespose(w, respObj)
+
+		// end request processing
+		return
+	}
+
+	// streamed array result is written while method is called
+	var streamed bool
+
 	// invoke named method with the provided parameters
-	respObj.Result, errObj = s.Call(reqObj.Method, paramsObj)
+	respObj.Result, errObj = func() (interface{}, *ErrorObject) {
+		// free method call slot, even when method panics
+		defer release()
+
+		// measure method call duration
+		defer func(start time.Time) {
+			s.checkSlowCall(r, reqObj.Method, time.Since(start))
+		}(time.Now())
+
+		result, errObj := s.callWithProfilerLabels(reqObj.Method, paramsObj)
+
+		// produce streamed result while call slot is held, so call limits and slow call check cover whole stream
+		if sr, ok := result.(*streamResult); ok && errObj == nil {
+			if streamed, errObj = s.writeStreamResult(w, respObj, sr); errObj != nil && !streamed {
+				return nil, errObj
+			}
+		}
+
+		return result, errObj
+	}()
+
+	// record audit trail of state-changing method call, outcome of streamed result is known here
+	s.audit(paramsObj, errObj)
+
+	if streamed {
+		// end request processing
+		return
+	}
+
+	// set cache directives of method response
+	s.setCacheControl(r, reqObj.Method, errObj)
+
 	if errObj != nil {
 		// define Error object
 		respObj.Error = errObj
@@ -269,6 +659,9 @@ func (s *Service) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// write response to HTTP writer
-	s.WriteRespose(w, respObj)
+	// rewrite result member names to requested naming convention
+	respObj.Result = s.transformResultKeys(r, respObj.Result)
+
+	// write response (or subscription stream) to HTTP writer
+	s.WriteSubscription(w, respObj, reqObj.Method)
 } // end request processing
@@ -1,9 +1,11 @@
 package jrpc2
 
 import (
+	"bytes"
 	"encoding/json"
 	"math"
 	"strconv"
+	"strings"
 )
 
 // ConvertIDtoString converts ID parameter to string, also validates ID data type.
@@ -15,8 +17,11 @@ func ConvertIDtoString(id *json.RawMessage) (string, *ErrorObject) {
 
 	var idObj interface{}
 
-	// decoding id to object
-	err := json.Unmarshal(*id, &idObj)
+	// decoding id to object, numbers are kept exact (64-bit integers do not fit float64)
+	dec := json.NewDecoder(bytes.NewReader(*id))
+	dec.UseNumber()
+
+	err := dec.Decode(&idObj)
 	if err != nil {
 		return "", &ErrorObject{
 			Code:    InvalidIDCode,
@@ -27,8 +32,14 @@ func ConvertIDtoString(id *json.RawMessage) (string, *ErrorObject) {
 
 	// checking allowed data types
 	switch v := idObj.(type) {
-	case float64: // json package will assume float64 data type when you Unmarshal into an interface{}
-		if math.Trunc(v) != v { // truncate non integer part from float64
+	case json.Number:
+		// integer literal is returned exactly as received
+		if !strings.ContainsAny(v.String(), ".eE") {
+			return v.String(), nil
+		}
+
+		f, err := v.Float64()
+		if err != nil || math.Trunc(f) != f { // truncate non integer part from float64
 			return "", &ErrorObject{
 				Code:    InvalidIDCode,
 				Message: InvalidIDMessage,
@@ -36,7 +47,7 @@ func ConvertIDtoString(id *json.RawMessage) (string, *ErrorObject) {
 			}
 		}
 
-		return strconv.FormatFloat(v, 'f', 0, 64), nil // convert number to string
+		return strconv.FormatFloat(f, 'f', 0, 64), nil // convert number to string
 	case string:
 		return v, nil
 	case nil:
@@ -49,3 +60,20 @@ func ConvertIDtoString(id *json.RawMessage) (string, *ErrorObject) {
 		}
 	}
 }
+
+// getIDMember returns raw 'id' member of request object, nil when member is missing (notification),
+// null ID is returned as raw 'null' value.
+func getIDMember(data []byte) *json.RawMessage {
+	var members map[string]json.RawMessage
+
+	if err := json.Unmarshal(data, &members); err != nil {
+		return nil
+	}
+
+	id, ok := members["id"]
+	if !ok {
+		return nil
+	}
+
+	return &id
+}
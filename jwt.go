@@ -0,0 +1,468 @@
+package jrpc2
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha256" // register SHA-256 hash function
+	_ "crypto/sha512" // register SHA-384 and SHA-512 hash functions
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// JWT verification defaults.
+const (
+	// DefaultJWKSRefreshInterval defines default lifetime of cached JWKS
+	DefaultJWKSRefreshInterval = time.Hour
+	// DefaultJWKSMinRefreshInterval defines default minimal interval between JWKS fetches caused by unknown key IDs
+	DefaultJWKSMinRefreshInterval = 10 * time.Second
+	// DefaultJWTLeeway defines default allowed clock skew of token time claims
+	DefaultJWTLeeway = time.Minute
+)
+
+// jwksFetchTimeout limits JWKS fetch, fetch is shared by requests and is not canceled with request that started it.
+const jwksFetchTimeout = 10 * time.Second
+
+// JWTConfig defines JWT bearer token verification settings.
+type JWTConfig struct {
+	// JWKSURL defines URL of JSON Web Key Set with token signing keys
+	JWKSURL string
+	// Issuer is required value of 'iss' claim, not checked when empty
+	Issuer string
+	// Audience is required value of 'aud' claim, not checked when empty
+	Audience string
+	// Leeway defines allowed clock skew of 'exp' and 'nbf' claims, defaults to DefaultJWTLeeway
+	Leeway time.Duration
+	// RefreshInterval defines lifetime of cached JWKS, defaults to DefaultJWKSRefreshInterval
+	RefreshInterval time.Duration
+	// MinRefreshInterval limits JWKS fetches on unknown key IDs (key rotation),
+	// defaults to DefaultJWKSMinRefreshInterval, protects key server from tokens with random key IDs
+	MinRefreshInterval time.Duration
+	// HTTPClient is used to fetch JWKS, defaults to client with 10 seconds timeout
+	HTTPClient *http.Client
+}
+
+// JWTClaims contains claims of verified JWT.
+type JWTClaims map[string]interface{}
+
+// GetJWTClaims returns claims of JWT verified by VerifyJWT middleware, nil when token was not verified.
+func (p ParametersObject) GetJWTClaims() JWTClaims {
+	if p.r == nil {
+		return nil
+	}
+
+	return jwtClaimsFromContext(p.r.Context())
+}
+
+// jwtHeader represents JOSE header of JWT.
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// jwk represents public key of JWKS, only RSA and EC keys are supported.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// publicKey converts JWK to public key.
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+
+	case "EC":
+		var curve elliptic.Curve
+
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		case "P-521":
+			curve = elliptic.P521()
+		default:
+			return nil, fmt.Errorf("unsupported curve '%s'", k.Crv)
+		}
+
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+
+		return &ecdsa.PublicKey{
+			Curve: curve,
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}, nil
+
+	default:
+		return nil, fmt.Errorf("unsupported key type '%s'", k.Kty)
+	}
+}
+
+// jwks caches signing keys fetched from JWKS URL.
+type jwks struct {
+	cfg    JWTConfig
+	flight singleflight.Group
+
+	mu       sync.Mutex
+	keys     map[string]crypto.PublicKey
+	fetched  time.Time
+	fetching bool
+}
+
+// fetch downloads JWKS, keys that can not be parsed are skipped.
+func (ks *jwks) fetch(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	req, err := http.NewRequest(http.MethodGet, ks.cfg.JWKSURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := ks.cfg.HTTPClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected JWKS response status code %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+
+	if err = json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]crypto.PublicKey, len(set.Keys))
+
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+
+		if pub, err := k.publicKey(); err == nil {
+			keys[k.Kid] = pub
+		}
+	}
+
+	return keys, nil
+}
+
+// refresh starts JWKS fetch or joins fetch in flight, cached keys are replaced when fetch succeeds.
+func (ks *jwks) refresh() <-chan singleflight.Result {
+	return ks.flight.DoChan(ks.cfg.JWKSURL, func() (interface{}, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), jwksFetchTimeout)
+		defer cancel()
+
+		keys, err := ks.fetch(ctx)
+
+		ks.mu.Lock()
+		defer ks.mu.Unlock()
+
+		ks.fetching = false
+
+		if err != nil {
+			return nil, err
+		}
+
+		ks.keys = keys
+
+		return nil, nil
+	})
+}
+
+// key returns signing key by key ID, JWKS is refreshed when cache is stale or key ID is unknown.
+// Keys are fetched outside of cache lock, known keys of stale cache are served while JWKS is refreshed.
+func (ks *jwks) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	ks.mu.Lock()
+
+	pub, ok := ks.keys[kid]
+
+	since := time.Since(ks.fetched)
+
+	// refresh stale cache or on unknown key (rotation), unknown keys refresh is rate limited
+	refresh := since > ks.cfg.RefreshInterval || (!ok && since > ks.cfg.MinRefreshInterval)
+	if refresh {
+		// failed fetch is retried no sooner than minimal refresh interval
+		ks.fetched = time.Now()
+		ks.fetching = true
+	}
+
+	// unknown key may be added by fetch in flight
+	wait := !ok && ks.fetching
+
+	ks.mu.Unlock()
+
+	if !refresh && !wait {
+		if !ok {
+			return nil, fmt.Errorf("signing key '%s' is unknown", kid)
+		}
+
+		return pub, nil
+	}
+
+	ch := ks.refresh()
+
+	if ok {
+		return pub, nil
+	}
+
+	var err error
+
+	select {
+	case <-ctx.Done():
+		err = ctx.Err()
+	case res := <-ch:
+		err = res.Err
+	}
+
+	ks.mu.Lock()
+	pub, ok = ks.keys[kid]
+	available := ks.keys != nil
+	ks.mu.Unlock()
+
+	if err != nil && !available {
+		return nil, fmt.Errorf("signing keys are unavailable: %v", err)
+	}
+
+	if !ok {
+		return nil, fmt.Errorf("signing key '%s' is unknown", kid)
+	}
+
+	return pub, nil
+}
+
+// verifyJWTSignature verifies signature of JWT signing input with public key.
+func verifyJWTSignature(alg string, pub crypto.PublicKey, input, sig []byte) bool {
+	var h crypto.Hash
+
+	switch alg[2:] {
+	case "256":
+		h = crypto.SHA256
+	case "384":
+		h = crypto.SHA384
+	case "512":
+		h = crypto.SHA512
+	default:
+		return false
+	}
+
+	hasher := h.New()
+	_, _ = hasher.Write(input)
+	digest := hasher.Sum(nil)
+
+	switch key := pub.(type) {
+	case *rsa.PublicKey:
+		return alg[:2] == "RS" && rsa.VerifyPKCS1v15(key, h, digest, sig) == nil
+
+	case *ecdsa.PublicKey:
+		size := (key.Curve.Params().BitSize + 7) / 8
+
+		if alg[:2] != "ES" || len(sig) != 2*size {
+			return false
+		}
+
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+
+		return ecdsa.Verify(key, digest, r, s)
+
+	default:
+		return false
+	}
+}
+
+// hasAudience checks that 'aud' claim (string or array of strings) contains audience.
+func hasAudience(claim interface{}, audience string) bool {
+	switch v := claim.(type) {
+	case string:
+		return v == audience
+	case []interface{}:
+		for _, el := range v {
+			if s, ok := el.(string); ok && s == audience {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// verify parses and verifies JWT, returns token claims or reason of rejection.
+func (ks *jwks) verify(ctx context.Context, token string) (JWTClaims, string) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, "token is malformed"
+	}
+
+	var header jwtHeader
+
+	b, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil || json.Unmarshal(b, &header) != nil {
+		return nil, "token header is malformed"
+	}
+
+	// 'none' and HMAC algorithms are not allowed with public keys
+	if len(header.Alg) != 5 || (header.Alg[:2] != "RS" && header.Alg[:2] != "ES") {
+		return nil, fmt.Sprintf("token algorithm '%s' is not supported", header.Alg)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, "token signature is malformed"
+	}
+
+	pub, err := ks.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err.Error()
+	}
+
+	if !verifyJWTSignature(header.Alg, pub, []byte(parts[0]+"."+parts[1]), sig) {
+		return nil, "token signature mismatch"
+	}
+
+	var claims JWTClaims
+
+	b, err = base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || json.Unmarshal(b, &claims) != nil {
+		return nil, "token claims are malformed"
+	}
+
+	now := time.Now()
+
+	exp, ok := claims["exp"].(float64)
+	if !ok {
+		return nil, "token expiration time is missing"
+	}
+
+	if now.After(time.Unix(int64(exp), 0).Add(ks.cfg.Leeway)) {
+		return nil, "token is expired"
+	}
+
+	if nbf, ok := claims["nbf"].(float64); ok && now.Add(ks.cfg.Leeway).Before(time.Unix(int64(nbf), 0)) {
+		return nil, "token is not valid yet"
+	}
+
+	if ks.cfg.Issuer != "" {
+		if iss, _ := claims["iss"].(string); iss != ks.cfg.Issuer {
+			return nil, "token issuer mismatch"
+		}
+	}
+
+	if ks.cfg.Audience != "" && !hasAudience(claims["aud"], ks.cfg.Audience) {
+		return nil, "token audience mismatch"
+	}
+
+	return claims, ""
+}
+
+// VerifyJWT returns Middleware that verifies JWT bearer token from Authorization header with keys of JWKS,
+// JWKS is cached and refreshed when token is signed with unknown key (key rotation). Signature (RS256, RS384, RS512,
+// ES256, ES384, ES512) and standard claims (exp, nbf, iss, aud) are verified, requests with missing or invalid token
+// are rejected with Invalid token error and 401 HTTP status code. Claims of verified token are available to methods
+// with ParametersObject.GetJWTClaims, 'sub' claim becomes principal when request has no authenticated principal:
+//
+//	s.AddMiddleware(jrpc2.VerifyJWT(jrpc2.JWTConfig{JWKSURL: url, Issuer: issuer, Audience: audience}))
+func VerifyJWT(cfg JWTConfig) Middleware {
+	if cfg.Leeway <= 0 {
+		cfg.Leeway = DefaultJWTLeeway
+	}
+
+	if cfg.RefreshInterval <= 0 {
+		cfg.RefreshInterval = DefaultJWKSRefreshInterval
+	}
+
+	if cfg.MinRefreshInterval <= 0 {
+		cfg.MinRefreshInterval = DefaultJWKSMinRefreshInterval
+	}
+
+	if cfg.HTTPClient == nil {
+		cfg.HTTPClient = &http.Client{Timeout: 10 * time.Second}
+	}
+
+	ks := &jwks{cfg: cfg}
+
+	reject := func(data ParametersObject, msg string) (interface{}, *ErrorObject) {
+		data.SetHTTPStatusCode(http.StatusUnauthorized)
+
+		if data.r != nil {
+			if headers := headersFromContext(data.r.Context()); headers != nil {
+				headers["WWW-Authenticate"] = `Bearer error="invalid_token"`
+			}
+		}
+
+		return nil, &ErrorObject{
+			Code:    InvalidTokenCode,
+			Message: InvalidTokenMessage,
+			Data:    msg,
+		}
+	}
+
+	return func(next Handler) Handler {
+		return func(data ParametersObject) (interface{}, *ErrorObject) {
+			if data.r == nil {
+				return reject(data, "bearer token is missing")
+			}
+
+			auth := strings.TrimSpace(data.r.Header.Get("Authorization"))
+			if len(auth) < 7 || !strings.EqualFold(auth[:7], "Bearer ") {
+				return reject(data, "bearer token is missing")
+			}
+
+			claims, msg := ks.verify(data.r.Context(), strings.TrimSpace(auth[7:]))
+			if claims == nil {
+				return reject(data, msg)
+			}
+
+			ctx := contextWithJWTClaims(data.r.Context(), claims)
+
+			if sub, ok := claims["sub"].(string); ok && sub != "" && principalFromContext(ctx) == "" {
+				ctx = contextWithPrincipal(ctx, sub)
+			}
+
+			data.r = data.r.WithContext(ctx)
+
+			return next(data)
+		}
+	}
+}
@@ -0,0 +1,169 @@
+package jrpc2
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"unicode"
+)
+
+// KeyCaseHeader defines HTTP header that selects key case convention of result members per request,
+// accepted values are 'snake' and 'camel', overrides service-wide setting.
+const KeyCaseHeader = "X-Key-Case"
+
+// KeyCase defines naming convention of object member names in results.
+type KeyCase int
+
+// Key case conventions.
+const (
+	// KeyCaseNone keeps result member names as marshaled by method, default
+	KeyCaseNone KeyCase = iota
+	// KeyCaseSnake rewrites result member names to snake_case
+	KeyCaseSnake
+	// KeyCaseCamel rewrites result member names to camelCase
+	KeyCaseCamel
+)
+
+// SetResultKeyCase sets service-wide naming convention of result member names, nested objects
+// and objects inside arrays are rewritten too, clients can select convention per request with X-Key-Case header.
+func (s *Service) SetResultKeyCase(kc KeyCase) {
+	s.keyCase = kc
+}
+
+// GetResultKeyCase gets service-wide naming convention of result member names from service object.
+func (s *Service) GetResultKeyCase() KeyCase {
+	return s.keyCase
+}
+
+// getKeyCase returns key case convention requested by client or configured for service.
+func (s *Service) getKeyCase(r *http.Request) KeyCase {
+	switch strings.ToLower(strings.TrimSpace(r.Header.Get(KeyCaseHeader))) {
+	case "snake":
+		return KeyCaseSnake
+	case "camel":
+		return KeyCaseCamel
+	default:
+		return s.keyCase
+	}
+}
+
+// transformResultKeys rewrites member names of result to requested key case convention,
+// result is returned unchanged when it can not be transformed.
+func (s *Service) transformResultKeys(r *http.Request, result interface{}) interface{} {
+	kc := s.getKeyCase(r)
+	if kc == KeyCaseNone || result == nil {
+		return result
+	}
+
+	// streamed results are not transformed, whole array would be built
+	if _, ok := result.(*streamResult); ok {
+		return result
+	}
+
+	b, err := json.Marshal(result)
+	if err != nil {
+		return result
+	}
+
+	// keep numbers exactly as marshaled
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+
+	var v interface{}
+
+	if err = dec.Decode(&v); err != nil {
+		return result
+	}
+
+	convert := toSnakeCase
+	if kc == KeyCaseCamel {
+		convert = toCamelCase
+	}
+
+	b, err = json.Marshal(transformKeys(v, convert))
+	if err != nil {
+		return result
+	}
+
+	return json.RawMessage(b)
+}
+
+// transformKeys rewrites member names of nested objects, including objects inside arrays.
+func transformKeys(v interface{}, convert func(string) string) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+
+		for k, el := range t {
+			m[convert(k)] = transformKeys(el, convert)
+		}
+
+		return m
+	case []interface{}:
+		for i, el := range t {
+			t[i] = transformKeys(el, convert)
+		}
+
+		return t
+	default:
+		return v
+	}
+}
+
+// toSnakeCase converts name to snake_case, acronyms are kept as single word ('userID' -> 'user_id').
+func toSnakeCase(name string) string {
+	runes := []rune(name)
+
+	var b strings.Builder
+
+	for i, c := range runes {
+		if unicode.IsUpper(c) {
+			if i > 0 && runes[i-1] != '_' &&
+				(unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+					(i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+				b.WriteRune('_')
+			}
+
+			c = unicode.ToLower(c)
+		}
+
+		b.WriteRune(c)
+	}
+
+	return b.String()
+}
+
+// toCamelCase converts name to camelCase ('user_id' -> 'userId', 'UserName' -> 'userName').
+func toCamelCase(name string) string {
+	var b strings.Builder
+
+	for i, part := range strings.Split(name, "_") {
+		if part == "" {
+			continue
+		}
+
+		runes := []rune(part)
+
+		if i == 0 || b.Len() == 0 {
+			// leading acronym is lowered as whole word ('HTTPRoute' -> 'httpRoute')
+			for j := 0; j < len(runes) && unicode.IsUpper(runes[j]); j++ {
+				if j > 0 && j+1 < len(runes) && unicode.IsLower(runes[j+1]) {
+					break
+				}
+
+				runes[j] = unicode.ToLower(runes[j])
+			}
+		} else {
+			runes[0] = unicode.ToUpper(runes[0])
+		}
+
+		b.WriteString(string(runes))
+	}
+
+	if b.Len() == 0 {
+		return name
+	}
+
+	return b.String()
+}
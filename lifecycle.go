@@ -0,0 +1,31 @@
+package jrpc2
+
+// OnStart registers lifecycle hook that runs when server is started by Start or StartTCPTLS,
+// after listener is bound and before requests are served. Hooks run once, in registration order,
+// so resources tied to service lifecycle (DB pools, caches) can be opened. Must be set before service is started.
+func (s *Service) OnStart(f func()) {
+	s.onStart = append(s.onStart, f)
+}
+
+// OnShutdown registers lifecycle hook that runs when service is drained (see Drain), after in-flight requests
+// finished and servers are shut down. Hooks run once, in reverse registration order, so resources are closed
+// in reverse order of opening.
+func (s *Service) OnShutdown(f func()) {
+	s.onShutdown = append(s.onShutdown, f)
+}
+
+// runStartHooks runs start hooks once per service lifecycle.
+func (s *Service) runStartHooks() {
+	s.lifecycle.startOnce.Do(func() {
+		for _, f := range s.onStart {
+			f()
+		}
+	})
+}
+
+// runShutdownHooks runs shutdown hooks in reverse order.
+func (s *Service) runShutdownHooks() {
+	for i := len(s.onShutdown) - 1; i >= 0; i-- {
+		s.onShutdown[i]()
+	}
+}
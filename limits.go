@@ -0,0 +1,110 @@
+package jrpc2
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// SetMethodMaxParamsSize sets maximal size in bytes of raw params for registered method,
+// zero size (default) disables limit. Requests exceeding limit are rejected before method call
+// with Invalid params error and HTTP 413 status code.
+func (s *Service) SetMethodMaxParamsSize(name string, size int64) error {
+	return s.updateMethod(name, func(m *method) {
+		m.MaxParamsSize = size
+	})
+}
+
+// checkParamsSize validates raw params size against method limit.
+func (s *Service) checkParamsSize(r *http.Request, name string, params []byte) (*http.Request, *ErrorObject) {
+	m, ok := s.lookupMethod(r, name)
+	if !ok || m.MaxParamsSize <= 0 {
+		return r, nil
+	}
+
+	if int64(len(params)) > m.MaxParamsSize {
+		// set Response status code to 413 (request entity too large)
+		r = setHTTPStatusCode(r, http.StatusRequestEntityTooLarge)
+
+		return r, &ErrorObject{
+			Code:    InvalidParamsCode,
+			Message: InvalidParamsMessage,
+			Data:    fmt.Sprintf("params size must not exceed %d bytes", m.MaxParamsSize),
+		}
+	}
+
+	return r, nil
+}
+
+// SetMaxParamsArity sets service-wide maximal number of positional params,
+// zero (default) disables limit. Requests exceeding limit are rejected before method call
+// with Invalid params error.
+func (s *Service) SetMaxParamsArity(n int) {
+	s.maxParamsArity = n
+}
+
+// GetMaxParamsArity gets service-wide maximal number of positional params.
+func (s *Service) GetMaxParamsArity() int {
+	return s.maxParamsArity
+}
+
+// SetMethodMaxParamsArity sets maximal number of positional params for registered method,
+// overriding service-wide limit, zero (default) uses service-wide limit.
+func (s *Service) SetMethodMaxParamsArity(name string, n int) error {
+	return s.updateMethod(name, func(m *method) {
+		m.MaxParamsArity = n
+	})
+}
+
+// checkParamsArity validates number of positional params against method or service-wide limit.
+func (s *Service) checkParamsArity(r *http.Request, name string, params []byte) *ErrorObject {
+	limit := s.maxParamsArity
+
+	if m, ok := s.lookupMethod(r, name); ok && m.MaxParamsArity > 0 {
+		limit = m.MaxParamsArity
+	}
+
+	if limit <= 0 {
+		return nil
+	}
+
+	// only positional params are limited
+	params = bytes.TrimSpace(params)
+	if len(params) == 0 || params[0] != '[' {
+		return nil
+	}
+
+	if countArrayElements(params, limit) > limit {
+		return &ErrorObject{
+			Code:    InvalidParamsCode,
+			Message: InvalidParamsMessage,
+			Data:    fmt.Sprintf("number of positional params must not exceed %d", limit),
+		}
+	}
+
+	return nil
+}
+
+// countArrayElements counts top-level elements of JSON array, counting stops after max elements exceeded.
+func countArrayElements(data []byte, max int) int {
+	dec := json.NewDecoder(bytes.NewReader(data))
+
+	// skip opening bracket
+	if _, err := dec.Token(); err != nil {
+		return 0
+	}
+
+	var n int
+
+	for dec.More() && n <= max {
+		var v json.RawMessage
+		if err := dec.Decode(&v); err != nil {
+			break
+		}
+
+		n++
+	}
+
+	return n
+}
@@ -0,0 +1,64 @@
+package jrpc2
+
+import (
+	"net/http"
+	"time"
+)
+
+// LogLevel defines severity of log entry.
+type LogLevel int
+
+// Log levels.
+const (
+	LogLevelInfo LogLevel = iota
+	LogLevelWarning
+	LogLevelError
+)
+
+// String returns text representation of log level.
+func (l LogLevel) String() string {
+	switch l {
+	case LogLevelInfo:
+		return "info"
+	case LogLevelWarning:
+		return "warning"
+	case LogLevelError:
+		return "error"
+	default:
+		return "unknown"
+	}
+}
+
+// LogEntry represents structured log entry emitted by service.
+type LogEntry struct {
+	// Level indicates severity of log entry
+	Level LogLevel
+	// Message provides a short description of event
+	Message string
+	// RequestID contains request correlation ID (X-Request-ID)
+	RequestID string
+	// Method contains the name of invoked method, if known
+	Method string
+	// Duration contains duration of method call, if measured
+	Duration time.Duration
+	// Fields contains additional event specific data
+	Fields map[string]interface{}
+}
+
+// SetLogHookFunction defines function that will be used as structured logging hook.
+func (s *Service) SetLogHookFunction(f func(r *http.Request, entry LogEntry)) {
+	s.logHook = f
+}
+
+// logEntry passes log entry to logging hook, request correlation ID is set from request.
+func (s *Service) logEntry(r *http.Request, entry LogEntry) {
+	if s.logHook == nil {
+		return
+	}
+
+	if entry.RequestID == "" {
+		entry.RequestID = requestIDFromContext(r.Context())
+	}
+
+	s.logHook(r, entry)
+}
@@ -0,0 +1,67 @@
+package jrpc2
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+)
+
+// requestMembers contains members of JSON-RPC 2.0 request object.
+// nolint:gochecknoglobals
+var requestMembers = map[string]bool{
+	"jsonrpc": true,
+	"method":  true,
+	"params":  true,
+	"id":      true,
+}
+
+// SetDisallowUnknownFieldsFlag sets flag that rejects request objects with members other than
+// 'jsonrpc', 'method', 'params', 'id' and 'meta' when enabled (matched exactly) with Invalid Request error,
+// catches client bugs early, unknown members are ignored by default.
+func (s *Service) SetDisallowUnknownFieldsFlag(flag bool) {
+	s.disallowUnknownFields = flag
+}
+
+// GetDisallowUnknownFieldsFlag gets disallow unknown fields flag from service object.
+func (s *Service) GetDisallowUnknownFieldsFlag() bool {
+	return s.disallowUnknownFields
+}
+
+// checkUnknownMembers validates that request object contains only known members.
+func (s *Service) checkUnknownMembers(r *http.Request, data []byte) (*http.Request, *ErrorObject) {
+	if !s.disallowUnknownFields {
+		return r, nil
+	}
+
+	var members map[string]json.RawMessage
+
+	if err := json.Unmarshal(data, &members); err != nil {
+		return r, nil
+	}
+
+	unknown := make([]string, 0)
+
+	for k := range members {
+		// metadata member is known when enabled
+		if !requestMembers[k] && !(k == "meta" && s.requestMeta) {
+			unknown = append(unknown, k)
+		}
+	}
+
+	if len(unknown) == 0 {
+		return r, nil
+	}
+
+	// deterministic error data
+	sort.Strings(unknown)
+
+	// set Response status code to 400 (bad request)
+	r = setHTTPStatusCode(r, http.StatusBadRequest)
+
+	return r, &ErrorObject{
+		Code:    InvalidRequestCode,
+		Message: InvalidRequestMessage,
+		Data:    fmt.Sprintf("request object contains unknown member '%s'", unknown[0]),
+	}
+}
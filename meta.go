@@ -0,0 +1,13 @@
+package jrpc2
+
+// SetRequestMetaFlag sets flag that enables non-standard top-level 'meta' request member,
+// carrying request metadata (trace IDs, tenant, locale) separately from params.
+// Metadata is available to methods and middlewares with ParametersObject.Meta, ignored when disabled (default).
+func (s *Service) SetRequestMetaFlag(flag bool) {
+	s.requestMeta = flag
+}
+
+// GetRequestMetaFlag gets request metadata flag from service object.
+func (s *Service) GetRequestMetaFlag() bool {
+	return s.requestMeta
+}
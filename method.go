@@ -1,7 +1,67 @@
 package jrpc2
 
+import (
+	"encoding/json"
+	"sync/atomic"
+	"time"
+)
+
+// Handler defines JSON-RPC 2.0 method function.
+type Handler func(ParametersObject) (interface{}, *ErrorObject)
+
+// Middleware defines function that wraps Handler with additional logic.
+type Middleware func(Handler) Handler
+
 // method represents an JSON-RPC 2.0 method.
 type method struct {
 	// Method is the callable function
-	Method func(ParametersObject) (interface{}, *ErrorObject)
+	Method Handler
+	// Middlewares wrap callable function, applies only to this method
+	Middlewares []Middleware
+	// Validator validates params without side effects, used in dry-run mode
+	Validator func(ParametersObject) *ErrorObject
+	// MaxParamsSize limits size of raw params in bytes, zero disables limit
+	MaxParamsSize int64
+	// MaxParamsArity limits number of positional params, zero uses service-wide limit
+	MaxParamsArity int
+	// Multipart permits multipart/form-data requests with file parts
+	Multipart bool
+	// Semaphore limits concurrent calls of method, nil when method has no own limit
+	Semaphore chan struct{}
+	// SlowThreshold overrides service-wide slow call threshold, zero uses service-wide threshold
+	SlowThreshold time.Duration
+	// Disabled makes method unavailable without unregistering it, non-zero when disabled, accessed atomically
+	Disabled *int32
+	// AckNotifications forces response with null ID for notifications, non-spec compatibility option
+	AckNotifications bool
+	// Coalesce makes identical concurrent calls share single method execution
+	Coalesce bool
+	// CacheTTL makes successful results reused for identical calls until expired, zero disables cache
+	CacheTTL time.Duration
+	// RedactPaths contains paths of fields redacted before request/response bytes are passed to hooks
+	RedactPaths []string
+	// Deprecation marks method as deprecated, nil when method is not deprecated
+	Deprecation *deprecation
+	// ReadOnly marks method without state changes, read-only methods are not audited
+	ReadOnly bool
+	// CacheControl contains Cache-Control directives of successful responses, empty for 'no-store'
+	CacheControl string
+	// Scope contains permission required from caller, see RegisterWithScope
+	Scope string
+	// Defaults contains default named params merged under client provided params, see RegisterWithDefaults
+	Defaults map[string]json.RawMessage
+}
+
+// isDisabled checks that method is disabled at runtime.
+func (m method) isDisabled() bool {
+	return m.Disabled != nil && atomic.LoadInt32(m.Disabled) != 0
+}
+
+// chain wraps handler with provided middlewares, first middleware is the outermost one.
+func chain(h Handler, mws ...Middleware) Handler {
+	for i := len(mws) - 1; i >= 0; i-- {
+		h = mws[i](h)
+	}
+
+	return h
 }
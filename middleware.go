@@ -0,0 +1,85 @@
+package jrpc2
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// FieldsHeader defines HTTP header with comma separated list of requested top-level result fields.
+const FieldsHeader = "X-RPC-Fields"
+
+// SparseFields is a Middleware that prunes top-level fields of object result
+// to ones requested by client in X-RPC-Fields header, opt-in per method:
+//
+//	s.Register("user.get", UserGet, jrpc2.SparseFields)
+//
+// Results that are not JSON objects and requests without header are left intact.
+func SparseFields(next Handler) Handler {
+	return func(data ParametersObject) (interface{}, *ErrorObject) {
+		result, errObj := next(data)
+		if errObj != nil || data.r == nil {
+			return result, errObj
+		}
+
+		header := strings.TrimSpace(data.r.Header.Get(FieldsHeader))
+		if header == "" {
+			return result, nil
+		}
+
+		b, err := json.Marshal(result)
+		if err != nil {
+			return result, nil
+		}
+
+		fields := make(map[string]json.RawMessage)
+
+		// only objects can be pruned
+		if err = json.Unmarshal(b, &fields); err != nil {
+			return result, nil
+		}
+
+		pruned := make(map[string]json.RawMessage)
+
+		for _, name := range strings.Split(header, ",") {
+			name = strings.TrimSpace(name)
+
+			if v, ok := fields[name]; ok {
+				pruned[name] = v
+			}
+		}
+
+		return pruned, nil
+	}
+}
+
+// RequireHeaders returns Middleware that rejects calls missing any of required HTTP headers
+// with Missing header error and 400 HTTP status code:
+//
+//	s.AddMiddleware(jrpc2.RequireHeaders("X-Tenant-ID"))
+func RequireHeaders(names ...string) Middleware {
+	return func(next Handler) Handler {
+		return func(data ParametersObject) (interface{}, *ErrorObject) {
+			var missing []string
+
+			for _, name := range names {
+				if data.r == nil || strings.TrimSpace(data.r.Header.Get(name)) == "" {
+					missing = append(missing, name)
+				}
+			}
+
+			if len(missing) > 0 {
+				data.SetHTTPStatusCode(http.StatusBadRequest)
+
+				return nil, &ErrorObject{
+					Code:    MissingHeaderCode,
+					Message: MissingHeaderMessage,
+					Data:    fmt.Sprintf("missing required headers: %s", strings.Join(missing, ", ")),
+				}
+			}
+
+			return next(data)
+		}
+	}
+}
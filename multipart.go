@@ -0,0 +1,122 @@
+package jrpc2
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"sync"
+)
+
+// MultipartRequestPart defines form name of multipart part that contains JSON-RPC 2.0 request,
+// the part must be first part of multipart/form-data request body, other parts are files.
+const MultipartRequestPart = "request"
+
+// upload holds multipart reader of request body, file parts are read on demand by method.
+type upload struct {
+	mu     sync.Mutex
+	reader *multipart.Reader
+}
+
+// SetMethodMultipart permits registered method to be called with multipart/form-data request,
+// where first part (see MultipartRequestPart) is JSON-RPC 2.0 request and other parts are files
+// streamed to method (see ParametersObject.File), instead of base64 encoded params.
+// Multipart requests for other methods are rejected with 415 HTTP status code.
+func (s *Service) SetMethodMultipart(name string, flag bool) error {
+	return s.updateMethod(name, func(m *method) {
+		m.Multipart = flag
+	})
+}
+
+// File returns reader of named file part of multipart request, file parts are streamed,
+// so they must be read in order they were sent, parts before requested one are skipped.
+func (p ParametersObject) File(name string) (io.Reader, *ErrorObject) {
+	var u *upload
+
+	if p.r != nil {
+		u = uploadFromContext(p.r.Context())
+	}
+
+	if u == nil {
+		return nil, &ErrorObject{
+			Code:    InvalidParamsCode,
+			Message: InvalidParamsMessage,
+			Data:    "request is not multipart",
+		}
+	}
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+
+	for {
+		part, err := u.reader.NextPart()
+		if err != nil {
+			return nil, &ErrorObject{
+				Code:    InvalidParamsCode,
+				Message: InvalidParamsMessage,
+				Data:    fmt.Sprintf("file '%s' is not uploaded", name),
+			}
+		}
+
+		if part.FormName() == name {
+			return part, nil
+		}
+	}
+}
+
+// isMultipartRequest checks that request body is multipart/form-data.
+func isMultipartRequest(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+
+	return err == nil && mediaType == "multipart/form-data"
+}
+
+// readMultipartRequest reads JSON-RPC 2.0 request from first part of multipart request,
+// remaining parts are kept in request context for method.
+func readMultipartRequest(r *http.Request) (*http.Request, []byte, error) {
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return r, nil, err
+	}
+
+	part, err := mr.NextPart()
+	if err != nil {
+		return r, nil, err
+	}
+
+	if part.FormName() != MultipartRequestPart {
+		return r, nil, fmt.Errorf("first multipart part must be '%s'", MultipartRequestPart)
+	}
+
+	data, err := ioutil.ReadAll(part)
+	if err != nil {
+		return r, nil, err
+	}
+
+	r = r.WithContext(contextWithUpload(r.Context(), &upload{reader: mr}))
+
+	return r, data, nil
+}
+
+// checkMultipart rejects multipart request for method that does not accept it.
+func (s *Service) checkMultipart(r *http.Request, name string) (*http.Request, *ErrorObject) {
+	if uploadFromContext(r.Context()) == nil {
+		return r, nil
+	}
+
+	// unknown method is reported by method call
+	if m, ok := s.lookupMethod(r, name); !ok || m.Multipart {
+		return r, nil
+	}
+
+	// set Response status code to 415 (unsupported media type)
+	r = setHTTPStatusCode(r, http.StatusUnsupportedMediaType)
+
+	return r, &ErrorObject{
+		Code:    InvalidRequestCode,
+		Message: InvalidRequestMessage,
+		Data:    fmt.Sprintf("method '%s' does not accept multipart requests", name),
+	}
+}
@@ -0,0 +1,124 @@
+package jrpc2
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// NDJSONContentType defines Content-Type of newline-delimited JSON request streams and responses.
+const NDJSONContentType = "application/x-ndjson"
+
+// maxNDJSONLineSize defines maximal size of single ND-JSON line in bytes.
+const maxNDJSONLineSize = 4 << 20
+
+// SetNDJSONFlag sets ND-JSON flag in service object, when enabled POST request with 'application/x-ndjson'
+// Content-Type is processed as stream of independent JSON-RPC 2.0 requests, one request object per line.
+// Every line is dispatched as separate request (Authorization, limits, hooks are applied per line) and
+// responses are streamed back in order as ND-JSON lines as soon as they are ready, notifications produce no lines.
+// Memory usage is bounded by single line, so huge batches can be ingested without building JSON array.
+func (s *Service) SetNDJSONFlag(flag bool) {
+	s.ndjson = flag
+}
+
+// GetNDJSONFlag gets ND-JSON flag from service object.
+func (s *Service) GetNDJSONFlag() bool {
+	return s.ndjson
+}
+
+// isNDJSONRequest checks that request body is ND-JSON stream.
+func isNDJSONRequest(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Content-Type"), NDJSONContentType)
+}
+
+// writeNDJSONError writes single ND-JSON line with error response object.
+func writeNDJSONError(w http.ResponseWriter, errObj *ErrorObject) {
+	respObj := DefaultResponseObject()
+	respObj.Error = errObj
+
+	_, _ = w.Write(append(respObj.Marshal(), '\n'))
+}
+
+// serveNDJSON dispatches every line of ND-JSON request stream as JSON-RPC 2.0 request over HTTP/1.1
+// and streams responses back as ND-JSON lines.
+func (s *Service) serveNDJSON(ctx context.Context, w http.ResponseWriter, r *http.Request) {
+	// stream is rejected as a whole before body is read
+	if err := s.CheckAuthorization(r); err != nil {
+		// set response header to 403, (forbidden)
+		w.WriteHeader(http.StatusForbidden)
+
+		return
+	}
+
+	r, _, errObj := s.decodeRequestBody(r.WithContext(ctx))
+	if errObj != nil {
+		w.Header().Set("Content-Type", NDJSONContentType)
+		w.WriteHeader(httpStatusCodeFlagFromContext(r.Context()))
+		writeNDJSONError(w, errObj)
+
+		return
+	}
+
+	for header, value := range s.headers {
+		w.Header().Set(header, value)
+	}
+
+	w.Header().Set("Content-Type", NDJSONContentType)
+	w.WriteHeader(http.StatusOK)
+
+	flusher, _ := w.(http.Flusher)
+
+	scanner := bufio.NewScanner(r.Body)
+	scanner.Buffer(make([]byte, 0, 64<<10), maxNDJSONLineSize)
+
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		// request body is read by dispatched request, copy line out of scanner buffer
+		reqData := append([]byte(nil), line...)
+
+		lineReq := r.Clone(ctx)
+		lineReq.Method = http.MethodPost
+		lineReq.Proto, lineReq.ProtoMajor, lineReq.ProtoMinor = "HTTP/1.1", 1, 1
+		lineReq.Body = ioutil.NopCloser(bytes.NewReader(reqData))
+		lineReq.ContentLength = int64(len(reqData))
+		lineReq.TransferEncoding = nil
+		lineReq.Header.Del("Content-Encoding")
+		lineReq.Header.Del("Expect")
+		lineReq.Header.Set("Content-Type", "application/json")
+		lineReq.Header.Set("Accept", "application/json")
+
+		rec := newResponseRecorder()
+
+		s.ServeHTTPContext(ctx, rec, lineReq)
+
+		// notifications and rejected requests without body produce no lines
+		if rec.body.Len() == 0 {
+			continue
+		}
+
+		rec.body.WriteByte('\n')
+
+		if _, err := w.Write(rec.body.Bytes()); err != nil {
+			return
+		}
+
+		if flusher != nil {
+			flusher.Flush()
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		writeNDJSONError(w, &ErrorObject{
+			Code:    ParseErrorCode,
+			Message: ParseErrorMessage,
+			Data:    err.Error(),
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package jrpc2
+
+import (
+	"encoding/json"
+	"net/http"
+)
+
+// SetMethodNotificationAck sets notification acknowledgment flag for registered method.
+// When enabled, notifications (requests without ID) to method receive regular response with null ID
+// instead of empty 204 response. This is not JSON-RPC 2.0 compliant, use only as compatibility
+// escape hatch for semi-compliant clients, default is strict 204 response.
+func (s *Service) SetMethodNotificationAck(name string, flag bool) error {
+	return s.updateMethod(name, func(m *method) {
+		m.AckNotifications = flag
+	})
+}
+
+// isNotificationAcknowledged checks that method responds to notifications.
+func (s *Service) isNotificationAcknowledged(r *http.Request, name string) bool {
+	m, ok := s.lookupMethod(r, name)
+
+	return ok && m.AckNotifications
+}
+
+// nullID returns JSON null as request ID.
+func nullID() *json.RawMessage {
+	id := json.RawMessage("null")
+
+	return &id
+}
@@ -0,0 +1,111 @@
+package jrpc2
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+)
+
+// SetUseNumberFlag sets flag that makes ParametersObject.UnmarshalParams decode numbers
+// into interface{} values as json.Number instead of float64, preserving precision of large integers.
+func (s *Service) SetUseNumberFlag(flag bool) {
+	s.useNumber = flag
+}
+
+// GetUseNumberFlag gets use number flag from service object.
+func (s *Service) GetUseNumberFlag() bool {
+	return s.useNumber
+}
+
+// UnmarshalParams decodes params member of JSON-RPC 2.0 request into v,
+// numbers are decoded as json.Number when enabled by service (see SetUseNumberFlag).
+func (p ParametersObject) UnmarshalParams(v interface{}) *ErrorObject {
+	dec := json.NewDecoder(bytes.NewReader(p.GetRawJSONParams()))
+
+	if p.r != nil && useNumberFlagFromContext(p.r.Context()) {
+		dec.UseNumber()
+	}
+
+	if err := dec.Decode(v); err != nil {
+		return &ErrorObject{
+			Code:    InvalidParamsCode,
+			Message: InvalidParamsMessage,
+			Data:    err.Error(),
+		}
+	}
+
+	return nil
+}
+
+// GetInt64Param parses named param member of JSON-RPC 2.0 request as int64, without loss of precision.
+func (p ParametersObject) GetInt64Param(field string) (int64, *ErrorObject) {
+	n, errObj := p.getNumberParam(field)
+	if errObj != nil {
+		return 0, errObj
+	}
+
+	v, err := n.Int64()
+	if err != nil {
+		return 0, &ErrorObject{
+			Code:    InvalidParamsCode,
+			Message: InvalidParamsMessage,
+			Data:    fmt.Sprintf("param '%s' must be integer: %s", field, err),
+		}
+	}
+
+	return v, nil
+}
+
+// GetFloat64Param parses named param member of JSON-RPC 2.0 request as float64.
+func (p ParametersObject) GetFloat64Param(field string) (float64, *ErrorObject) {
+	n, errObj := p.getNumberParam(field)
+	if errObj != nil {
+		return 0, errObj
+	}
+
+	v, err := n.Float64()
+	if err != nil {
+		return 0, &ErrorObject{
+			Code:    InvalidParamsCode,
+			Message: InvalidParamsMessage,
+			Data:    fmt.Sprintf("param '%s' must be number: %s", field, err),
+		}
+	}
+
+	return v, nil
+}
+
+// getNumberParam extracts named param member of JSON-RPC 2.0 request as json.Number.
+func (p ParametersObject) getNumberParam(field string) (json.Number, *ErrorObject) {
+	params := make(map[string]json.RawMessage)
+
+	err := json.Unmarshal(p.GetRawJSONParams(), &params)
+	if err != nil {
+		return "", &ErrorObject{
+			Code:    InvalidParamsCode,
+			Message: InvalidParamsMessage,
+			Data:    err.Error(),
+		}
+	}
+
+	raw, ok := params[field]
+	if !ok {
+		return "", &ErrorObject{
+			Code:    InvalidParamsCode,
+			Message: InvalidParamsMessage,
+			Data:    fmt.Sprintf("param '%s' is required", field),
+		}
+	}
+
+	var n json.Number
+
+	if err = json.Unmarshal(raw, &n); err != nil {
+		return "", &ErrorObject{
+			Code:    InvalidParamsCode,
+			Message: InvalidParamsMessage,
+			Data:    fmt.Sprintf("param '%s' must be number: %s", field, err),
+		}
+	}
+
+	return n, nil
+}
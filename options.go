@@ -0,0 +1,58 @@
+package jrpc2
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// SetOptionsFlag sets OPTIONS flag in service object, when enabled OPTIONS request is answered
+// with 204 HTTP status code, 'Allow' header and custom service headers (e.g. CORS headers),
+// without Authorization check and without parsing JSON-RPC request.
+func (s *Service) SetOptionsFlag(flag bool) {
+	s.options = flag
+}
+
+// GetOptionsFlag gets OPTIONS flag from service object.
+func (s *Service) GetOptionsFlag() bool {
+	return s.options
+}
+
+// SetDescriptionURL sets URL of service description document (e.g. OpenRPC), advertised in OPTIONS response
+// with 'Link' header of 'service-desc' relation, empty URL (default) disables header.
+func (s *Service) SetDescriptionURL(url string) {
+	s.descriptionURL = url
+}
+
+// GetDescriptionURL gets URL of service description document from service object.
+func (s *Service) GetDescriptionURL() string {
+	return s.descriptionURL
+}
+
+// allowedMethods returns HTTP methods accepted by RPC endpoint.
+func (s *Service) allowedMethods() string {
+	methods := []string{http.MethodOptions, http.MethodPost}
+
+	if s.headProbe {
+		methods = append([]string{http.MethodHead}, methods...)
+	}
+
+	return strings.Join(methods, ", ")
+}
+
+// serveOptions replies to OPTIONS request with capabilities of RPC endpoint.
+func (s *Service) serveOptions(w http.ResponseWriter) {
+	// set custom response headers
+	for header, value := range s.headers {
+		w.Header().Set(header, value)
+	}
+
+	w.Header().Set("Allow", s.allowedMethods())
+
+	if s.descriptionURL != "" {
+		w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"service-desc\"", s.descriptionURL))
+	}
+
+	// set response header to 204, (no content)
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -2,14 +2,32 @@ package jrpc2
 
 import (
 	"bufio"
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
 	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"mime/multipart"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"reflect"
+	"runtime/pprof"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 )
@@ -850,6 +868,615 @@ func TestСontextWithHeaders(t *testing.T) {
 	_verifyequal(t, headers, newHeaders)
 }
 
+func TestContextWithRequestID(t *testing.T) {
+	ctx := context.Background()
+
+	id := requestIDFromContext(ctx)
+	_verifyequal(t, id, "")
+
+	ctx = contextWithRequestID(ctx, "test-request-id")
+	id = requestIDFromContext(ctx)
+	_verifyequal(t, id, "test-request-id")
+}
+
+func TestRequestIDValidation(t *testing.T) {
+	for id, valid := range map[string]bool{
+		"test-request-id":                         true,
+		"f81d4fae-7dec-11d0-a765-00a0c91e6bf6":    true,
+		"":                                        false,
+		"with space":                              false,
+		"injected\r\nSet-Cookie: session=value":   false,
+		"tab\tseparated":                          false,
+		"non-ascii-\u00e9":                        false,
+		strings.Repeat("a", maxRequestIDLength):   true,
+		strings.Repeat("a", maxRequestIDLength+1): false,
+	} {
+		_verifyequal(t, isValidRequestID(id), valid)
+	}
+
+	req := httptest.NewRequest("POST", "http://localhost", nil)
+	req.Header[RequestIDHeader] = []string{"injected\r\nSet-Cookie: session=value"}
+
+	id := getRequestIDFromHeader(req)
+	_verifyequal(t, len(id), 32)
+	_verifyequal(t, strings.ContainsAny(id, "\r\n"), false)
+}
+
+func TestWriteResponseErrorPrecedence(t *testing.T) {
+	testService := Create("")
+
+	w := httptest.NewRecorder()
+
+	respObj := DefaultResponseObject()
+	respObj.r = httptest.NewRequest("POST", "http://localhost", nil)
+	respObj.Result = "must not be sent"
+	respObj.Error = &ErrorObject{
+		Code:    InternalErrorCode,
+		Message: InternalErrorMessage,
+	}
+
+	testService.WriteRespose(w, respObj)
+
+	decoded := make(map[string]interface{})
+
+	err := json.Unmarshal(w.Body.Bytes(), &decoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, ok := decoded["result"]; ok {
+		t.Fatal("expected result member to be absent when error is set")
+	}
+
+	if _, ok := decoded["error"]; !ok {
+		t.Fatal("expected error member to be present")
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	_verifyequal(t, levenshtein("", ""), 0)
+	_verifyequal(t, levenshtein("user.create", "user.create"), 0)
+	_verifyequal(t, levenshtein("user.creat", "user.create"), 1)
+	_verifyequal(t, levenshtein("kitten", "sitting"), 3)
+	_verifyequal(t, levenshtein("", "abc"), 3)
+}
+
+func TestMethodNotFoundSuggestion(t *testing.T) {
+	testService := Create("")
+	testService.Register("user.create", Update)
+	testService.Register("user.delete", Update)
+
+	testreq := httptest.NewRequest("POST", "http://localhost", nil)
+
+	// suggestions are disabled by default
+	_, errObj := testService.Call("user.creat", ParametersObject{r: testreq})
+	_verifyerrobj(t, errObj, MethodNotFoundCode, MethodNotFoundMessage)
+	_verifyequal(t, errObj.Data, nil)
+
+	testService.SetMethodSuggestionDistance(2)
+	_verifyequal(t, testService.GetMethodSuggestionDistance(), 2)
+
+	// close match
+	_, errObj = testService.Call("user.creat", ParametersObject{r: testreq})
+	_verifyerrobj(t, errObj, MethodNotFoundCode, MethodNotFoundMessage)
+	_verifyequal(t, errObj.Data, "did you mean 'user.create'?")
+
+	// no match within distance
+	_, errObj = testService.Call("account.get", ParametersObject{r: testreq})
+	_verifyerrobj(t, errObj, MethodNotFoundCode, MethodNotFoundMessage)
+	_verifyequal(t, errObj.Data, nil)
+}
+
+func TestTrustedPrincipalHeader(t *testing.T) {
+	testService := Create("")
+	testService.SetBehindReverseProxyFlag(false)
+	testService.SetTrustedPrincipalHeader("X-Authenticated-User")
+	_verifyequal(t, testService.GetTrustedPrincipalHeader(), "X-Authenticated-User")
+
+	err := testService.SetTrustedProxies([]string{"10.0.0.0/8"})
+	if err != nil {
+		t.Fatalf("unexpected error '%s'", err)
+	}
+
+	_verifyequal(t, testService.GetTrustedProxies(), []string{"10.0.0.0/8"})
+
+	err = testService.SetTrustedProxies([]string{"10.0.0.0"})
+	_verifyequal(t, err == nil, false) // expecting error
+
+	testService.Register("whoami", func(data ParametersObject) (interface{}, *ErrorObject) {
+		return []string{data.GetPrincipal(), data.GetHeaders().Get("X-Authenticated-User")}, nil
+	})
+
+	// trusted source
+	req := _newrpcrequest(`{"jsonrpc": "2.0", "method": "whoami", "id": 1}`)
+	req.RemoteAddr = "10.1.2.3:4567"
+	req.Header.Set("X-Authenticated-User", "alice")
+
+	_, respObj := _serverpc(t, testService, req)
+	_verifyequal(t, respObj.Result, []interface{}{"alice", "alice"})
+
+	// untrusted source, header must be stripped
+	req = _newrpcrequest(`{"jsonrpc": "2.0", "method": "whoami", "id": 1}`)
+	req.RemoteAddr = "8.8.8.8:4567"
+	req.Header.Set("X-Authenticated-User", "alice")
+
+	_, respObj = _serverpc(t, testService, req)
+	_verifyequal(t, respObj.Result, []interface{}{"", ""})
+}
+
+func TestHeadProbe(t *testing.T) {
+	testService := Create("")
+
+	// HEAD requests are rejected by default
+	w, _ := _serverpc(t, testService, httptest.NewRequest("HEAD", "http://localhost/", nil))
+	_verifyequal(t, w.Code, http.StatusMethodNotAllowed)
+
+	testService.SetHeadProbeFlag(true)
+	_verifyequal(t, testService.GetHeadProbeFlag(), true)
+
+	w, _ = _serverpc(t, testService, httptest.NewRequest("HEAD", "http://localhost/", nil))
+	_verifyequal(t, w.Code, http.StatusOK)
+	_verifyequal(t, w.Body.Len(), 0)
+}
+
+func TestMethodMaxParamsSize(t *testing.T) {
+	testService := Create("")
+	testService.Register("upload", Update)
+
+	err := testService.SetMethodMaxParamsSize("upload", 16)
+	if err != nil {
+		t.Fatalf("unexpected error '%s'", err)
+	}
+
+	err = testService.SetMethodMaxParamsSize("unknown", 16)
+	_verifyequal(t, err == nil, false) // expecting error
+
+	w, respObj := _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "upload", "params": [1, 2], "id": 1}`))
+	_verifyequal(t, w.Code, http.StatusOK)
+	_verifyequal(t, respObj.Error, (*ErrorObject)(nil))
+
+	w, respObj = _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "upload", "params": [1, 2, 3, 4, 5, 6, 7, 8], "id": 1}`))
+	_verifyequal(t, w.Code, http.StatusRequestEntityTooLarge)
+	_verifyerrobj(t, respObj.Error, InvalidParamsCode, InvalidParamsMessage)
+}
+
+func TestParseErrorSnippet(t *testing.T) {
+	testService := Create("")
+
+	body := `{"jsonrpc": "2.0", "method": "update", "id": 1, }`
+
+	// snippets are disabled by default
+	_, respObj := _serverpc(t, testService, _newrpcrequest(body))
+	_verifyerrobj(t, respObj.Error, ParseErrorCode, ParseErrorMessage)
+
+	if _, ok := respObj.Error.Data.(string); !ok {
+		t.Fatal("expected error data to be a string")
+	}
+
+	testService.SetParseErrorSnippetLength(10)
+	_verifyequal(t, testService.GetParseErrorSnippetLength(), 10)
+
+	_, respObj = _serverpc(t, testService, _newrpcrequest(body))
+	_verifyerrobj(t, respObj.Error, ParseErrorCode, ParseErrorMessage)
+
+	data, ok := respObj.Error.Data.(map[string]interface{})
+	if !ok {
+		t.Fatal("expected error data to be an object")
+	}
+
+	_verifyequal(t, data["offset"], float64(strings.Index(body, "}")+1))
+	_verifyequal(t, data["snippet"], `"id": 1, }`)
+
+	// control characters are sanitized
+	_verifyequal(t, sanitizeSnippet([]byte("a\x00b\nc")), "a.b.c")
+}
+
+func TestMethodNotificationAck(t *testing.T) {
+	testService := Create("")
+	testService.Register("update", Update)
+	testService.Register("echo", func(data ParametersObject) (interface{}, *ErrorObject) {
+		return data.GetMethodName(), nil
+	})
+
+	err := testService.SetMethodNotificationAck("echo", true)
+	if err != nil {
+		t.Fatalf("unexpected error '%s'", err)
+	}
+
+	err = testService.SetMethodNotificationAck("unknown", true)
+	_verifyequal(t, err == nil, false) // expecting error
+
+	// strict 204 by default
+	w, respObj := _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "update"}`))
+	_verifyequal(t, w.Code, http.StatusNoContent)
+	_verifyequal(t, respObj, (*ResponseObject)(nil))
+
+	// acknowledged notification
+	w, respObj = _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "echo"}`))
+	_verifyequal(t, w.Code, http.StatusOK)
+	_verifyequal(t, respObj.Result, "echo")
+
+	if !strings.Contains(w.Body.String(), `"id":null`) {
+		t.Fatalf("expected null ID in response '%s'", w.Body.String())
+	}
+}
+
+func TestPriorityQueue(t *testing.T) {
+	testService := Create("")
+	testService.SetMaxConcurrency(1)
+	_verifyequal(t, testService.GetMaxConcurrency(), 1)
+
+	testService.SetPriorityFunction(func(r *http.Request, _ string) int {
+		priority, _ := strconv.Atoi(r.Header.Get("X-Priority"))
+
+		return priority
+	})
+
+	var mu sync.Mutex
+
+	order := make([]string, 0)
+	block := make(chan struct{})
+
+	testService.Register("block", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		<-block
+
+		return nil, nil
+	})
+	testService.Register("record", func(data ParametersObject) (interface{}, *ErrorObject) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		order = append(order, data.GetHeaders().Get("X-Priority"))
+
+		return nil, nil
+	})
+
+	var wg sync.WaitGroup
+
+	serve := func(method, priority string) {
+		defer wg.Done()
+
+		req := _newrpcrequest(fmt.Sprintf(`{"jsonrpc": "2.0", "method": "%s", "id": 1}`, method))
+		req.Header.Set("X-Priority", priority)
+
+		testService.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	waitQueued := func(n int) {
+		for testService.limiter.waiting() != n {
+			time.Sleep(time.Millisecond)
+		}
+	}
+
+	// occupy the only slot
+	wg.Add(1)
+
+	go serve("block", "0")
+
+	for {
+		testService.limiter.mu.Lock()
+		active := testService.limiter.active
+		testService.limiter.mu.Unlock()
+
+		if active == 1 {
+			break
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	// queue low priority calls before high priority one
+	for i, priority := range []string{"1", "1", "10"} {
+		wg.Add(1)
+
+		go serve("record", priority)
+
+		waitQueued(i + 1)
+	}
+
+	close(block)
+	wg.Wait()
+
+	_verifyequal(t, order, []string{"10", "1", "1"})
+}
+
+func TestServiceClone(t *testing.T) {
+	base := Create("")
+	base.SetHeaders(map[string]string{"Server": "base"})
+	base.Register("update", Update)
+
+	tenant := base.Clone()
+	tenant.SetHeaders(map[string]string{"Server": "tenant"})
+	tenant.GetHeaders()["X-Tenant"] = "A"
+	tenant.Register("tenant.only", Update)
+	tenant.SetMaxConcurrency(2)
+
+	err := tenant.AddAuthorization(username, password, []string{"127.0.0.1/32"})
+	if err != nil {
+		t.Fatalf("unexpected error '%s'", err)
+	}
+
+	// original service is not affected
+	_verifyequal(t, base.GetHeaders(), map[string]string{"Server": "base"})
+	_verifyequal(t, base.GetMaxConcurrency(), 0)
+	_verifyequal(t, base.auth == nil, true)
+
+	_, ok := base.methods["tenant.only"]
+	_verifyequal(t, ok, false)
+
+	// shared methods are callable on clone
+	testreq := httptest.NewRequest("POST", "http://localhost", nil)
+
+	_, errObj := tenant.Call("update", ParametersObject{r: testreq})
+	_verifyequal(t, errObj, (*ErrorObject)(nil))
+
+	// headers map of clone is independent
+	clone := base.Clone()
+	clone.GetHeaders()["Server"] = "clone"
+
+	_verifyequal(t, base.GetHeaders()["Server"], "base")
+}
+
+func TestSparseFields(t *testing.T) {
+	testService := Create("")
+
+	user := func(_ ParametersObject) (interface{}, *ErrorObject) {
+		return map[string]interface{}{
+			"id":    1,
+			"name":  "alice",
+			"email": "alice@example.com",
+		}, nil
+	}
+
+	testService.Register("user.get", user, SparseFields)
+	testService.Register("user.full", user)
+
+	// selected fields
+	req := _newrpcrequest(`{"jsonrpc": "2.0", "method": "user.get", "id": 1}`)
+	req.Header.Set(FieldsHeader, "id, name, unknown")
+
+	_, respObj := _serverpc(t, testService, req)
+	_verifyequal(t, respObj.Result, map[string]interface{}{"id": float64(1), "name": "alice"})
+
+	// no header, all fields
+	req = _newrpcrequest(`{"jsonrpc": "2.0", "method": "user.get", "id": 1}`)
+
+	_, respObj = _serverpc(t, testService, req)
+	_verifyequal(t, len(respObj.Result.(map[string]interface{})), 3)
+
+	// method without opt-in ignores header
+	req = _newrpcrequest(`{"jsonrpc": "2.0", "method": "user.full", "id": 1}`)
+	req.Header.Set(FieldsHeader, "id")
+
+	_, respObj = _serverpc(t, testService, req)
+	_verifyequal(t, len(respObj.Result.(map[string]interface{})), 3)
+}
+
+func TestBatchNotImplemented(t *testing.T) {
+	var calls int
+
+	testService := Create("")
+	testService.Register("count", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		calls++
+
+		return nil, nil
+	})
+
+	entry := `{"jsonrpc": "2.0", "method": "count", "id": 1}`
+
+	// batches of any size are rejected wholesale with the same error
+	for _, n := range []int{1, 2, 101} {
+		w, respObj := _serverpc(t, testService, _newrpcrequest("["+strings.Repeat(entry+",", n-1)+entry+"]"))
+		_verifyequal(t, w.Code, http.StatusOK)
+		_verifyerrobj(t, respObj.Error, NotImplementedCode, NotImplementedMessage)
+	}
+
+	_verifyequal(t, calls, 0)
+}
+
+func TestBytes(t *testing.T) {
+	data := []byte{0x00, 0xff, 0xfe, 'j', 'r', 'p', 'c'}
+
+	b, err := json.Marshal(struct {
+		Blob Bytes `json:"blob"`
+	}{Blob: data})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, string(b), `{"blob":"AP/+anJwYw=="}`)
+
+	// standard, URL-safe and raw encodings are accepted
+	for _, encoded := range []string{`"AP/+anJwYw=="`, `"AP_-anJwYw=="`, `"AP_-anJwYw"`} {
+		var decoded Bytes
+
+		if err = json.Unmarshal([]byte(encoded), &decoded); err != nil {
+			t.Fatal(err)
+		}
+
+		_verifyequal(t, []byte(decoded), data)
+	}
+
+	params := ParametersObject{params: []byte(`{"blob": "AP/+anJwYw==", "other": 1}`)}
+
+	blob, errObj := params.UnmarshalBytes("blob")
+	if errObj != nil {
+		t.Fatalf("unexpected error '%v'", errObj)
+	}
+
+	_verifyequal(t, blob, data)
+
+	_, errObj = params.UnmarshalBytes("missing")
+	_verifyerrobj(t, errObj, InvalidParamsCode, InvalidParamsMessage)
+}
+
+func TestAccessLog(t *testing.T) {
+	var buf bytes.Buffer
+
+	testService := Create("")
+	testService.Register("update", Update)
+	testService.SetAccessLog(&buf, CombinedLogFormat)
+
+	req := _newrpcrequest(`{"jsonrpc": "2.0", "method": "update", "id": 1}`)
+	req.Header.Set("X-Real-IP", "192.0.2.1")
+	req.Header.Set("User-Agent", "test-agent")
+
+	_serverpc(t, testService, req)
+
+	// pre-dispatch failure
+	req = httptest.NewRequest("GET", "http://localhost/", nil)
+	req.Header.Set("X-Real-IP", "192.0.2.1")
+
+	_serverpc(t, testService, req)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("expected 2 access log lines, got %d", len(lines))
+	}
+
+	if !strings.HasPrefix(lines[0], "192.0.2.1 - - [") ||
+		!strings.Contains(lines[0], `"POST / HTTP/1.1" 200 `) ||
+		!strings.Contains(lines[0], `"-" "test-agent"`) {
+		t.Fatalf("unexpected access log line '%s'", lines[0])
+	}
+
+	if !strings.Contains(lines[1], `"GET / HTTP/1.1" 405 `) {
+		t.Fatalf("unexpected access log line '%s'", lines[1])
+	}
+
+	// disabled access log
+	buf.Reset()
+	testService.SetAccessLog(nil, AccessLogDisabled)
+
+	_serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "update", "id": 1}`))
+	_verifyequal(t, buf.Len(), 0)
+}
+
+func TestAccessLogEscaping(t *testing.T) {
+	var buf bytes.Buffer
+
+	testService := Create("")
+	testService.Register("update", Update)
+	testService.SetAccessLog(&buf, CommonLogFormat)
+
+	// user name and request URI can not forge access log lines
+	req := _newrpcrequest(`{"jsonrpc": "2.0", "method": "update", "id": 1}`)
+	req.URL.RawQuery = `q="x`
+	req.SetBasicAuth("evil\n192.0.2.1 - admin", "password")
+
+	_serverpc(t, testService, req)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 1 {
+		t.Fatalf("expected single access log line, got %d", len(lines))
+	}
+
+	if !strings.Contains(lines[0], ` - evil\n192.0.2.1 - admin [`) ||
+		!strings.Contains(lines[0], `"POST /?q=\"x HTTP/1.1"`) {
+		t.Fatalf("unexpected access log line '%s'", lines[0])
+	}
+}
+
+func TestAccessLogWriterFlusher(t *testing.T) {
+	// recorder supports flushing
+	w := (&accessLogWriter{ResponseWriter: httptest.NewRecorder()}).wrap()
+
+	_, ok := w.(http.Flusher)
+	_verifyequal(t, ok, true)
+
+	// writer without flushing support is not reported as flusher
+	w = (&accessLogWriter{ResponseWriter: struct{ http.ResponseWriter }{httptest.NewRecorder()}}).wrap()
+
+	_, ok = w.(http.Flusher)
+	_verifyequal(t, ok, false)
+}
+
+func TestConditionalRegistration(t *testing.T) {
+	testService := Create("")
+	testService.RegisterIf(true, "feature.on", Update)
+	testService.RegisterIf(false, "feature.off", Update)
+
+	testreq := httptest.NewRequest("POST", "http://localhost", nil)
+
+	_, errObj := testService.Call("feature.on", ParametersObject{r: testreq})
+	_verifyequal(t, errObj, (*ErrorObject)(nil))
+
+	_, errObj = testService.Call("feature.off", ParametersObject{r: testreq})
+	_verifyerrobj(t, errObj, MethodNotFoundCode, MethodNotFoundMessage)
+
+	// toggle method off and on at runtime
+	err := testService.SetMethodEnabled("feature.on", false)
+	if err != nil {
+		t.Fatalf("unexpected error '%s'", err)
+	}
+
+	_, errObj = testService.Call("feature.on", ParametersObject{r: testreq})
+	_verifyerrobj(t, errObj, MethodNotFoundCode, MethodNotFoundMessage)
+
+	err = testService.SetMethodEnabled("feature.on", true)
+	if err != nil {
+		t.Fatalf("unexpected error '%s'", err)
+	}
+
+	_, errObj = testService.Call("feature.on", ParametersObject{r: testreq})
+	_verifyequal(t, errObj, (*ErrorObject)(nil))
+
+	err = testService.SetMethodEnabled("feature.off", true)
+	_verifyequal(t, err == nil, false) // expecting error
+}
+
+func TestSlowThreshold(t *testing.T) {
+	testService := Create("")
+	testService.SetSlowThreshold(time.Hour)
+	_verifyequal(t, testService.GetSlowThreshold(), time.Hour)
+
+	slow := func(_ ParametersObject) (interface{}, *ErrorObject) {
+		time.Sleep(20 * time.Millisecond)
+
+		return nil, nil
+	}
+
+	testService.Register("slow", slow)
+	testService.Register("slow.default", slow)
+
+	err := testService.SetMethodSlowThreshold("slow", 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("unexpected error '%s'", err)
+	}
+
+	var mu sync.Mutex
+
+	entries := make([]LogEntry, 0)
+
+	testService.SetLogHookFunction(func(_ *http.Request, entry LogEntry) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		entries = append(entries, entry)
+	})
+
+	// method threshold overrides service-wide threshold
+	_serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "slow.default", "id": 1}`))
+	_verifyequal(t, len(entries), 0)
+
+	_serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "slow", "id": 1}`))
+
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 log entry, got %d", len(entries))
+	}
+
+	_verifyequal(t, entries[0].Level, LogLevelWarning)
+	_verifyequal(t, entries[0].Method, "slow")
+
+	if entries[0].Duration < 10*time.Millisecond {
+		t.Fatalf("expected duration to exceed threshold, got %s", entries[0].Duration)
+	}
+
+	if entries[0].RequestID == "" {
+		t.Fatal("expected request ID in log entry")
+	}
+}
+
 // verifies that err contains code and message
 func _verifyerr(t *testing.T, err error, code int, message string) {
 	if !strings.Contains(err.Error(), strconv.Itoa(code)) {
@@ -901,3 +1528,2983 @@ func _createfile(t *testing.T, path string, contents []byte) {
 
 	wr.Flush()
 }
+
+func TestRegisterWithMiddleware(t *testing.T) {
+	testService := Create("")
+
+	calls := make([]string, 0)
+
+	mw := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return func(data ParametersObject) (interface{}, *ErrorObject) {
+				calls = append(calls, name)
+
+				return next(data)
+			}
+		}
+	}
+
+	testService.AddMiddleware(mw("service"))
+	testService.Register("protected", Update, mw("first"), mw("second"))
+	testService.Register("public", Update)
+
+	testreq := httptest.NewRequest("POST", "http://localhost", nil)
+
+	_, errObj := testService.Call("protected", ParametersObject{method: "protected", r: testreq})
+	if errObj != nil {
+		t.Fatalf("unexpected error '%v'", errObj)
+	}
+
+	_verifyequal(t, calls, []string{"service", "first", "second"})
+
+	calls = calls[:0]
+
+	_, errObj = testService.Call("public", ParametersObject{method: "public", r: testreq})
+	if errObj != nil {
+		t.Fatalf("unexpected error '%v'", errObj)
+	}
+
+	_verifyequal(t, calls, []string{"service"})
+}
+
+// creates JSON-RPC 2.0 HTTP request with default headers
+func _newrpcrequest(body string) *http.Request {
+	req := httptest.NewRequest("POST", "http://localhost/", strings.NewReader(body))
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+
+	return req
+}
+
+// serves HTTP request by service, decodes JSON-RPC 2.0 response
+func _serverpc(t *testing.T, s *Service, req *http.Request) (*httptest.ResponseRecorder, *ResponseObject) {
+	w := httptest.NewRecorder()
+
+	s.ServeHTTP(w, req)
+
+	if w.Body.Len() == 0 {
+		return w, nil
+	}
+
+	respObj := new(ResponseObject)
+
+	if err := json.Unmarshal(w.Body.Bytes(), respObj); err != nil {
+		t.Fatalf("unexpected error '%s'", err)
+	}
+
+	return w, respObj
+}
+
+func TestReservedMethodNamespace(t *testing.T) {
+	register := func(s *Service, name string) error {
+		return s.RegisterE(name, Update)
+	}
+
+	testService := Create("")
+
+	// reserved namespace is rejected by default
+	_verifyequal(t, register(testService, "rpc.ping") == nil, false)
+	_verifyequal(t, register(testService, "RPC.discover") == nil, false)
+	_verifyequal(t, register(testService, "system.listMethods") == nil, false)
+	_verifyequal(t, register(testService, "user.get"), error(nil))
+
+	_, ok := testService.methods["system.listMethods"]
+	_verifyequal(t, ok, false)
+
+	// Register does not validate method names
+	testService.Register("system.describe", Update)
+
+	_, ok = testService.methods["system.describe"]
+	_verifyequal(t, ok, true)
+
+	// override flag permits reserved methods
+	testService.AllowReservedMethods(true)
+	_verifyequal(t, register(testService, "rpc.custom"), error(nil))
+
+	testreq := httptest.NewRequest("POST", "http://localhost", nil)
+
+	_, errObj := testService.Call("rpc.custom", ParametersObject{r: testreq})
+	_verifyequal(t, errObj, (*ErrorObject)(nil))
+}
+
+func TestHandlerStripPrefix(t *testing.T) {
+	testService := Create("")
+	testService.Register("ok", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		return "ok", nil
+	})
+
+	mux := http.NewServeMux()
+	mux.Handle("/rpc/", testService.HandlerStripPrefix("/rpc/"))
+	mux.Handle("/plain", testService.Handler())
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	for _, path := range []string{"/rpc/", "/rpc/v1", "/plain"} {
+		req, err := http.NewRequest("POST", ts.URL+path, strings.NewReader(`{"jsonrpc": "2.0", "method": "ok", "id": 1}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		body, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_ = resp.Body.Close()
+
+		_verifyequal(t, resp.StatusCode, http.StatusOK)
+		_verifyequal(t, string(body), `{"jsonrpc":"2.0","result":"ok","id":1}`)
+	}
+}
+
+func TestHandlerStripPrefixExactPath(t *testing.T) {
+	testService := Create("")
+	testService.Register("ok", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		return "ok", nil
+	})
+
+	mux := http.NewServeMux()
+	mux.Handle("/rpc", testService.HandlerStripPrefix("/rpc"))
+
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	req, err := http.NewRequest("POST", ts.URL+"/rpc", strings.NewReader(`{"jsonrpc": "2.0", "method": "ok", "id": 1}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_ = resp.Body.Close()
+
+	// stripped path is empty
+	_verifyequal(t, resp.StatusCode, http.StatusOK)
+	_verifyequal(t, string(body), `{"jsonrpc":"2.0","result":"ok","id":1}`)
+}
+
+func TestByteAccounting(t *testing.T) {
+	var entries []LogEntry
+
+	testService := Create("")
+	testService.Register("ok", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		return "ok", nil
+	})
+	testService.SetLogHookFunction(func(_ *http.Request, entry LogEntry) {
+		entries = append(entries, entry)
+	})
+
+	body := `{"jsonrpc": "2.0", "method": "ok", "id": 1}`
+
+	serve := func() *httptest.ResponseRecorder {
+		testreq := httptest.NewRequest("POST", "http://localhost", strings.NewReader(body))
+		testreq.Header.Set("Accept", "application/json")
+		testreq.Header.Set("Content-Type", "application/json")
+
+		rr := httptest.NewRecorder()
+		testService.ServeHTTP(rr, testreq)
+
+		return rr
+	}
+
+	// disabled by default
+	serve()
+	_verifyequal(t, len(entries), 0)
+
+	testService.SetByteAccountingFlag(true)
+	_verifyequal(t, testService.GetByteAccountingFlag(), true)
+
+	rr := serve()
+	_verifyequal(t, len(entries), 1)
+	_verifyequal(t, entries[0].Message, "request bytes")
+	_verifyequal(t, entries[0].Method, "ok")
+	_verifyequal(t, entries[0].Fields["bytes_read"], int64(len(body)))
+	_verifyequal(t, entries[0].Fields["bytes_written"], int64(rr.Body.Len()))
+}
+
+func TestCountingWriterFlusher(t *testing.T) {
+	// recorder supports flushing
+	w := (&countingWriter{ResponseWriter: httptest.NewRecorder()}).wrap()
+
+	_, ok := w.(http.Flusher)
+	_verifyequal(t, ok, true)
+
+	// writer without flushing support is not reported as flusher
+	w = (&countingWriter{ResponseWriter: struct{ http.ResponseWriter }{httptest.NewRecorder()}}).wrap()
+
+	_, ok = w.(http.Flusher)
+	_verifyequal(t, ok, false)
+}
+
+func TestRequireHeaders(t *testing.T) {
+	testService := Create("")
+	testService.AddMiddleware(RequireHeaders("X-Tenant-ID"))
+	testService.Register("update", Update)
+
+	// required header is present
+	req := _newrpcrequest(`{"jsonrpc": "2.0", "method": "update", "id": 1}`)
+	req.Header.Set("X-Tenant-ID", "tenant")
+
+	w, respObj := _serverpc(t, testService, req)
+	_verifyequal(t, w.Code, http.StatusOK)
+	_verifyequal(t, respObj.Error, (*ErrorObject)(nil))
+
+	// required header is missing
+	w, respObj = _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "update", "id": 1}`))
+	_verifyequal(t, w.Code, http.StatusBadRequest)
+	_verifyerrobj(t, respObj.Error, MissingHeaderCode, MissingHeaderMessage)
+	_verifyequal(t, respObj.Error.Data, "missing required headers: X-Tenant-ID")
+}
+
+func TestResponseWarnings(t *testing.T) {
+	testService := Create("")
+	testService.Register("partial", func(data ParametersObject) (interface{}, *ErrorObject) {
+		data.AddWarning("item 2 skipped")
+		data.AddWarning("item 3 skipped")
+
+		return []int{1}, nil
+	})
+	testService.Register("update", Update)
+
+	w, _ := _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "partial", "id": 1}`))
+	_verifyequal(t, w.Code, http.StatusOK)
+	_verifyequal(t, w.Body.String(), `{"jsonrpc":"2.0","result":[1],"id":1,"meta":{"warnings":["item 2 skipped","item 3 skipped"]}}`)
+
+	// no meta member without warnings
+	w, _ = _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "update", "id": 1}`))
+	_verifyequal(t, strings.Contains(w.Body.String(), `"meta"`), false)
+}
+
+func TestEmptyRequestBody(t *testing.T) {
+	testService := Create("")
+
+	for _, body := range []string{"", " \n\t"} {
+		_, respObj := _serverpc(t, testService, _newrpcrequest(body))
+		_verifyerrobj(t, respObj.Error, InvalidRequestCode, InvalidRequestMessage)
+		_verifyequal(t, respObj.Error.Data, "empty request body")
+	}
+}
+
+func TestMethodAlias(t *testing.T) {
+	testService := Create("")
+	testService.Register("user.get", func(data ParametersObject) (interface{}, *ErrorObject) {
+		return data.GetMethodName(), nil
+	})
+	testService.Register("user.delete", Update)
+
+	err := testService.Alias("getUser", "user.get")
+	if err != nil {
+		t.Fatalf("unexpected error '%s'", err)
+	}
+
+	err = testService.Alias("fetchUser", "getUser")
+	if err != nil {
+		t.Fatalf("unexpected error '%s'", err)
+	}
+
+	testreq := httptest.NewRequest("POST", "http://localhost", nil)
+
+	for _, name := range []string{"user.get", "getUser", "fetchUser"} {
+		result, errObj := testService.Call(name, ParametersObject{r: testreq, method: name})
+		_verifyequal(t, errObj, (*ErrorObject)(nil))
+		_verifyequal(t, result, name)
+	}
+
+	// conflict with registered method
+	err = testService.Alias("user.delete", "user.get")
+	_verifyequal(t, err == nil, false) // expecting error
+
+	// unknown canonical method
+	err = testService.Alias("oldName", "unknown")
+	_verifyequal(t, err == nil, false) // expecting error
+
+	// alias cycle
+	err = testService.Alias("user.get", "fetchUser")
+	_verifyequal(t, err == nil, false) // expecting error
+
+	err = testService.Alias("getUser", "fetchUser")
+	_verifyequal(t, err == nil, false) // expecting error
+}
+
+func TestRegisterAliasName(t *testing.T) {
+	testService := Create("")
+	testService.Register("user.get", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		return "user.get", nil
+	})
+
+	for _, alias := range []string{"getUser", "fetchUser"} {
+		if err := testService.Alias(alias, "user.get"); err != nil {
+			t.Fatalf("unexpected error '%s'", err)
+		}
+	}
+
+	// alias name is rejected by RegisterE
+	err := testService.RegisterE("getUser", Update)
+	_verifyequal(t, err == nil, false) // expecting error
+
+	_, ok := testService.currentRegistry().methods["getUser"]
+	_verifyequal(t, ok, false)
+
+	testreq := httptest.NewRequest("POST", "http://localhost", nil)
+
+	// Register replaces alias, registered method is reachable
+	testService.Register("fetchUser", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		return "fetchUser", nil
+	})
+
+	result, errObj := testService.Call("fetchUser", ParametersObject{r: testreq, method: "fetchUser"})
+	_verifyequal(t, errObj, (*ErrorObject)(nil))
+	_verifyequal(t, result, "fetchUser")
+
+	// other aliases are kept
+	result, errObj = testService.Call("getUser", ParametersObject{r: testreq, method: "getUser"})
+	_verifyequal(t, errObj, (*ErrorObject)(nil))
+	_verifyequal(t, result, "user.get")
+}
+
+func TestAcceptedResult(t *testing.T) {
+	testService := Create("")
+	testService.Register("job.start", func(data ParametersObject) (interface{}, *ErrorObject) {
+		return data.Accepted("job-42")
+	})
+
+	w, respObj := _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "job.start", "id": 1}`))
+	_verifyequal(t, w.Code, http.StatusAccepted)
+	_verifyequal(t, respObj.Error, (*ErrorObject)(nil))
+	_verifyequal(t, respObj.Result, map[string]interface{}{"status": AcceptedStatus, "token": "job-42"})
+}
+
+func TestTimeoutHandler(t *testing.T) {
+	testService := Create("")
+	testService.Register("update", Update)
+	testService.Register("stuck", func(data ParametersObject) (interface{}, *ErrorObject) {
+		<-data.r.Context().Done()
+
+		return nil, nil
+	})
+
+	h := testService.TimeoutHandler(50 * time.Millisecond)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, _newrpcrequest(`{"jsonrpc": "2.0", "method": "stuck", "id": 1}`))
+
+	_verifyequal(t, w.Code, http.StatusServiceUnavailable)
+	_verifyequal(t, w.Header().Get("Content-Type"), "application/json")
+	_verifyequal(t, w.Body.String(), `{"jsonrpc":"2.0","error":{"code":-32603,"message":"Internal error","data":"request timeout"},"id":null}`)
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, _newrpcrequest(`{"jsonrpc": "2.0", "method": "update", "id": 1}`))
+
+	_verifyequal(t, w.Code, http.StatusOK)
+}
+
+func TestRequestResponseObjectCodec(t *testing.T) {
+	reqObj, err := NewRequestObject("subtract", []int{42, 23}, 1)
+	if err != nil {
+		t.Fatalf("unexpected error '%s'", err)
+	}
+
+	b, err := json.Marshal(reqObj)
+	if err != nil {
+		t.Fatalf("unexpected error '%s'", err)
+	}
+
+	_verifyequal(t, string(b), `{"jsonrpc":"2.0","method":"subtract","params":[42,23],"id":1}`)
+
+	decoded := new(RequestObject)
+
+	if err = json.Unmarshal(b, decoded); err != nil {
+		t.Fatalf("unexpected error '%s'", err)
+	}
+
+	_verifyequal(t, decoded, reqObj)
+
+	// notification without params
+	reqObj, err = NewRequestObject("update", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error '%s'", err)
+	}
+
+	b, _ = json.Marshal(reqObj)
+	_verifyequal(t, string(b), `{"jsonrpc":"2.0","method":"update"}`)
+
+	// invalid requests
+	for _, tc := range []struct {
+		method string
+		params interface{}
+		id     interface{}
+	}{
+		{"", nil, 1},
+		{"rpc.internal", nil, 1},
+		{"update", 42, 1},
+		{"update", nil, 1.5},
+		{"update", nil, true},
+	} {
+		_, err = NewRequestObject(tc.method, tc.params, tc.id)
+		_verifyequal(t, err == nil, false) // expecting error
+	}
+
+	// response round-trip
+	data := `{"jsonrpc":"2.0","result":{"value":19},"id":"ID:1"}`
+
+	respObj, err := ParseResponseObject([]byte(data))
+	if err != nil {
+		t.Fatalf("unexpected error '%s'", err)
+	}
+
+	_verifyequal(t, respObj.Result, map[string]interface{}{"value": float64(19)})
+	_verifyequal(t, string(respObj.Marshal()), data)
+
+	data = `{"jsonrpc":"2.0","error":{"code":-32601,"message":"Method not found"},"id":null}`
+
+	respObj, err = ParseResponseObject([]byte(data))
+	if err != nil {
+		t.Fatalf("unexpected error '%s'", err)
+	}
+
+	_verifyerrobj(t, respObj.Error, MethodNotFoundCode, MethodNotFoundMessage)
+	_verifyequal(t, string(respObj.Marshal()), data)
+
+	// invalid responses
+	for _, data := range []string{
+		`{"jsonrpc":"1.0","result":1,"id":1}`,
+		`{"jsonrpc":"2.0","id":1}`,
+		`{"jsonrpc":"2.0","result":1,"error":{"code":-32603,"message":"Internal error"},"id":1}`,
+		`{"jsonrpc":"2.0","result":1}`,
+		`{"jsonrpc":"2.0","result":1,"id":{}}`,
+		`[]`,
+	} {
+		_, err = ParseResponseObject([]byte(data))
+		_verifyequal(t, err == nil, false) // expecting error
+	}
+}
+
+func TestMethodCoalescing(t *testing.T) {
+	const calls = 10
+
+	var executions int32
+
+	release := make(chan struct{})
+
+	testService := Create("")
+	testService.Register("read", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		atomic.AddInt32(&executions, 1)
+		<-release
+
+		return "value", nil
+	})
+
+	err := testService.SetMethodCoalescing("read", true)
+	_verifyequal(t, err == nil, false) // expecting error, method is not read-only
+
+	err = testService.SetMethodReadOnly("read", true)
+	if err != nil {
+		t.Fatalf("unexpected error '%s'", err)
+	}
+
+	err = testService.SetMethodCoalescing("read", true)
+	if err != nil {
+		t.Fatalf("unexpected error '%s'", err)
+	}
+
+	err = testService.SetMethodCoalescing("unknown", true)
+	_verifyequal(t, err == nil, false) // expecting error
+
+	var wg sync.WaitGroup
+
+	results := make(chan interface{}, calls)
+
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			testreq := httptest.NewRequest("POST", "http://localhost", nil)
+
+			result, errObj := testService.Call("read", ParametersObject{r: testreq, params: []byte(`{"key": 1}`)})
+			if errObj != nil {
+				t.Errorf("unexpected error '%v'", errObj)
+			}
+
+			results <- result
+		}()
+	}
+
+	// let all calls join in-flight execution
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
+
+	_verifyequal(t, atomic.LoadInt32(&executions), int32(1))
+
+	for result := range results {
+		_verifyequal(t, result, "value")
+	}
+
+	// sequential calls are not coalesced
+	testreq := httptest.NewRequest("POST", "http://localhost", nil)
+
+	_, errObj := testService.Call("read", ParametersObject{r: testreq, params: []byte(`{"key": 1}`)})
+	_verifyequal(t, errObj, (*ErrorObject)(nil))
+	_verifyequal(t, atomic.LoadInt32(&executions), int32(2))
+}
+
+func TestMethodCoalescingLeaderCancel(t *testing.T) {
+	started := make(chan struct{})
+	release := make(chan struct{})
+
+	testService := Create("")
+	testService.Register("read", func(data ParametersObject) (interface{}, *ErrorObject) {
+		close(started)
+		<-release
+
+		if data.GetContext().Err() != nil {
+			return nil, &ErrorObject{Code: InternalErrorCode, Message: InternalErrorMessage}
+		}
+
+		data.SetHTTPStatusCode(http.StatusAccepted)
+		data.AddWarning("stale value")
+
+		return "value", nil
+	})
+
+	_verifyequal(t, testService.SetMethodReadOnly("read", true), nil)
+	_verifyequal(t, testService.SetMethodCoalescing("read", true), nil)
+
+	newRequest := func(ctx context.Context, code *int, w *warnings) *http.Request {
+		ctx = contextWithHTTPStatusOverride(ctx, code)
+		ctx = contextWithWarnings(ctx, w)
+
+		return httptest.NewRequest("POST", "http://localhost", nil).WithContext(ctx)
+	}
+
+	leaderCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var leaderCode, followerCode int
+
+	var leaderWarnings, followerWarnings warnings
+
+	leaderDone := make(chan *ErrorObject, 1)
+
+	go func() {
+		_, errObj := testService.Call("read", ParametersObject{
+			r:      newRequest(leaderCtx, &leaderCode, &leaderWarnings),
+			params: []byte(`{"key": 1}`),
+		})
+
+		leaderDone <- errObj
+	}()
+
+	<-started
+
+	followerDone := make(chan interface{}, 1)
+
+	go func() {
+		result, errObj := testService.Call("read", ParametersObject{
+			r:      newRequest(context.Background(), &followerCode, &followerWarnings),
+			params: []byte(`{"key": 1}`),
+		})
+		if errObj != nil {
+			t.Errorf("unexpected error '%v'", errObj)
+		}
+
+		followerDone <- result
+	}()
+
+	// let follower join in-flight execution
+	time.Sleep(50 * time.Millisecond)
+
+	// canceled leader stops waiting, shared execution continues
+	cancel()
+
+	errObj := <-leaderDone
+	_verifyequal(t, errObj == nil, false) // expecting error
+
+	close(release)
+
+	_verifyequal(t, <-followerDone, "value")
+	_verifyequal(t, followerCode, http.StatusAccepted)
+	_verifyequal(t, followerWarnings.get(), []string{"stale value"})
+	_verifyequal(t, leaderCode, 0)
+}
+
+func TestErrorDetails(t *testing.T) {
+	errObj := (&ErrorObject{
+		Code:    InvalidRequestCode,
+		Message: InvalidRequestMessage,
+	}).WithDetails(
+		ErrorDetail{Type: "QuotaExceeded", Value: map[string]int{"limit": 10}},
+		ErrorDetail{Type: "PreconditionFailed"},
+	)
+
+	b, err := json.Marshal(errObj)
+	if err != nil {
+		t.Fatalf("unexpected error '%s'", err)
+	}
+
+	_verifyequal(t, string(b), `{"code":-32600,"message":"Invalid Request","data":[{"type":"QuotaExceeded","value":{"limit":10}},{"type":"PreconditionFailed"}]}`)
+
+	decoded := new(ErrorObject)
+
+	if err = json.Unmarshal(b, decoded); err != nil {
+		t.Fatalf("unexpected error '%s'", err)
+	}
+
+	details, err := decoded.Details()
+	_verifyequal(t, err, nil)
+	_verifyequal(t, len(details), 2)
+	_verifyequal(t, details[0].Type, "QuotaExceeded")
+	_verifyequal(t, details[0].Value, map[string]interface{}{"limit": float64(10)})
+	_verifyequal(t, details[1].Type, "PreconditionFailed")
+
+	// data that is not details array
+	_, err = (&ErrorObject{Data: "mock server error"}).Details()
+	_verifyequal(t, err == nil, false) // expecting error
+
+	_, err = (&ErrorObject{Data: []interface{}{map[string]interface{}{"value": 1}}}).Details()
+	_verifyequal(t, err == nil, false) // expecting error
+
+	_, err = (&ErrorObject{Code: InternalErrorCode}).Details()
+	_verifyequal(t, err == nil, false) // expecting error
+}
+
+func TestErrorHTTPStatusMode(t *testing.T) {
+	testService := Create("")
+	_verifyequal(t, testService.GetErrorHTTPStatusMode(), Mapped)
+
+	// mapped status code by default
+	w, respObj := _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "1.0", "method": "update", "id": 1}`))
+	_verifyequal(t, w.Code, http.StatusBadRequest)
+	_verifyerrobj(t, respObj.Error, InvalidRequestCode, InvalidRequestMessage)
+
+	testService.SetErrorHTTPStatusMode(Always200)
+	_verifyequal(t, testService.GetErrorHTTPStatusMode(), Always200)
+
+	w, respObj = _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "1.0", "method": "update", "id": 1}`))
+	_verifyequal(t, w.Code, http.StatusOK)
+	_verifyerrobj(t, respObj.Error, InvalidRequestCode, InvalidRequestMessage)
+
+	req := httptest.NewRequest("POST", "http://localhost", strings.NewReader(`{}`))
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("Content-Type", "text/plain")
+
+	w, respObj = _serverpc(t, testService, req)
+	_verifyequal(t, w.Code, http.StatusOK)
+	_verifyequal(t, respObj.Error == nil, false)
+}
+
+func TestRedaction(t *testing.T) {
+	var reqLog, respLog string
+
+	testService := Create("")
+	testService.Register("login", func(data ParametersObject) (interface{}, *ErrorObject) {
+		return map[string]interface{}{"token": "secret-token", "user": "alice"}, nil
+	})
+	testService.Register("import", func(data ParametersObject) (interface{}, *ErrorObject) {
+		return data.GetRawJSONParams(), nil
+	})
+	testService.SetRequestHookFunction(func(_ *http.Request, data []byte) error {
+		reqLog = string(data)
+
+		return nil
+	})
+	testService.SetResponseHookFunction(func(_ *http.Request, data []byte) error {
+		respLog = string(data)
+
+		return nil
+	})
+
+	testService.SetRedactedFields("params.auth.password")
+
+	err := testService.SetMethodRedactedFields("login", "result.token")
+	if err != nil {
+		t.Fatalf("unexpected error '%s'", err)
+	}
+
+	err = testService.SetMethodRedactedFields("import", "params.users.token", "params.keys.1", "result.users.token")
+	if err != nil {
+		t.Fatalf("unexpected error '%s'", err)
+	}
+
+	err = testService.SetMethodRedactedFields("unknown", "params.password")
+	_verifyequal(t, err == nil, false) // expecting error
+
+	// global nested path and per-method response path
+	w, respObj := _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "login", "params": {"auth": {"user": "alice", "password": "pwd"}}, "id": 1}`))
+	_verifyequal(t, w.Code, http.StatusOK)
+	_verifyequal(t, respObj.Result, map[string]interface{}{"token": "secret-token", "user": "alice"})
+
+	_verifyequal(t, reqLog, `{"id":1,"jsonrpc":"2.0","method":"login","params":{"auth":{"password":"***","user":"alice"}}}`)
+	_verifyequal(t, respLog, `{"id":1,"jsonrpc":"2.0","result":{"token":"***","user":"alice"}}`)
+
+	// array paths
+	_serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "import", "params": {"users": [{"name": "a", "token": "t1"}, {"name": "b", "token": "t2"}], "keys": ["k0", "k1"]}, "id": 1}`))
+
+	_verifyequal(t, reqLog, `{"id":1,"jsonrpc":"2.0","method":"import","params":{"keys":["k0","***"],"users":[{"name":"a","token":"***"},{"name":"b","token":"***"}]}}`)
+	_verifyequal(t, respLog, `{"id":1,"jsonrpc":"2.0","result":{"keys":["k0","k1"],"users":[{"name":"a","token":"***"},{"name":"b","token":"***"}]}}`)
+
+	// payloads without redacted fields are passed intact
+	body := `{"jsonrpc": "2.0", "method": "import", "params": {"other": 1.50}, "id": 1}`
+
+	_serverpc(t, testService, _newrpcrequest(body))
+	_verifyequal(t, reqLog, body)
+}
+
+func TestProfilerLabels(t *testing.T) {
+	testService := Create("")
+	testService.Register("label", func(data ParametersObject) (interface{}, *ErrorObject) {
+		label, _ := pprof.Label(data.r.Context(), ProfilerLabel)
+
+		return label, nil
+	})
+
+	_, respObj := _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "label", "id": 1}`))
+	_verifyequal(t, respObj.Result, "")
+
+	testService.SetProfilerLabelsFlag(true)
+	_verifyequal(t, testService.GetProfilerLabelsFlag(), true)
+
+	_, respObj = _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "label", "id": 1}`))
+	_verifyequal(t, respObj.Result, "label")
+}
+
+func TestNotFoundHandler(t *testing.T) {
+	testService := Create("")
+	testService.SetRoute("/jrpc")
+	testService.Register("ok", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		return "ok", nil
+	})
+
+	serve := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "http://localhost"+path, strings.NewReader(`{"jsonrpc": "2.0", "method": "ok", "id": 1}`))
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Content-Type", "application/json")
+
+		w := httptest.NewRecorder()
+		testService.Handler().ServeHTTP(w, req)
+
+		return w
+	}
+
+	w := serve("/jrpc")
+	_verifyequal(t, w.Code, http.StatusOK)
+
+	// default JSON-RPC shaped 404
+	w = serve("/jrpc/other")
+	_verifyequal(t, w.Code, http.StatusNotFound)
+	_verifyequal(t, w.Header().Get("Content-Type"), "application/json")
+	_verifyequal(t, w.Body.String(), `{"jsonrpc":"2.0","error":{"code":-32600,"message":"Invalid Request","data":"endpoint not found"},"id":null}`)
+
+	// plain 404
+	testService.SetNotFoundHandler(http.NotFoundHandler())
+
+	w = serve("/other")
+	_verifyequal(t, w.Code, http.StatusNotFound)
+	_verifyequal(t, strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain"), true)
+}
+
+func TestMissingVersusNullID(t *testing.T) {
+	testService := Create("")
+	testService.Register("update", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		return "ok", nil
+	})
+
+	// missing id member, notification
+	w, respObj := _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "update"}`))
+	_verifyequal(t, w.Code, http.StatusNoContent)
+	_verifyequal(t, respObj, (*ResponseObject)(nil))
+
+	// null id member, request with null ID
+	w, _ = _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "update", "id": null}`))
+	_verifyequal(t, w.Code, http.StatusOK)
+	_verifyequal(t, w.Body.String(), `{"jsonrpc":"2.0","result":"ok","id":null}`)
+
+	// member that differs only in case is not an ID
+	w, respObj = _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "update", "ID": 5}`))
+	_verifyequal(t, w.Code, http.StatusNoContent)
+	_verifyequal(t, respObj, (*ResponseObject)(nil))
+}
+
+func TestMarkDeprecated(t *testing.T) {
+	var entries []LogEntry
+
+	testService := Create("")
+	testService.Register("old", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		return "ok", nil
+	})
+	testService.SetLogHookFunction(func(_ *http.Request, entry LogEntry) {
+		entries = append(entries, entry)
+	})
+
+	sunset := time.Date(2030, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+	err := testService.MarkDeprecated("old", "use 'new' instead", sunset)
+	if err != nil {
+		t.Fatalf("unexpected error '%s'", err)
+	}
+
+	err = testService.MarkDeprecated("unknown", "", time.Time{})
+	_verifyequal(t, err == nil, false) // expecting error
+
+	w, respObj := _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "old", "id": 1}`))
+	_verifyequal(t, w.Code, http.StatusOK)
+	_verifyequal(t, respObj.Result, "ok")
+	_verifyequal(t, w.Header().Get(DeprecationHeader), "true")
+	_verifyequal(t, w.Header().Get(SunsetHeader), "Tue, 01 Jan 2030 00:00:00 GMT")
+	_verifyequal(t, respObj.Meta.Warnings, []string{"method 'old' is deprecated: use 'new' instead"})
+
+	_verifyequal(t, len(entries), 1)
+	_verifyequal(t, entries[0].Level, LogLevelWarning)
+	_verifyequal(t, entries[0].Method, "old")
+}
+
+func TestObjectPoolsReset(t *testing.T) {
+	id := json.RawMessage(`1`)
+
+	respObj := getResponseObject()
+	respObj.Result = "ok"
+	respObj.ID = &id
+	respObj.r = _newrpcrequest(`{}`)
+	putResponseObject(respObj)
+
+	respObj = getResponseObject()
+	_verifyequal(t, respObj.Jsonrpc, JSONRPCVersion)
+	_verifyequal(t, respObj.Result, nil)
+	_verifyequal(t, respObj.ID, (*json.RawMessage)(nil))
+	_verifyequal(t, respObj.r, (*http.Request)(nil))
+
+	params := json.RawMessage(`[1, 2]`)
+
+	reqObj := getRequestObject()
+	reqObj.Method = "update"
+	reqObj.Params = params
+	putRequestObject(reqObj)
+
+	reqObj = getRequestObject()
+	_verifyequal(t, reqObj.Method, "")
+	_verifyequal(t, len(reqObj.Params), 0)
+	_verifyequal(t, string(params), "[1, 2]") // retained params are not reused
+
+	headers := getHeaders()
+	headers["X-Test"] = "test"
+	putHeaders(headers)
+
+	_verifyequal(t, len(getHeaders()), 0)
+
+	buf := getBodyBuffer()
+	buf.WriteString(`{"jsonrpc": "2.0"}`)
+	putBodyBuffer(buf)
+
+	_verifyequal(t, getBodyBuffer().Len(), 0)
+
+	// oversized buffers are not retained
+	buf = getBodyBuffer()
+	buf.Grow(maxPooledBodyBufferSize + 1)
+	putBodyBuffer(buf)
+
+	_verifyequal(t, getBodyBuffer().Cap() <= maxPooledBodyBufferSize, true)
+}
+
+func TestRequestHookRetainsBody(t *testing.T) {
+	var retained [][]byte
+
+	testService := Create("")
+	testService.Register("update", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		return "ok", nil
+	})
+	testService.SetRequestHookFunction(func(_ *http.Request, data []byte) error {
+		retained = append(retained, data) // retained without copy
+
+		return nil
+	})
+
+	first := `{"jsonrpc": "2.0", "method": "update", "params": [1], "id": 1}`
+	second := `{"jsonrpc": "2.0", "method": "update", "params": [2], "id": 2}`
+
+	_serverpc(t, testService, _newrpcrequest(first))
+	_serverpc(t, testService, _newrpcrequest(second))
+
+	_verifyequal(t, len(retained), 2)
+	_verifyequal(t, string(retained[0]), first) // not overwritten by pooled buffer reuse
+	_verifyequal(t, string(retained[1]), second)
+}
+
+func BenchmarkServeHTTP(b *testing.B) {
+	testService := Create("")
+	testService.Register("update", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		return "ok", nil
+	})
+
+	body := []byte(`{"jsonrpc": "2.0", "method": "update", "params": [1, 2, 3], "id": 1}`)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		req := httptest.NewRequest("POST", "http://localhost/", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+
+		testService.ServeHTTP(httptest.NewRecorder(), req)
+	}
+}
+
+func BenchmarkServeHTTPLargeBody(b *testing.B) {
+	testService := Create("")
+	testService.Register("update", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		return "ok", nil
+	})
+
+	body := []byte(`{"jsonrpc": "2.0", "method": "update", "params": ["` + strings.Repeat("x", 16<<10) + `"], "id": 1}`)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		req := httptest.NewRequest("POST", "http://localhost/", bytes.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+
+		testService.ServeHTTP(httptest.NewRecorder(), req)
+	}
+}
+
+func TestStrictContentLength(t *testing.T) {
+	testService := Create("")
+	testService.Register("update", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		return "ok", nil
+	})
+
+	body := `{"jsonrpc": "2.0", "method": "update", "id": 1}`
+
+	// not checked by default
+	req := _newrpcrequest(body)
+	req.ContentLength = int64(len(body)) + 10
+
+	w, respObj := _serverpc(t, testService, req)
+	_verifyequal(t, w.Code, http.StatusOK)
+	_verifyequal(t, respObj.Result, "ok")
+
+	testService.SetStrictContentLengthFlag(true)
+	_verifyequal(t, testService.GetStrictContentLengthFlag(), true)
+
+	// over-declared body
+	w, respObj = _serverpc(t, testService, req)
+	_verifyequal(t, w.Code, http.StatusBadRequest)
+	_verifyerrobj(t, respObj.Error, ParseErrorCode, ParseErrorMessage)
+
+	// truncated body
+	req = _newrpcrequest(body)
+	req.ContentLength = int64(len(body)) - 10
+
+	w, respObj = _serverpc(t, testService, req)
+	_verifyequal(t, w.Code, http.StatusBadRequest)
+	_verifyerrobj(t, respObj.Error, ParseErrorCode, ParseErrorMessage)
+
+	// matching length
+	w, respObj = _serverpc(t, testService, _newrpcrequest(body))
+	_verifyequal(t, w.Code, http.StatusOK)
+	_verifyequal(t, respObj.Result, "ok")
+
+	// chunked body without declared length
+	req = _newrpcrequest(body)
+	req.ContentLength = -1
+
+	w, respObj = _serverpc(t, testService, req)
+	_verifyequal(t, w.Code, http.StatusOK)
+	_verifyequal(t, respObj.Result, "ok")
+}
+
+func TestRegisterWithDefaults(t *testing.T) {
+	testService := Create("")
+	err := testService.RegisterWithDefaults("echo", json.RawMessage(`{"limit": 10, "order": "asc"}`), func(data ParametersObject) (interface{}, *ErrorObject) {
+		var params map[string]interface{}
+
+		if err := json.Unmarshal(data.GetRawJSONParams(), &params); err != nil {
+			return string(data.GetRawJSONParams()), nil
+		}
+
+		return params, nil
+	})
+	_verifyequal(t, err, nil)
+
+	// omitted params get defaults
+	_, respObj := _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "echo", "id": 1}`))
+	_verifyequal(t, respObj.Result, map[string]interface{}{"limit": float64(10), "order": "asc"})
+
+	// client provided values win, other members are kept
+	_, respObj = _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "echo", "params": {"limit": 5, "offset": 2}, "id": 1}`))
+	_verifyequal(t, respObj.Result, map[string]interface{}{"limit": float64(5), "order": "asc", "offset": float64(2)})
+
+	// explicit null value is client provided value
+	_, respObj = _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "echo", "params": {"order": null}, "id": 1}`))
+	_verifyequal(t, respObj.Result, map[string]interface{}{"limit": float64(10), "order": nil})
+
+	// positional params are passed unchanged
+	_, respObj = _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "echo", "params": [1, 2], "id": 1}`))
+	_verifyequal(t, respObj.Result, "[1, 2]")
+
+	// defaults must be JSON object
+	err = testService.RegisterWithDefaults("invalid", json.RawMessage(`[1]`), Update)
+	_verifyequal(t, err == nil, false) // expecting error
+
+	_, ok := testService.currentRegistry().methods["invalid"]
+	_verifyequal(t, ok, false)
+}
+
+func TestResultKeyCase(t *testing.T) {
+	type item struct {
+		ItemID    int    `json:"itemID"`
+		ItemName  string `json:"item_name"`
+		HTTPRoute string
+	}
+
+	testService := Create("")
+	testService.Register("items", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		return map[string]interface{}{
+			"totalCount": 1,
+			"item_list":  []item{{ItemID: 1, ItemName: "one", HTTPRoute: "/one"}},
+		}, nil
+	})
+
+	body := `{"jsonrpc": "2.0", "method": "items", "id": 1}`
+
+	// no transformation by default
+	_, respObj := _serverpc(t, testService, _newrpcrequest(body))
+	_verifyequal(t, respObj.Result, map[string]interface{}{
+		"totalCount": float64(1),
+		"item_list": []interface{}{
+			map[string]interface{}{"itemID": float64(1), "item_name": "one", "HTTPRoute": "/one"},
+		},
+	})
+
+	testService.SetResultKeyCase(KeyCaseSnake)
+	_verifyequal(t, testService.GetResultKeyCase(), KeyCaseSnake)
+
+	_, respObj = _serverpc(t, testService, _newrpcrequest(body))
+	_verifyequal(t, respObj.Result, map[string]interface{}{
+		"total_count": float64(1),
+		"item_list": []interface{}{
+			map[string]interface{}{"item_id": float64(1), "item_name": "one", "http_route": "/one"},
+		},
+	})
+
+	// header overrides service setting
+	req := _newrpcrequest(body)
+	req.Header.Set(KeyCaseHeader, "camel")
+
+	_, respObj = _serverpc(t, testService, req)
+	_verifyequal(t, respObj.Result, map[string]interface{}{
+		"totalCount": float64(1),
+		"itemList": []interface{}{
+			map[string]interface{}{"itemID": float64(1), "itemName": "one", "httpRoute": "/one"},
+		},
+	})
+
+	_verifyequal(t, toSnakeCase("userIDList"), "user_id_list")
+	_verifyequal(t, toCamelCase("user_id_list"), "userIdList")
+	_verifyequal(t, toCamelCase("UserName"), "userName")
+}
+
+func TestRequestDecompression(t *testing.T) {
+	testService := Create("")
+	testService.Register("update", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		return "ok", nil
+	})
+
+	body := []byte(`{"jsonrpc": "2.0", "method": "update", "id": 1}`)
+
+	var gzipped, deflated bytes.Buffer
+
+	gw := gzip.NewWriter(&gzipped)
+	_, _ = gw.Write(body)
+	_ = gw.Close()
+
+	zw := zlib.NewWriter(&deflated)
+	_, _ = zw.Write(body)
+	_ = zw.Close()
+
+	newRequest := func(data []byte, encoding string) *http.Request {
+		req := httptest.NewRequest("POST", "http://localhost/", bytes.NewReader(data))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Content-Encoding", encoding)
+
+		return req
+	}
+
+	// encoding is ignored by default
+	_, respObj := _serverpc(t, testService, newRequest(body, "gzip"))
+	_verifyequal(t, respObj.Result, "ok")
+
+	testService.SetRequestDecompressionFlag(true)
+	_verifyequal(t, testService.GetRequestDecompressionFlag(), true)
+
+	for encoding, data := range map[string][]byte{
+		"gzip":     gzipped.Bytes(),
+		"deflate":  deflated.Bytes(),
+		"identity": body,
+	} {
+		w, respObj := _serverpc(t, testService, newRequest(data, encoding))
+		_verifyequal(t, w.Code, http.StatusOK)
+		_verifyequal(t, respObj.Result, "ok")
+	}
+
+	// unsupported encoding
+	w, respObj := _serverpc(t, testService, newRequest(body, "br"))
+	_verifyequal(t, w.Code, http.StatusUnsupportedMediaType)
+	_verifyerrobj(t, respObj.Error, ParseErrorCode, ParseErrorMessage)
+
+	// corrupted body
+	w, respObj = _serverpc(t, testService, newRequest(body, "gzip"))
+	_verifyequal(t, w.Code, http.StatusBadRequest)
+	_verifyerrobj(t, respObj.Error, ParseErrorCode, ParseErrorMessage)
+
+	// custom decoder, declared length is checked against encoded body
+	testService.SetContentDecoder("br", func(r io.Reader) (io.ReadCloser, error) {
+		return zlib.NewReader(r)
+	})
+	testService.SetStrictContentLengthFlag(true)
+
+	w, respObj = _serverpc(t, testService, newRequest(deflated.Bytes(), "br"))
+	_verifyequal(t, w.Code, http.StatusOK)
+	_verifyequal(t, respObj.Result, "ok")
+}
+
+func TestRequestDecompressionLimit(t *testing.T) {
+	testService := Create("")
+	testService.SetRequestDecompressionFlag(true)
+	testService.Register("update", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		return "ok", nil
+	})
+
+	_verifyequal(t, testService.GetMaxDecompressedSize(), int64(DefaultMaxDecompressedSize))
+
+	body := []byte(`{"jsonrpc": "2.0", "method": "update", "id": 1}`)
+
+	gzipped := func(data []byte) io.Reader {
+		var buf bytes.Buffer
+
+		gw := gzip.NewWriter(&buf)
+		_, _ = gw.Write(data)
+		_ = gw.Close()
+
+		return &buf
+	}
+
+	newRequest := func(data []byte) *http.Request {
+		req := httptest.NewRequest("POST", "http://localhost/", gzipped(data))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Content-Encoding", "gzip")
+
+		return req
+	}
+
+	testService.SetMaxDecompressedSize(int64(len(body)))
+
+	w, respObj := _serverpc(t, testService, newRequest(body))
+	_verifyequal(t, w.Code, http.StatusOK)
+	_verifyequal(t, respObj.Result, "ok")
+
+	// small compressed body expands over limit
+	w, respObj = _serverpc(t, testService, newRequest(append(body, bytes.Repeat([]byte(" "), 1<<20)...)))
+	_verifyequal(t, w.Code, http.StatusRequestEntityTooLarge)
+	_verifyerrobj(t, respObj.Error, ParseErrorCode, ParseErrorMessage)
+}
+
+func TestServeHTTPContext(t *testing.T) {
+	type ctxKeyTest struct{}
+
+	testService := Create("")
+	testService.Register("trace", func(data ParametersObject) (interface{}, *ErrorObject) {
+		v, _ := data.GetContext().Value(ctxKeyTest{}).(string)
+
+		return v, nil
+	})
+
+	body := `{"jsonrpc": "2.0", "method": "trace", "id": 1}`
+
+	ctx := context.WithValue(context.Background(), ctxKeyTest{}, "trace-id")
+
+	w := httptest.NewRecorder()
+	testService.ServeHTTPContext(ctx, w, _newrpcrequest(body))
+
+	var respObj ResponseObject
+
+	if err := json.Unmarshal(w.Body.Bytes(), &respObj); err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, respObj.Result, "trace-id")
+
+	// ServeHTTP uses request context
+	_, resp := _serverpc(t, testService, _newrpcrequest(body).WithContext(ctx))
+	_verifyequal(t, resp.Result, "trace-id")
+
+	_, resp = _serverpc(t, testService, _newrpcrequest(body))
+	_verifyequal(t, resp.Result, "")
+}
+
+func TestReplaceRegistry(t *testing.T) {
+	version := func(v string) Handler {
+		return func(_ ParametersObject) (interface{}, *ErrorObject) {
+			return v, nil
+		}
+	}
+
+	testService := Create("")
+	testService.Register("version", version("a"))
+
+	regA := testService.Registry()
+
+	regB := NewRegistry()
+	regB.Register("version", version("b"))
+	regB.Register("extra", version("b"))
+
+	body := `{"jsonrpc": "2.0", "method": "version", "id": 1}`
+
+	var wg sync.WaitGroup
+
+	stop := make(chan struct{})
+
+	// swap registries under concurrent load
+	wg.Add(1)
+
+	go func() {
+		defer wg.Done()
+
+		for i := 0; i < 100; i++ {
+			reg := regA
+			if i%2 == 0 {
+				reg = regB
+			}
+
+			if err := testService.ReplaceRegistry(reg); err != nil {
+				t.Error(err)
+			}
+		}
+
+		close(stop)
+	}()
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+
+				w := httptest.NewRecorder()
+				testService.ServeHTTP(w, _newrpcrequest(body))
+
+				var respObj ResponseObject
+
+				if err := json.Unmarshal(w.Body.Bytes(), &respObj); err != nil {
+					t.Error(err)
+
+					return
+				}
+
+				if respObj.Result != "a" && respObj.Result != "b" {
+					t.Errorf("unexpected result '%v'", respObj.Result)
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	// last swap restored snapshot
+	_, respObj := _serverpc(t, testService, _newrpcrequest(body))
+	_verifyequal(t, respObj.Result, "a")
+
+	_, respObj = _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "extra", "id": 1}`))
+	_verifyerrobj(t, respObj.Error, MethodNotFoundCode, MethodNotFoundMessage)
+
+	// registry is copied, later changes do not affect service
+	regA.Register("extra", version("a"))
+
+	_, respObj = _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "extra", "id": 1}`))
+	_verifyerrobj(t, respObj.Error, MethodNotFoundCode, MethodNotFoundMessage)
+
+	// reserved method names are rejected
+	regC := NewRegistry()
+	regC.Register("rpc.version", version("c"))
+
+	err := testService.ReplaceRegistry(regC)
+	_verifyequal(t, err == nil, false) // expecting error
+}
+
+func TestMethodSettersWhileServing(t *testing.T) {
+	testService := Create("")
+	testService.Register("version", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		return "a", nil
+	})
+
+	reg := testService.Registry()
+
+	var wg sync.WaitGroup
+
+	stop := make(chan struct{})
+
+	// calls and registry swaps run concurrently with per-method setters, checked by race detector
+	for _, f := range []func(){
+		func() {
+			_serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "version", "id": 1}`))
+		},
+		func() {
+			_ = testService.ReplaceRegistry(reg)
+		},
+	} {
+		wg.Add(1)
+
+		go func(f func()) {
+			defer wg.Done()
+
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+					f()
+				}
+			}
+		}(f)
+	}
+
+	for i := 0; i < 100; i++ {
+		_ = testService.SetMethodSlowThreshold("version", time.Duration(i)*time.Millisecond)
+		_ = testService.SetMethodReadOnly("version", i%2 == 0)
+		_ = testService.SetMethodEnabled("version", true)
+		_ = testService.Alias(fmt.Sprintf("version.v%d", i), "version")
+	}
+
+	close(stop)
+	wg.Wait()
+
+	_, respObj := _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "version", "id": 1}`))
+	_verifyequal(t, respObj.Result, "a")
+}
+func TestRawIDEcho(t *testing.T) {
+	testService := Create("")
+	testService.Register("id", func(data ParametersObject) (interface{}, *ErrorObject) {
+		return data.GetID(), nil
+	})
+
+	for _, id := range []string{`9007199254740993`, `-9223372036854775808`, `"ID:1"`, `1.0`} {
+		w := httptest.NewRecorder()
+		testService.ServeHTTP(w, _newrpcrequest(`{"jsonrpc": "2.0", "method": "id", "id": `+id+`}`))
+
+		var respObj struct {
+			Result string          `json:"result"`
+			ID     json.RawMessage `json:"id"`
+		}
+
+		if err := json.Unmarshal(w.Body.Bytes(), &respObj); err != nil {
+			t.Fatal(err)
+		}
+
+		// byte-exact round-trip of ID
+		_verifyequal(t, string(respObj.ID), id)
+	}
+
+	_, respObj := _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "id", "id": 9007199254740993}`))
+	_verifyequal(t, respObj.Result, "9007199254740993")
+
+	_, respObj = _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "id", "id": 1.0}`))
+	_verifyequal(t, respObj.Result, "1")
+
+	_, respObj = _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "id", "id": 1.5}`))
+	_verifyerrobj(t, respObj.Error, InvalidIDCode, InvalidIDMessage)
+}
+
+func TestDisallowUnknownFields(t *testing.T) {
+	testService := Create("")
+	testService.Register("update", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		return "ok", nil
+	})
+
+	body := `{"jsonrpc": "2.0", "method": "update", "foo": "bar", "id": 1}`
+
+	// lenient by default
+	w, respObj := _serverpc(t, testService, _newrpcrequest(body))
+	_verifyequal(t, w.Code, http.StatusOK)
+	_verifyequal(t, respObj.Result, "ok")
+
+	testService.SetDisallowUnknownFieldsFlag(true)
+	_verifyequal(t, testService.GetDisallowUnknownFieldsFlag(), true)
+
+	w, respObj = _serverpc(t, testService, _newrpcrequest(body))
+	_verifyequal(t, w.Code, http.StatusBadRequest)
+	_verifyerrobj(t, respObj.Error, InvalidRequestCode, InvalidRequestMessage)
+	_verifyequal(t, respObj.Error.Data, "request object contains unknown member 'foo'")
+
+	// members are matched exactly
+	w, respObj = _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "update", "ID": 1}`))
+	_verifyequal(t, w.Code, http.StatusBadRequest)
+	_verifyerrobj(t, respObj.Error, InvalidRequestCode, InvalidRequestMessage)
+
+	w, respObj = _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "update", "params": [], "id": 1}`))
+	_verifyequal(t, w.Code, http.StatusOK)
+	_verifyequal(t, respObj.Result, "ok")
+}
+
+func TestAdminHandler(t *testing.T) {
+	release := make(chan struct{})
+	started := make(chan struct{})
+
+	testService := Create("")
+	testService.Register("update", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		return "ok", nil
+	})
+	testService.Register("wait", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		close(started)
+		<-release
+
+		return "done", nil
+	})
+
+	admin := testService.AdminHandler()
+
+	getStatus := func() AdminStatus {
+		w := httptest.NewRecorder()
+		admin.ServeHTTP(w, httptest.NewRequest("GET", "http://localhost"+AdminStatusPath, nil))
+
+		_verifyequal(t, w.Code, http.StatusOK)
+
+		var status AdminStatus
+
+		if err := json.Unmarshal(w.Body.Bytes(), &status); err != nil {
+			t.Fatal(err)
+		}
+
+		return status
+	}
+
+	status := getStatus()
+	_verifyequal(t, status.InFlight, int64(0))
+	_verifyequal(t, status.Methods, []string{"update", "wait"})
+	_verifyequal(t, status.Draining, false)
+
+	// in-flight request
+	done := make(chan *ResponseObject)
+
+	go func() {
+		w := httptest.NewRecorder()
+		testService.ServeHTTP(w, _newrpcrequest(`{"jsonrpc": "2.0", "method": "wait", "id": 1}`))
+
+		respObj := new(ResponseObject)
+		_ = json.Unmarshal(w.Body.Bytes(), respObj)
+
+		done <- respObj
+	}()
+
+	<-started
+
+	_verifyequal(t, getStatus().InFlight, int64(1))
+
+	// drain only on POST
+	w := httptest.NewRecorder()
+	admin.ServeHTTP(w, httptest.NewRequest("GET", "http://localhost"+AdminDrainPath, nil))
+	_verifyequal(t, w.Code, http.StatusMethodNotAllowed)
+
+	// drain requires authorization or explicit opt-in
+	w = httptest.NewRecorder()
+	admin.ServeHTTP(w, httptest.NewRequest("POST", "http://localhost"+AdminDrainPath, nil))
+	_verifyequal(t, w.Code, http.StatusForbidden)
+	_verifyequal(t, testService.IsDraining(), false)
+
+	testService.SetInsecureAdminFlag(true)
+	_verifyequal(t, testService.GetInsecureAdminFlag(), true)
+
+	w = httptest.NewRecorder()
+	admin.ServeHTTP(w, httptest.NewRequest("POST", "http://localhost"+AdminDrainPath, nil))
+	_verifyequal(t, w.Code, http.StatusAccepted)
+
+	// wait for drain to start
+	for !testService.IsDraining() {
+		time.Sleep(time.Millisecond)
+	}
+
+	// new requests are rejected while draining
+	w, respObj := _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "update", "id": 2}`))
+	_verifyequal(t, w.Code, http.StatusServiceUnavailable)
+	_verifyerrobj(t, respObj.Error, InternalErrorCode, InternalErrorMessage)
+
+	_verifyequal(t, getStatus().Drained, false)
+
+	// in-flight request finishes
+	close(release)
+	_verifyequal(t, (<-done).Result, "done")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := testService.Drain(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	status = getStatus()
+	_verifyequal(t, status.InFlight, int64(0))
+	_verifyequal(t, status.Drained, true)
+
+	// admin requests are authorized
+	if err := testService.AddAuthorization("user", "password", []string{"127.0.0.1/32"}); err != nil {
+		t.Fatal(err)
+	}
+
+	w = httptest.NewRecorder()
+	admin.ServeHTTP(w, httptest.NewRequest("GET", "http://localhost"+AdminStatusPath, nil))
+	_verifyequal(t, w.Code, http.StatusForbidden)
+}
+
+func TestRedirect(t *testing.T) {
+	testService := Create("")
+	testService.Register("invoice", func(data ParametersObject) (interface{}, *ErrorObject) {
+		return data.Redirect("https://billing.example.com/rpc")
+	})
+
+	w, respObj := _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "invoice", "id": 1}`))
+	_verifyequal(t, w.Code, http.StatusOK)
+	_verifyequal(t, w.Header().Get("Location"), "https://billing.example.com/rpc")
+	_verifyerrobj(t, respObj.Error, MethodMovedCode, MethodMovedMessage)
+	_verifyequal(t, respObj.Error.Data, map[string]interface{}{"location": "https://billing.example.com/rpc"})
+	_verifyequal(t, respObj.Result, nil)
+}
+
+func TestRateLimitHeaders(t *testing.T) {
+	reset := time.Unix(1700000000, 0)
+
+	testService := Create("")
+	testService.Register("limited", func(data ParametersObject) (interface{}, *ErrorObject) {
+		data.SetRateLimitHeaders(100, 42, reset)
+
+		return "ok", nil
+	})
+
+	w, respObj := _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "limited", "id": 1}`))
+	_verifyequal(t, respObj.Result, "ok")
+	_verifyequal(t, w.Header().Get(RateLimitLimitHeader), "100")
+	_verifyequal(t, w.Header().Get(RateLimitRemainingHeader), "42")
+	_verifyequal(t, w.Header().Get(RateLimitResetHeader), "1700000000")
+}
+
+func TestAuditHook(t *testing.T) {
+	var entries []AuditEntry
+
+	testService := Create("")
+	testService.Register("read", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		return "ok", nil
+	})
+	testService.Register("write", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		return "ok", nil
+	})
+	testService.Register("fail", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		return nil, &ErrorObject{Code: InternalErrorCode, Message: InternalErrorMessage}
+	})
+	testService.SetRedactedFields("params.password")
+	testService.SetAuditHook(func(_ *http.Request, entry AuditEntry) {
+		entries = append(entries, entry)
+	})
+
+	if err := testService.SetMethodReadOnly("read", true); err != nil {
+		t.Fatal(err)
+	}
+
+	_serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "read", "id": 1}`))
+	_verifyequal(t, len(entries), 0)
+
+	req := _newrpcrequest(`{"jsonrpc": "2.0", "method": "write", "params": {"user": "alice", "password": "secret"}, "id": 1}`)
+	req.Header.Set("X-Real-IP", "10.0.0.1")
+
+	_serverpc(t, testService, req)
+	_verifyequal(t, len(entries), 1)
+	_verifyequal(t, entries[0].Method, "write")
+	_verifyequal(t, entries[0].Status, AuditStatusOK)
+	_verifyequal(t, entries[0].ClientIP, "10.0.0.1")
+	_verifyequal(t, string(entries[0].Params), `{"password":"***","user":"alice"}`)
+	_verifyequal(t, entries[0].Timestamp.IsZero(), false)
+
+	_serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "fail", "id": 1}`))
+	_verifyequal(t, len(entries), 2)
+	_verifyequal(t, entries[1].Status, AuditStatusError)
+	_verifyequal(t, entries[1].ErrorCode, InternalErrorCode)
+
+	// unknown methods are not audited
+	_serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "unknown", "id": 1}`))
+	_verifyequal(t, len(entries), 2)
+}
+
+func TestMaxParamsArity(t *testing.T) {
+	testService := Create("")
+	testService.Register("update", Update)
+	testService.Register("sum", Update)
+
+	testService.SetMaxParamsArity(4)
+	_verifyequal(t, testService.GetMaxParamsArity(), 4)
+
+	err := testService.SetMethodMaxParamsArity("sum", 2)
+	if err != nil {
+		t.Fatalf("unexpected error '%s'", err)
+	}
+
+	err = testService.SetMethodMaxParamsArity("unknown", 2)
+	_verifyequal(t, err == nil, false) // expecting error
+
+	_, respObj := _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "update", "params": [1, 2, 3, 4], "id": 1}`))
+	_verifyequal(t, respObj.Error, (*ErrorObject)(nil))
+
+	_, respObj = _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "update", "params": [1, 2, 3, 4, 5, 6, 7, 8, 9, 10], "id": 1}`))
+	_verifyerrobj(t, respObj.Error, InvalidParamsCode, InvalidParamsMessage)
+	_verifyequal(t, respObj.Error.Data, "number of positional params must not exceed 4")
+
+	// per-method limit overrides service-wide limit
+	_, respObj = _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "sum", "params": [1, 2, 3], "id": 1}`))
+	_verifyerrobj(t, respObj.Error, InvalidParamsCode, InvalidParamsMessage)
+
+	// named params are not limited by arity
+	_, respObj = _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "update", "params": {"a": 1, "b": 2, "c": 3, "d": 4, "e": 5}, "id": 1}`))
+	_verifyequal(t, respObj.Error, (*ErrorObject)(nil))
+}
+
+func TestUseNumber(t *testing.T) {
+	var (
+		generic map[string]interface{}
+		id      int64
+		ratio   float64
+	)
+
+	testService := Create("")
+	testService.Register("store", func(data ParametersObject) (interface{}, *ErrorObject) {
+		if errObj := data.UnmarshalParams(&generic); errObj != nil {
+			return nil, errObj
+		}
+
+		var errObj *ErrorObject
+
+		if id, errObj = data.GetInt64Param("id"); errObj != nil {
+			return nil, errObj
+		}
+
+		if ratio, errObj = data.GetFloat64Param("ratio"); errObj != nil {
+			return nil, errObj
+		}
+
+		return nil, nil
+	})
+
+	// 2^53 + 1 is not representable as float64
+	body := `{"jsonrpc": "2.0", "method": "store", "params": {"id": 9007199254740993, "ratio": 0.5}, "id": 1}`
+
+	_, respObj := _serverpc(t, testService, _newrpcrequest(body))
+	_verifyequal(t, respObj.Error, (*ErrorObject)(nil))
+	_verifyequal(t, id, int64(9007199254740993))
+	_verifyequal(t, ratio, 0.5)
+
+	if _, ok := generic["id"].(float64); !ok {
+		t.Fatalf("expected float64 number by default, got %T", generic["id"])
+	}
+
+	testService.SetUseNumberFlag(true)
+	_verifyequal(t, testService.GetUseNumberFlag(), true)
+
+	_, respObj = _serverpc(t, testService, _newrpcrequest(body))
+	_verifyequal(t, respObj.Error, (*ErrorObject)(nil))
+	_verifyequal(t, generic["id"], json.Number("9007199254740993"))
+
+	_, respObj = _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "store", "params": {"id": 1.5, "ratio": 0.5}, "id": 1}`))
+	_verifyerrobj(t, respObj.Error, InvalidParamsCode, InvalidParamsMessage)
+}
+
+type testProvider struct {
+	methods map[string]Handler
+}
+
+func (p *testProvider) Methods() map[string]Handler {
+	return p.methods
+}
+
+func TestRegisterProvider(t *testing.T) {
+	result := func(v string) Handler {
+		return func(_ ParametersObject) (interface{}, *ErrorObject) {
+			return v, nil
+		}
+	}
+
+	users := &testProvider{methods: map[string]Handler{"users.get": result("user"), "users.list": result("users")}}
+	orders := &testProvider{methods: map[string]Handler{"orders.get": result("order")}}
+
+	testService := Create("")
+	testService.Register("ping", result("pong"))
+
+	if err := testService.RegisterProvider(users); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := testService.RegisterProvider(orders); err != nil {
+		t.Fatal(err)
+	}
+
+	// name collision across providers
+	err := testService.RegisterProvider(&testProvider{methods: map[string]Handler{"orders.get": result("dup"), "orders.new": result("dup")}})
+	_verifyequal(t, err == nil, false) // expecting error
+
+	_, respObj := _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "users.get", "id": 1}`))
+	_verifyequal(t, respObj.Result, "user")
+
+	_, respObj = _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "orders.get", "id": 1}`))
+	_verifyequal(t, respObj.Result, "order")
+
+	// colliding provider registers nothing
+	_, respObj = _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "orders.new", "id": 1}`))
+	_verifyerrobj(t, respObj.Error, MethodNotFoundCode, MethodNotFoundMessage)
+
+	if err = testService.SetMethodMaxParamsArity("users.get", 1); err != nil {
+		t.Fatal(err)
+	}
+
+	users.methods = map[string]Handler{"users.get": result("user.v2")}
+
+	if err = testService.ReloadProviders(); err != nil {
+		t.Fatal(err)
+	}
+
+	_, respObj = _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "users.get", "params": [1, 2], "id": 1}`))
+	_verifyerrobj(t, respObj.Error, InvalidParamsCode, InvalidParamsMessage) // per-method settings are preserved
+
+	_, respObj = _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "users.get", "id": 1}`))
+	_verifyequal(t, respObj.Result, "user.v2")
+
+	_, respObj = _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "users.list", "id": 1}`))
+	_verifyerrobj(t, respObj.Error, MethodNotFoundCode, MethodNotFoundMessage)
+
+	_, respObj = _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "ping", "id": 1}`))
+	_verifyequal(t, respObj.Result, "pong")
+}
+
+func TestMultipartUpload(t *testing.T) {
+	newMultipartRequest := func(rpc string, files map[string]string) *http.Request {
+		body := new(bytes.Buffer)
+		mw := multipart.NewWriter(body)
+
+		part, _ := mw.CreateFormField(MultipartRequestPart)
+		_, _ = part.Write([]byte(rpc))
+
+		for name, content := range files {
+			part, _ = mw.CreateFormFile(name, name+".txt")
+			_, _ = part.Write([]byte(content))
+		}
+
+		_ = mw.Close()
+
+		req := httptest.NewRequest("POST", "http://localhost/", body)
+		req.Header.Set("Accept", "application/json")
+		req.Header.Set("Content-Type", mw.FormDataContentType())
+
+		return req
+	}
+
+	testService := Create("")
+	testService.SetStrictContentLengthFlag(true)
+	testService.Register("upload", func(data ParametersObject) (interface{}, *ErrorObject) {
+		var params struct {
+			Name string `json:"name"`
+		}
+
+		if errObj := data.UnmarshalParams(&params); errObj != nil {
+			return nil, errObj
+		}
+
+		f, errObj := data.File("file")
+		if errObj != nil {
+			return nil, errObj
+		}
+
+		content, err := ioutil.ReadAll(f)
+		if err != nil {
+			return nil, &ErrorObject{Code: InternalErrorCode, Message: InternalErrorMessage, Data: err.Error()}
+		}
+
+		return params.Name + ":" + string(content), nil
+	})
+	testService.Register("update", Update)
+
+	err := testService.SetMethodMultipart("upload", true)
+	if err != nil {
+		t.Fatalf("unexpected error '%s'", err)
+	}
+
+	err = testService.SetMethodMultipart("unknown", true)
+	_verifyequal(t, err == nil, false) // expecting error
+
+	w, respObj := _serverpc(t, testService, newMultipartRequest(
+		`{"jsonrpc": "2.0", "method": "upload", "params": {"name": "report"}, "id": 1}`,
+		map[string]string{"file": "file content"},
+	))
+	_verifyequal(t, w.Code, http.StatusOK)
+	_verifyequal(t, respObj.Error, (*ErrorObject)(nil))
+	_verifyequal(t, respObj.Result, "report:file content")
+
+	// missing file part
+	_, respObj = _serverpc(t, testService, newMultipartRequest(
+		`{"jsonrpc": "2.0", "method": "upload", "params": {"name": "report"}, "id": 1}`, nil,
+	))
+	_verifyerrobj(t, respObj.Error, InvalidParamsCode, InvalidParamsMessage)
+
+	// method without multipart opt-in
+	w, respObj = _serverpc(t, testService, newMultipartRequest(
+		`{"jsonrpc": "2.0", "method": "update", "params": [1], "id": 1}`,
+		map[string]string{"file": "file content"},
+	))
+	_verifyequal(t, w.Code, http.StatusUnsupportedMediaType)
+	_verifyerrobj(t, respObj.Error, InvalidRequestCode, InvalidRequestMessage)
+
+	// plain JSON requests are unaffected
+	_, respObj = _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "update", "params": [1], "id": 1}`))
+	_verifyequal(t, respObj.Error, (*ErrorObject)(nil))
+}
+
+func TestOptions(t *testing.T) {
+	testService := Create("")
+	testService.SetHeaders(map[string]string{"Access-Control-Allow-Origin": "*"})
+
+	req := httptest.NewRequest(http.MethodOptions, "http://localhost/", nil)
+
+	// disabled by default, rejected by request method validation
+	w := httptest.NewRecorder()
+	testService.ServeHTTP(w, req)
+	_verifyequal(t, w.Code, http.StatusMethodNotAllowed)
+
+	testService.SetOptionsFlag(true)
+	_verifyequal(t, testService.GetOptionsFlag(), true)
+
+	w = httptest.NewRecorder()
+	testService.ServeHTTP(w, req)
+	_verifyequal(t, w.Code, http.StatusNoContent)
+	_verifyequal(t, w.Header().Get("Allow"), "OPTIONS, POST")
+	_verifyequal(t, w.Header().Get("Access-Control-Allow-Origin"), "*")
+	_verifyequal(t, w.Header().Get("Link"), "")
+
+	testService.SetHeadProbeFlag(true)
+	testService.SetDescriptionURL("/openrpc.json")
+	_verifyequal(t, testService.GetDescriptionURL(), "/openrpc.json")
+
+	w = httptest.NewRecorder()
+	testService.ServeHTTP(w, req)
+	_verifyequal(t, w.Code, http.StatusNoContent)
+	_verifyequal(t, w.Header().Get("Allow"), "HEAD, OPTIONS, POST")
+	_verifyequal(t, w.Header().Get("Link"), `</openrpc.json>; rel="service-desc"`)
+}
+
+func TestMethodConcurrency(t *testing.T) {
+	entered := make(chan struct{})
+	unblock := make(chan struct{})
+
+	testService := Create("")
+	testService.Register("report", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		entered <- struct{}{}
+		<-unblock
+
+		return "done", nil
+	})
+	testService.Register("update", Update)
+
+	err := testService.SetMethodConcurrency("report", 1)
+	if err != nil {
+		t.Fatalf("unexpected error '%s'", err)
+	}
+
+	err = testService.SetMethodConcurrency("unknown", 1)
+	_verifyequal(t, err == nil, false) // expecting error
+
+	done := make(chan *ResponseObject)
+
+	go func() {
+		_, respObj := _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "report", "id": 1}`))
+		done <- respObj
+	}()
+
+	<-entered
+
+	// method limit is reached
+	w, respObj := _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "report", "id": 2}`))
+	_verifyequal(t, w.Code, http.StatusServiceUnavailable)
+	_verifyerrobj(t, respObj.Error, ServerBusyCode, ServerBusyMessage)
+
+	// other methods remain responsive
+	w, respObj = _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "update", "params": [1], "id": 3}`))
+	_verifyequal(t, w.Code, http.StatusOK)
+	_verifyequal(t, respObj.Error, (*ErrorObject)(nil))
+
+	close(unblock)
+
+	respObj = <-done
+	_verifyequal(t, respObj.Result, "done")
+
+	// slot is freed after call
+	go func() { <-entered }()
+
+	_, respObj = _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "report", "id": 4}`))
+	_verifyequal(t, respObj.Result, "done")
+}
+
+func TestRequestMeta(t *testing.T) {
+	var meta json.RawMessage
+
+	testService := Create("")
+	testService.SetDisallowUnknownFieldsFlag(true)
+	testService.Register("update", func(data ParametersObject) (interface{}, *ErrorObject) {
+		meta = data.Meta()
+
+		return string(data.GetRawJSONParams()), nil
+	})
+
+	body := `{"jsonrpc": "2.0", "method": "update", "params": [1], "meta": {"tenant": "acme"}, "id": 1}`
+
+	// unknown member when disabled
+	_, respObj := _serverpc(t, testService, _newrpcrequest(body))
+	_verifyerrobj(t, respObj.Error, InvalidRequestCode, InvalidRequestMessage)
+
+	testService.SetRequestMetaFlag(true)
+	_verifyequal(t, testService.GetRequestMetaFlag(), true)
+
+	_, respObj = _serverpc(t, testService, _newrpcrequest(body))
+	_verifyequal(t, respObj.Error, (*ErrorObject)(nil))
+	_verifyequal(t, respObj.Result, "[1]")
+	_verifyequal(t, string(meta), `{"tenant": "acme"}`)
+
+	_, respObj = _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "update", "params": [1], "id": 1}`))
+	_verifyequal(t, respObj.Error, (*ErrorObject)(nil))
+	_verifyequal(t, meta == nil, true)
+
+	// ignored when disabled
+	testService.SetRequestMetaFlag(false)
+	testService.SetDisallowUnknownFieldsFlag(false)
+
+	_, respObj = _serverpc(t, testService, _newrpcrequest(body))
+	_verifyequal(t, respObj.Error, (*ErrorObject)(nil))
+	_verifyequal(t, meta == nil, true)
+}
+
+func TestMethodCachePrincipal(t *testing.T) {
+	var calls int
+
+	testService := Create("")
+	testService.SetBehindReverseProxyFlag(false)
+	testService.SetTrustedPrincipalHeader("X-Authenticated-User")
+
+	if err := testService.SetTrustedProxies([]string{"10.0.0.0/8"}); err != nil {
+		t.Fatal(err)
+	}
+
+	testService.Register("profile", func(data ParametersObject) (interface{}, *ErrorObject) {
+		calls++
+
+		return fmt.Sprintf("%s:%d", data.GetPrincipal(), calls), nil
+	})
+
+	_verifyequal(t, testService.SetMethodReadOnly("profile", true), nil)
+
+	err := testService.SetMethodCache("profile", time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error '%s'", err)
+	}
+
+	err = testService.SetMethodCache("unknown", time.Minute)
+	_verifyequal(t, err == nil, false) // expecting error
+
+	call := func(principal string) interface{} {
+		req := _newrpcrequest(`{"jsonrpc": "2.0", "method": "profile", "id": 1}`)
+		req.RemoteAddr = "10.1.2.3:4567"
+
+		if principal != "" {
+			req.Header.Set("X-Authenticated-User", principal)
+		}
+
+		_, respObj := _serverpc(t, testService, req)
+
+		return respObj.Result
+	}
+
+	_verifyequal(t, call("alice"), "alice:1")
+	_verifyequal(t, call("alice"), "alice:1") // cached
+	_verifyequal(t, call("bob"), "bob:2")     // isolated from other principal
+	_verifyequal(t, call(""), ":3")           // anonymous requests are cached separately
+	_verifyequal(t, call(""), ":3")
+	_verifyequal(t, call("bob"), "bob:2")
+
+	// custom key ignores principal
+	testService.SetCacheKeyFunction(func(_ string, data ParametersObject) string {
+		return data.GetHeaders().Get("Accept-Language")
+	})
+
+	_verifyequal(t, call("alice"), "alice:4")
+	_verifyequal(t, call("bob"), "alice:4")
+}
+
+func TestMethodCacheEffects(t *testing.T) {
+	var calls int
+
+	reset := time.Unix(1700000000, 0)
+
+	testService := Create("")
+	testService.Register("report", func(data ParametersObject) (interface{}, *ErrorObject) {
+		calls++
+
+		data.SetHTTPStatusCode(http.StatusAccepted)
+		data.SetRateLimitHeaders(100, 42, reset)
+		data.AddWarning("report is stale")
+
+		return calls, nil
+	})
+
+	// state-changing methods are not cached
+	_verifyequal(t, testService.SetMethodCache("report", time.Minute) == nil, false) // expecting error
+
+	_verifyequal(t, testService.SetMethodReadOnly("report", true), nil)
+	_verifyequal(t, testService.SetMethodCache("report", time.Minute), nil)
+
+	// cached responses are identical to response of original call
+	for i := 0; i < 2; i++ {
+		w, respObj := _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "report", "id": 1}`))
+		_verifyequal(t, w.Code, http.StatusAccepted)
+		_verifyequal(t, w.Header().Get(RateLimitRemainingHeader), "42")
+		_verifyequal(t, respObj.Result, float64(1))
+		_verifyequal(t, respObj.Meta.Warnings, []string{"report is stale"})
+	}
+}
+
+func TestMethodCacheAuthorization(t *testing.T) {
+	var calls int
+
+	testService := Create("")
+	testService.Register("profile", func(data ParametersObject) (interface{}, *ErrorObject) {
+		username, _, _ := data.r.BasicAuth()
+		calls++
+
+		return fmt.Sprintf("%s:%d", username, calls), nil
+	})
+
+	_verifyequal(t, testService.SetMethodReadOnly("profile", true), nil)
+
+	err := testService.SetMethodCache("profile", time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error '%s'", err)
+	}
+
+	call := func(username string) interface{} {
+		req := _newrpcrequest(`{"jsonrpc": "2.0", "method": "profile", "id": 1}`)
+		req.SetBasicAuth(username, "secret")
+
+		_, respObj := _serverpc(t, testService, req)
+
+		return respObj.Result
+	}
+
+	_verifyequal(t, call("alice"), "alice:1")
+	_verifyequal(t, call("alice"), "alice:1") // cached
+	_verifyequal(t, call("bob"), "bob:2")     // Basic Authorization without principal is isolated
+	_verifyequal(t, call("alice"), "alice:1")
+}
+
+func TestMethodCacheMaxEntries(t *testing.T) {
+	var calls int
+
+	testService := Create("")
+	testService.SetMaxCacheEntries(2)
+	testService.Register("echo", func(data ParametersObject) (interface{}, *ErrorObject) {
+		calls++
+
+		return calls, nil
+	})
+
+	_verifyequal(t, testService.SetMethodReadOnly("echo", true), nil)
+
+	err := testService.SetMethodCache("echo", time.Minute)
+	if err != nil {
+		t.Fatalf("unexpected error '%s'", err)
+	}
+
+	call := func(param string) interface{} {
+		req := _newrpcrequest(`{"jsonrpc": "2.0", "method": "echo", "params": ["` + param + `"], "id": 1}`)
+
+		_, respObj := _serverpc(t, testService, req)
+
+		return respObj.Result
+	}
+
+	_verifyequal(t, testService.GetMaxCacheEntries(), 2)
+	_verifyequal(t, call("a"), float64(1))
+	_verifyequal(t, call("b"), float64(2))
+	_verifyequal(t, call("a"), float64(1)) // cached, most recently used
+	_verifyequal(t, call("c"), float64(3)) // evicts least recently used 'b'
+	_verifyequal(t, call("a"), float64(1))
+	_verifyequal(t, call("b"), float64(4))
+	_verifyequal(t, len(testService.cache.entries), 2)
+}
+
+func TestRequestReplay(t *testing.T) {
+	var calls int
+
+	testService := Create("")
+	testService.SetRedactedFields("params.password")
+	testService.Register("login", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		calls++
+
+		return calls, nil
+	})
+
+	admin := testService.AdminHandler()
+
+	// replay is not available without debug capture
+	w := httptest.NewRecorder()
+	admin.ServeHTTP(w, httptest.NewRequest("GET", "http://localhost"+AdminReplayPath, nil))
+	_verifyequal(t, w.Code, http.StatusNotFound)
+
+	testService.SetRequestCaptureSize(2)
+	_verifyequal(t, testService.GetRequestCaptureSize(), 2)
+
+	for i := 1; i <= 3; i++ {
+		_serverpc(t, testService, _newrpcrequest(fmt.Sprintf(`{"jsonrpc": "2.0", "method": "login", "params": {"password": "secret"}, "id": %d}`, i)))
+	}
+
+	// only last requests are kept, redacted
+	captured := testService.CapturedRequests(0)
+	_verifyequal(t, len(captured), 2)
+	_verifyequal(t, string(captured[0].Body), `{"id":2,"jsonrpc":"2.0","method":"login","params":{"password":"***"}}`)
+	_verifyequal(t, string(captured[1].Body), `{"id":3,"jsonrpc":"2.0","method":"login","params":{"password":"***"}}`)
+
+	// replay requires authorization or explicit opt-in
+	w = httptest.NewRecorder()
+	admin.ServeHTTP(w, httptest.NewRequest("POST", "http://localhost"+AdminReplayPath+"?count=1", nil))
+	_verifyequal(t, w.Code, http.StatusForbidden)
+
+	testService.SetInsecureAdminFlag(true)
+
+	w = httptest.NewRecorder()
+	admin.ServeHTTP(w, httptest.NewRequest("POST", "http://localhost"+AdminReplayPath+"?count=1", nil))
+	_verifyequal(t, w.Code, http.StatusOK)
+
+	var results []ReplayResult
+
+	if err := json.Unmarshal(w.Body.Bytes(), &results); err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, len(results), 1)
+	_verifyequal(t, results[0].StatusCode, http.StatusOK)
+	_verifyequal(t, string(results[0].Body), `{"jsonrpc":"2.0","result":4,"id":3}`)
+
+	// replayed requests are not captured again
+	_verifyequal(t, len(testService.CapturedRequests(0)), 2)
+	_verifyequal(t, string(testService.CapturedRequests(1)[0].Body), string(captured[1].Body))
+}
+
+func TestValidateRegistration(t *testing.T) {
+	testService := Create("")
+	testService.Register("update", Update)
+
+	if err := testService.Alias("modify", "update"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := testService.ValidateRegistration(); err != nil {
+		t.Fatalf("unexpected error '%s'", err)
+	}
+
+	// alias target removed from method set
+	reg := NewRegistry()
+	reg.Register("broken", nil)
+	reg.Register("wrapped", Update, nil)
+	reg.aliases = testService.Registry().aliases
+
+	if err := testService.ReplaceRegistry(reg); err != nil {
+		t.Fatal(err)
+	}
+
+	err := testService.ValidateRegistration()
+	_verifyequal(t, err == nil, false) // expecting error
+	_verifyequal(t, err.Error(), "invalid registration: "+
+		"alias 'modify' points to unregistered method 'update'; "+
+		"method 'broken' has no handler; "+
+		"method 'wrapped' middleware #0 is nil")
+}
+
+func TestRESTRoute(t *testing.T) {
+	testService := Create("")
+	testService.Register("user.get", func(data ParametersObject) (interface{}, *ErrorObject) {
+		var params struct {
+			ID     string `json:"id"`
+			Fields string `json:"fields"`
+		}
+
+		if errObj := data.UnmarshalParams(&params); errObj != nil {
+			return nil, errObj
+		}
+
+		if params.ID == "0" {
+			return nil, &ErrorObject{Code: InvalidParamsCode, Message: InvalidParamsMessage}
+		}
+
+		return map[string]string{"id": params.ID, "fields": params.Fields}, nil
+	})
+
+	err := testService.AddRESTRoute("GET", "/user/{id}", "user.get")
+	if err != nil {
+		t.Fatalf("unexpected error '%s'", err)
+	}
+
+	err = testService.AddRESTRoute("GET", "/order/{id}", "order.get")
+	_verifyequal(t, err == nil, false) // expecting error
+
+	rest := testService.RESTHandler()
+
+	w := httptest.NewRecorder()
+	rest.ServeHTTP(w, httptest.NewRequest("GET", "http://localhost/user/42", strings.NewReader(`{"fields": "name"}`)))
+	_verifyequal(t, w.Code, http.StatusOK)
+	_verifyequal(t, w.Body.String(), `{"fields":"name","id":"42"}`)
+
+	// method errors are unwrapped
+	w = httptest.NewRecorder()
+	rest.ServeHTTP(w, httptest.NewRequest("GET", "http://localhost/user/0", nil))
+	_verifyequal(t, w.Code, http.StatusBadRequest)
+	_verifyequal(t, w.Body.String(), `{"code":-32602,"message":"Invalid params"}`)
+
+	// unmatched routes
+	for _, target := range []string{"/user", "/user/42/orders", "/users/42"} {
+		w = httptest.NewRecorder()
+		rest.ServeHTTP(w, httptest.NewRequest("GET", "http://localhost"+target, nil))
+		_verifyequal(t, w.Code, http.StatusNotFound)
+	}
+
+	w = httptest.NewRecorder()
+	rest.ServeHTTP(w, httptest.NewRequest("DELETE", "http://localhost/user/42", nil))
+	_verifyequal(t, w.Code, http.StatusNotFound)
+}
+
+func TestRESTRouteHeaders(t *testing.T) {
+	testService := Create("")
+	testService.Register("quota.get", func(data ParametersObject) (interface{}, *ErrorObject) {
+		data.SetRateLimitHeaders(10, 9, time.Now().Add(time.Minute))
+
+		return "ok", nil
+	})
+
+	err := testService.AddRESTRoute("GET", "/quota", "quota.get")
+	if err != nil {
+		t.Fatalf("unexpected error '%s'", err)
+	}
+
+	w := httptest.NewRecorder()
+	testService.RESTHandler().ServeHTTP(w, httptest.NewRequest("GET", "http://localhost/quota", nil))
+	_verifyequal(t, w.Code, http.StatusOK)
+	_verifyequal(t, w.Body.String(), `"ok"`)
+	_verifyequal(t, w.Header().Get("Content-Type"), "application/json")
+	_verifyequal(t, w.Header().Get(RateLimitLimitHeader), "10")
+	_verifyequal(t, w.Header().Get(RateLimitRemainingHeader), "9")
+}
+
+func TestResponseNullID(t *testing.T) {
+	testService := Create("")
+
+	for _, body := range []string{
+		`{"jsonrpc": "2.0", "method": `,
+		`not json`,
+		`[]`,
+		``,
+	} {
+		w, respObj := _serverpc(t, testService, _newrpcrequest(body))
+
+		var members map[string]json.RawMessage
+
+		if err := json.Unmarshal(w.Body.Bytes(), &members); err != nil {
+			t.Fatal(err)
+		}
+
+		id, ok := members["id"]
+		if !ok {
+			t.Fatalf("expected id member in response to '%s', got '%s'", body, w.Body.String())
+		}
+
+		_verifyequal(t, string(id), "null")
+		_verifyequal(t, respObj.Error == nil, false) // expecting error
+	}
+
+	// request rejected before body is parsed
+	req := _newrpcrequest(`{"jsonrpc": "2.0", "method": "update", "id": 1}`)
+	req.Header.Set("Content-Type", "text/plain")
+
+	w, _ := _serverpc(t, testService, req)
+	_verifyequal(t, w.Code, http.StatusUnsupportedMediaType)
+	_verifyequal(t, strings.HasSuffix(w.Body.String(), `"id":null}`), true)
+}
+
+func TestRequireTLS(t *testing.T) {
+	testService := Create("")
+	testService.Register("update", Update)
+	testService.SetRequireTLSFlag(true)
+	_verifyequal(t, testService.GetRequireTLSFlag(), true)
+
+	if err := testService.SetTrustedProxies([]string{"10.0.0.0/8"}); err != nil {
+		t.Fatal(err)
+	}
+
+	body := `{"jsonrpc": "2.0", "method": "update", "params": [1], "id": 1}`
+
+	// direct TLS connection
+	req := _newrpcrequest(body)
+	req.TLS = new(tls.ConnectionState)
+
+	w, respObj := _serverpc(t, testService, req)
+	_verifyequal(t, w.Code, http.StatusOK)
+	_verifyequal(t, respObj.Error, (*ErrorObject)(nil))
+
+	// HTTPS terminated by trusted proxy
+	req = _newrpcrequest(body)
+	req.RemoteAddr = "10.1.2.3:4567"
+	req.Header.Set("X-Forwarded-Proto", "https")
+
+	w, respObj = _serverpc(t, testService, req)
+	_verifyequal(t, w.Code, http.StatusOK)
+	_verifyequal(t, respObj.Error, (*ErrorObject)(nil))
+
+	// plain HTTP, forwarded header from untrusted source is ignored
+	for _, remote := range []string{"10.1.2.3:4567", "8.8.8.8:4567"} {
+		req = _newrpcrequest(body)
+		req.RemoteAddr = remote
+
+		if remote == "8.8.8.8:4567" {
+			req.Header.Set("X-Forwarded-Proto", "https")
+		}
+
+		w, respObj = _serverpc(t, testService, req)
+		_verifyequal(t, w.Code, http.StatusUpgradeRequired)
+		_verifyequal(t, w.Header().Get("Upgrade"), "TLS/1.2, HTTP/1.1")
+		_verifyerrobj(t, respObj.Error, TLSRequiredCode, TLSRequiredMessage)
+	}
+}
+
+func TestTrustUnixSocketPeers(t *testing.T) {
+	testService := Create("")
+	testService.SetBehindReverseProxyFlag(false)
+	testService.SetRequireTLSFlag(true)
+	testService.SetTrustedPrincipalHeader("X-Authenticated-User")
+	testService.Register("whoami", func(data ParametersObject) (interface{}, *ErrorObject) {
+		return data.GetPrincipal(), nil
+	})
+
+	// request received over unix socket, peer has no network address
+	unixRequest := func() *http.Request {
+		req := _newrpcrequest(`{"jsonrpc": "2.0", "method": "whoami", "id": 1}`)
+		req.RemoteAddr = "@"
+		req.Header.Set("X-Authenticated-User", "alice")
+		req.Header.Set("X-Forwarded-Proto", "https")
+
+		ctx := context.WithValue(req.Context(), http.LocalAddrContextKey, &net.UnixAddr{Name: "/tmp/jrpc2.sock", Net: "unix"})
+
+		return req.WithContext(ctx)
+	}
+
+	w, respObj := _serverpc(t, testService, unixRequest())
+	_verifyequal(t, w.Code, http.StatusUpgradeRequired)
+	_verifyerrobj(t, respObj.Error, TLSRequiredCode, TLSRequiredMessage)
+
+	testService.SetTrustUnixSocketPeersFlag(true)
+	_verifyequal(t, testService.GetTrustUnixSocketPeersFlag(), true)
+
+	w, respObj = _serverpc(t, testService, unixRequest())
+	_verifyequal(t, w.Code, http.StatusOK)
+	_verifyequal(t, respObj.Result, "alice")
+
+	// TCP peers are still checked against trusted networks
+	req := _newrpcrequest(`{"jsonrpc": "2.0", "method": "whoami", "id": 1}`)
+	req.RemoteAddr = "8.8.8.8:4567"
+	req.Header.Set("X-Forwarded-Proto", "https")
+
+	w, _ = _serverpc(t, testService, req)
+	_verifyequal(t, w.Code, http.StatusUpgradeRequired)
+}
+
+func TestCacheControl(t *testing.T) {
+	testService := Create("")
+	testService.Register("update", Update)
+
+	// no directives by default
+	w, _ := _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "update", "params": [1], "id": 1}`))
+	_verifyequal(t, w.Header().Get("Cache-Control"), "")
+
+	err := testService.RegisterReadCacheable("catalog", 5*time.Minute, func(data ParametersObject) (interface{}, *ErrorObject) {
+		if string(data.GetRawJSONParams()) == `"fail"` {
+			return nil, &ErrorObject{Code: InternalErrorCode, Message: InternalErrorMessage}
+		}
+
+		return "items", nil
+	})
+	_verifyequal(t, err, nil)
+
+	err = testService.RegisterReadCacheable("rpc.catalog", time.Minute, Update)
+	_verifyequal(t, err == nil, false) // expecting error
+
+	testService.Register("profile", Update)
+
+	err = testService.SetMethodCacheControl("profile", "private, max-age=60")
+	if err != nil {
+		t.Fatalf("unexpected error '%s'", err)
+	}
+
+	err = testService.SetMethodCacheControl("unknown", "private")
+	_verifyequal(t, err == nil, false) // expecting error
+
+	for _, tc := range []struct {
+		body     string
+		expected string
+	}{
+		{`{"jsonrpc": "2.0", "method": "catalog", "id": 1}`, "public, max-age=300"},
+		{`{"jsonrpc": "2.0", "method": "catalog", "params": "fail", "id": 1}`, CacheControlNoStore},
+		{`{"jsonrpc": "2.0", "method": "profile", "params": [1], "id": 1}`, "private, max-age=60"},
+		{`{"jsonrpc": "2.0", "method": "update", "params": [1], "id": 1}`, CacheControlNoStore},
+	} {
+		w, _ = _serverpc(t, testService, _newrpcrequest(tc.body))
+		_verifyequal(t, w.Header().Get("Cache-Control"), tc.expected)
+	}
+
+	// reads served over GET
+	err = testService.AddRESTRoute("GET", "/catalog", "catalog")
+	if err != nil {
+		t.Fatalf("unexpected error '%s'", err)
+	}
+
+	rest := testService.RESTHandler()
+
+	w = httptest.NewRecorder()
+	rest.ServeHTTP(w, httptest.NewRequest("GET", "http://localhost/catalog", nil))
+	_verifyequal(t, w.Code, http.StatusOK)
+	_verifyequal(t, w.Header().Get("Cache-Control"), "public, max-age=300")
+
+	// authenticated responses are not stored by shared caches
+	req := httptest.NewRequest("GET", "http://localhost/catalog", nil)
+	req.SetBasicAuth("alice", "secret")
+
+	w = httptest.NewRecorder()
+	rest.ServeHTTP(w, req)
+	_verifyequal(t, w.Code, http.StatusOK)
+	_verifyequal(t, w.Header().Get("Cache-Control"), "private, max-age=300")
+
+	err = testService.SetMethodCacheControl("catalog", "public, s-maxage=60")
+	if err != nil {
+		t.Fatalf("unexpected error '%s'", err)
+	}
+
+	w = httptest.NewRecorder()
+	rest.ServeHTTP(w, req)
+	_verifyequal(t, w.Header().Get("Cache-Control"), "public, s-maxage=60")
+}
+
+func TestResultMarshalError(t *testing.T) {
+	var entries []LogEntry
+
+	testService := Create("")
+	testService.SetLogHookFunction(func(r *http.Request, entry LogEntry) {
+		entries = append(entries, entry)
+	})
+	testService.Register("channel", func(data ParametersObject) (interface{}, *ErrorObject) {
+		return map[string]interface{}{"events": make(chan int)}, nil
+	})
+
+	w, respObj := _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "channel", "id": 7}`))
+	_verifyequal(t, w.Code, http.StatusOK)
+	_verifyerrobj(t, respObj.Error, InternalErrorCode, InternalErrorMessage)
+	_verifyequal(t, respObj.Error.Data, MarshalErrorData)
+	_verifyequal(t, string(*respObj.ID), "7")
+
+	// full marshaling error is logged
+	_verifyequal(t, len(entries), 1)
+	_verifyequal(t, entries[0].Level, LogLevelError)
+	_verifyequal(t, entries[0].Method, "channel")
+	_verifyequal(t, strings.Contains(entries[0].Fields["error"].(string), "chan int"), true)
+
+	// custom error object
+	testService.SetMarshalErrorFunction(func(r *http.Request, err error) *ErrorObject {
+		return &ErrorObject{Code: -32000, Message: "Result unavailable"}
+	})
+
+	_, respObj = _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "channel", "id": 8}`))
+	_verifyerrobj(t, respObj.Error, -32000, "Result unavailable")
+}
+
+func _signjwt(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": kid})
+	payload, _ := json.Marshal(claims)
+
+	input := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(input))
+
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestVerifyJWT(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		keys    = map[string]*rsa.PrivateKey{}
+		fetches int
+	)
+
+	addKey := func(kid string) *rsa.PrivateKey {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		mu.Lock()
+		keys[kid] = key
+		mu.Unlock()
+
+		return key
+	}
+
+	jwksServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		fetches++
+
+		set := []map[string]string{}
+		for kid, key := range keys {
+			set = append(set, map[string]string{
+				"kty": "RSA",
+				"kid": kid,
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			})
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": set})
+	}))
+	defer jwksServer.Close()
+
+	key := addKey("key-1")
+
+	testService := Create("")
+	testService.AddMiddleware(VerifyJWT(JWTConfig{
+		JWKSURL:            jwksServer.URL,
+		Issuer:             "https://issuer.example",
+		Audience:           "jrpc2",
+		MinRefreshInterval: time.Nanosecond,
+	}))
+	testService.Register("whoami", func(data ParametersObject) (interface{}, *ErrorObject) {
+		return []interface{}{data.GetPrincipal(), data.GetJWTClaims()["scope"]}, nil
+	})
+
+	claims := func(exp time.Time, aud interface{}) map[string]interface{} {
+		return map[string]interface{}{
+			"iss":   "https://issuer.example",
+			"aud":   aud,
+			"sub":   "alice",
+			"scope": "read",
+			"exp":   exp.Unix(),
+		}
+	}
+
+	call := func(token string) (*httptest.ResponseRecorder, *ResponseObject) {
+		req := _newrpcrequest(`{"jsonrpc": "2.0", "method": "whoami", "id": 1}`)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+
+		return _serverpc(t, testService, req)
+	}
+
+	// valid token, claims are available to method
+	w, respObj := call(_signjwt(t, key, "key-1", claims(time.Now().Add(time.Hour), []string{"other", "jrpc2"})))
+	_verifyequal(t, w.Code, http.StatusOK)
+	_verifyequal(t, respObj.Result, []interface{}{"alice", "read"})
+
+	for _, tc := range []struct {
+		token string
+		data  string
+	}{
+		{"", "bearer token is missing"},
+		{_signjwt(t, key, "key-1", claims(time.Now().Add(-time.Hour), "jrpc2")), "token is expired"},
+		{_signjwt(t, key, "key-1", claims(time.Now().Add(time.Hour), "other")), "token audience mismatch"},
+		{_signjwt(t, addKey("other-key"), "key-1", claims(time.Now().Add(time.Hour), "jrpc2")), "token signature mismatch"},
+	} {
+		w, respObj = call(tc.token)
+		_verifyequal(t, w.Code, http.StatusUnauthorized)
+		_verifyequal(t, w.Header().Get("WWW-Authenticate"), `Bearer error="invalid_token"`)
+		_verifyerrobj(t, respObj.Error, InvalidTokenCode, InvalidTokenMessage)
+		_verifyequal(t, respObj.Error.Data, tc.data)
+	}
+
+	// rotated key is fetched on unknown key ID
+	mu.Lock()
+	before := fetches
+	mu.Unlock()
+
+	rotated := addKey("key-2")
+
+	w, respObj = call(_signjwt(t, rotated, "key-2", claims(time.Now().Add(time.Hour), "jrpc2")))
+	_verifyequal(t, w.Code, http.StatusOK)
+	_verifyequal(t, respObj.Result, []interface{}{"alice", "read"})
+
+	mu.Lock()
+	_verifyequal(t, fetches, before+1)
+	mu.Unlock()
+}
+
+func TestReset(t *testing.T) {
+	var calls int
+
+	testService := Create("")
+	testService.SetRequestCaptureSize(10)
+	testService.Register("counter", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		calls++
+
+		return calls, nil
+	})
+
+	_verifyequal(t, testService.SetMethodReadOnly("counter", true), nil)
+
+	if err := testService.SetMethodCache("counter", time.Minute); err != nil {
+		t.Fatalf("unexpected error '%s'", err)
+	}
+
+	call := func() interface{} {
+		_, respObj := _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "counter", "id": 1}`))
+
+		return respObj.Result
+	}
+
+	_verifyequal(t, call(), float64(1))
+	_verifyequal(t, call(), float64(1)) // cached
+	_verifyequal(t, len(testService.CapturedRequests(0)), 2)
+
+	testService.Reset()
+
+	_verifyequal(t, len(testService.CapturedRequests(0)), 0)
+	_verifyequal(t, call(), float64(2)) // cache is cleared, method is still registered
+	_verifyequal(t, len(testService.CapturedRequests(0)), 1)
+
+	// safe with live traffic
+	var wg sync.WaitGroup
+
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+
+		go func() {
+			defer wg.Done()
+
+			testService.ServeHTTP(httptest.NewRecorder(), _newrpcrequest(`{"jsonrpc": "2.0", "method": "rpc.ping", "id": 1}`))
+		}()
+
+		go func() {
+			defer wg.Done()
+
+			testService.Reset()
+		}()
+	}
+
+	wg.Wait()
+}
+
+func TestNDJSONStream(t *testing.T) {
+	testService := Create("")
+	testService.Register("update", Update)
+	testService.Register("echo", func(data ParametersObject) (interface{}, *ErrorObject) {
+		return data.GetRawJSONParams(), nil
+	})
+
+	body := strings.Join([]string{
+		`{"jsonrpc": "2.0", "method": "echo", "params": [1], "id": 1}`,
+		`{"jsonrpc": "2.0", "method": "update", "params": [2]}`,
+		``,
+		`{"jsonrpc": "2.0", "method": "echo", "params": [3], "id"`,
+		`{"jsonrpc": "2.0", "method": "echo", "params": [4], "id": 4}`,
+	}, "\n")
+
+	newRequest := func() *http.Request {
+		req := httptest.NewRequest("POST", "http://localhost/", strings.NewReader(body))
+		req.Header.Set("Content-Type", NDJSONContentType)
+		req.Header.Set("Accept", NDJSONContentType)
+
+		return req
+	}
+
+	// disabled by default
+	w := httptest.NewRecorder()
+	testService.ServeHTTP(w, newRequest())
+	_verifyequal(t, w.Code, http.StatusUnsupportedMediaType)
+
+	testService.SetNDJSONFlag(true)
+	_verifyequal(t, testService.GetNDJSONFlag(), true)
+
+	w = httptest.NewRecorder()
+	testService.ServeHTTP(w, newRequest())
+	_verifyequal(t, w.Code, http.StatusOK)
+	_verifyequal(t, w.Header().Get("Content-Type"), NDJSONContentType)
+
+	// notification and empty line produce no response lines
+	lines := strings.Split(strings.TrimSuffix(w.Body.String(), "\n"), "\n")
+	_verifyequal(t, len(lines), 3)
+
+	responses := make([]*ResponseObject, 0, len(lines))
+
+	for _, line := range lines {
+		respObj := new(ResponseObject)
+
+		if err := json.Unmarshal([]byte(line), respObj); err != nil {
+			t.Fatalf("unexpected error '%s'", err)
+		}
+
+		responses = append(responses, respObj)
+	}
+
+	_verifyequal(t, string(*responses[0].ID), "1")
+	_verifyequal(t, responses[0].Result, []interface{}{float64(1)})
+	_verifyerrobj(t, responses[1].Error, ParseErrorCode, ParseErrorMessage)
+	_verifyequal(t, string(*responses[2].ID), "4")
+	_verifyequal(t, responses[2].Result, []interface{}{float64(4)})
+}
+
+func TestLifecycleHooks(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		events []string
+	)
+
+	record := func(event string) func() {
+		return func() {
+			mu.Lock()
+			events = append(events, event)
+			mu.Unlock()
+		}
+	}
+
+	testService := Create("/tmp/jrpc2-lifecycle.sock")
+	testService.OnStart(record("start:db"))
+	testService.OnStart(record("start:cache"))
+	testService.OnShutdown(record("shutdown:db"))
+	testService.OnShutdown(record("shutdown:cache"))
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- testService.Start()
+	}()
+
+	// wait for start hooks
+	for i := 0; i < 500; i++ {
+		mu.Lock()
+		n := len(events)
+		mu.Unlock()
+
+		if n == 2 {
+			break
+		}
+
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := testService.Drain(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatal(err)
+	}
+
+	// second drain does not repeat shutdown hooks
+	if err := testService.Drain(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, events, []string{"start:db", "start:cache", "shutdown:cache", "shutdown:db"})
+}
+
+func TestRegisterWithScope(t *testing.T) {
+	testService := Create("")
+
+	err := testService.RegisterWithScope("orders.list", "orders:read", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		return "orders", nil
+	})
+	_verifyequal(t, err, nil)
+
+	err = testService.RegisterWithScope("orders.delete", "orders:write", Update)
+	_verifyequal(t, err, nil)
+
+	err = testService.RegisterWithScope("system.orders", "orders:read", Update)
+	_verifyequal(t, err == nil, false) // expecting error
+
+	// scope is published with method
+	m, ok := testService.currentRegistry().methods["orders.delete"]
+	_verifyequal(t, ok, true)
+	_verifyequal(t, m.Scope, "orders:write")
+
+	call := func(name string, claims JWTClaims) (*httptest.ResponseRecorder, *ResponseObject) {
+		req := _newrpcrequest(`{"jsonrpc": "2.0", "method": "` + name + `", "params": [1], "id": 1}`)
+		if claims != nil {
+			req = req.WithContext(contextWithJWTClaims(req.Context(), claims))
+		}
+
+		return _serverpc(t, testService, req)
+	}
+
+	// scopes of verified token
+	w, respObj := call("orders.list", JWTClaims{"scope": "profile orders:read"})
+	_verifyequal(t, w.Code, http.StatusOK)
+	_verifyequal(t, respObj.Result, "orders")
+
+	w, respObj = call("orders.list", JWTClaims{"scp": []interface{}{"orders:read"}})
+	_verifyequal(t, w.Code, http.StatusOK)
+	_verifyequal(t, respObj.Result, "orders")
+
+	for _, claims := range []JWTClaims{nil, {"scope": "orders:read"}} {
+		w, respObj = call("orders.delete", claims)
+		_verifyequal(t, w.Code, http.StatusForbidden)
+		_verifyerrobj(t, respObj.Error, PermissionCode, PermissionMessage)
+		_verifyequal(t, respObj.Error.Data, "scope 'orders:write' is required")
+	}
+
+	// custom scopes source
+	testService.SetScopesFunction(func(data ParametersObject) []string {
+		return strings.Split(data.GetHeaders().Get("X-Scopes"), ",")
+	})
+
+	req := _newrpcrequest(`{"jsonrpc": "2.0", "method": "orders.delete", "params": [1], "id": 1}`)
+	req.Header.Set("X-Scopes", "orders:read,orders:write")
+
+	w, respObj = _serverpc(t, testService, req)
+	_verifyequal(t, w.Code, http.StatusOK)
+	_verifyequal(t, respObj.Error == nil, true)
+}
+
+func TestJWKSRefreshStaleKeys(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fetches int32
+
+	block := make(chan struct{})
+
+	jwksServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// refreshes hang until released
+		if atomic.AddInt32(&fetches, 1) > 1 {
+			<-block
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"keys": []map[string]string{{
+			"kty": "RSA",
+			"kid": "key-1",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	defer jwksServer.Close()
+	defer close(block)
+
+	ks := &jwks{cfg: JWTConfig{
+		JWKSURL:            jwksServer.URL,
+		RefreshInterval:    time.Millisecond,
+		MinRefreshInterval: time.Millisecond,
+		HTTPClient:         http.DefaultClient,
+	}}
+
+	_, err = ks.key(context.Background(), "key-1")
+	_verifyequal(t, err, nil)
+
+	time.Sleep(5 * time.Millisecond)
+
+	// known key of stale cache is served while refresh hangs
+	pub, err := ks.key(context.Background(), "key-1")
+	_verifyequal(t, err, nil)
+	_verifyequal(t, pub == nil, false)
+
+	// caller waiting for unknown key gives up with its own context, refresh is not canceled
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err = ks.key(ctx, "key-2")
+	_verifyequal(t, err == nil, false) // expecting error
+
+	_, err = ks.key(context.Background(), "key-1")
+	_verifyequal(t, err, nil)
+
+	_verifyequal(t, atomic.LoadInt32(&fetches), int32(2))
+}
+
+func TestCacheControlWhileServing(t *testing.T) {
+	testService := Create("")
+	testService.Register("update", Update)
+
+	var wg sync.WaitGroup
+
+	stop := make(chan struct{})
+
+	wg.Add(1)
+
+	// calls run concurrently with registration of cacheable methods, checked by race detector
+	go func() {
+		defer wg.Done()
+
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+				_serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "update", "params": [1], "id": 1}`))
+			}
+		}
+	}()
+
+	for i := 0; i < 100; i++ {
+		_ = testService.RegisterReadCacheable(fmt.Sprintf("catalog.v%d", i), time.Minute, Update)
+		_ = testService.SetMethodCacheControl("update", "")
+	}
+
+	close(stop)
+	wg.Wait()
+
+	w, _ := _serverpc(t, testService, _newrpcrequest(`{"jsonrpc": "2.0", "method": "catalog.v1", "params": [1], "id": 1}`))
+	_verifyequal(t, w.Header().Get("Cache-Control"), "public, max-age=60")
+}
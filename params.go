@@ -1,6 +1,7 @@
 package jrpc2
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 )
@@ -15,6 +16,8 @@ type ParametersObject struct {
 	r *http.Request // contains pointer to HTTP request object
 
 	params json.RawMessage // contains raw JSON params of invoked method
+
+	meta json.RawMessage // contains raw JSON request metadata, nil when disabled by service
 }
 
 // GetID returns request ID as string data type.
@@ -32,6 +35,42 @@ func (p ParametersObject) GetRawID() *json.RawMessage {
 	return p.id
 }
 
+// GetContext returns context of the HTTP request, background context when method is called without request.
+func (p ParametersObject) GetContext() context.Context {
+	if p.r == nil {
+		return context.Background()
+	}
+
+	return p.r.Context()
+}
+
+// GetRequestID returns request correlation ID (X-Request-ID), differs from JSON-RPC request ID.
+func (p ParametersObject) GetRequestID() string {
+	return requestIDFromContext(p.r.Context())
+}
+
+// IsDryRun returns true when request is processed in validation-only (dry-run) mode.
+func (p ParametersObject) IsDryRun() bool {
+	return dryRunFlagFromContext(p.r.Context())
+}
+
+// GetPrincipal returns authenticated principal of request, empty string for anonymous requests.
+func (p ParametersObject) GetPrincipal() string {
+	return principalFromContext(p.r.Context())
+}
+
+// SetHTTPStatusCode overrides HTTP status code of response, used by methods and middlewares to signal
+// transport level failures (e.g. 400, 403), ignored for notifications.
+func (p ParametersObject) SetHTTPStatusCode(code int) {
+	if p.r == nil {
+		return
+	}
+
+	if v := httpStatusOverrideFromContext(p.r.Context()); v != nil {
+		*v = code
+	}
+}
+
 // GetMethodName returns invoked request Method name as string data type.
 func (p ParametersObject) GetMethodName() string {
 	return p.method
@@ -56,6 +95,12 @@ func (p ParametersObject) GetRawJSONParams() json.RawMessage {
 	return p.params
 }
 
+// Meta returns raw JSON request metadata (non-standard 'meta' request member),
+// nil when request has no metadata or metadata is disabled by service (see SetRequestMetaFlag).
+func (p ParametersObject) Meta() json.RawMessage {
+	return p.meta
+}
+
 // GetCookies parses and returns the HTTP cookies sent with the request.
 func (p ParametersObject) GetCookies() []*http.Cookie {
 	return p.r.Cookies()
@@ -0,0 +1,81 @@
+package jrpc2
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode"
+)
+
+// ParseErrorData represents detailed data of request body decoding error.
+type ParseErrorData struct {
+	// Error contains decoder error message
+	Error string `json:"error"`
+	// Offset contains byte offset in request body where error occurred
+	Offset int64 `json:"offset"`
+	// Snippet contains sanitized part of request body around offset
+	Snippet string `json:"snippet"`
+}
+
+// SetParseErrorSnippetLength sets maximal length of request body snippet included in parse error data,
+// zero length (default) disables detailed parse error data, keep disabled in production
+// to avoid echoing untrusted data back to clients.
+func (s *Service) SetParseErrorSnippetLength(length int) {
+	s.snippetLength = length
+}
+
+// GetParseErrorSnippetLength gets maximal length of request body snippet included in parse error data.
+func (s *Service) GetParseErrorSnippetLength() int {
+	return s.snippetLength
+}
+
+// getErrorOffset returns offset of decoding error, -1 when unknown.
+func getErrorOffset(err error) int64 {
+	switch v := err.(type) {
+	case *json.SyntaxError:
+		return v.Offset
+	case *json.UnmarshalTypeError:
+		return v.Offset
+	default:
+		return -1
+	}
+}
+
+// sanitizeSnippet replaces non-printable characters in snippet.
+func sanitizeSnippet(data []byte) string {
+	return strings.Map(
+		func(r rune) rune {
+			if unicode.IsPrint(r) {
+				return r
+			}
+
+			return '.'
+		},
+		strings.ToValidUTF8(string(data), "."),
+	)
+}
+
+// parseErrorData returns data for decoding error of request body.
+func (s *Service) parseErrorData(err error, data []byte) interface{} {
+	offset := getErrorOffset(err)
+
+	if s.snippetLength <= 0 || offset < 0 {
+		return err.Error()
+	}
+
+	// snippet window around offset, bounded by request body
+	end := offset + int64(s.snippetLength/2)
+	if end > int64(len(data)) {
+		end = int64(len(data))
+	}
+
+	start := end - int64(s.snippetLength)
+	if start < 0 {
+		start = 0
+	}
+
+	return ParseErrorData{
+		Error:   err.Error(),
+		Offset:  offset,
+		Snippet: sanitizeSnippet(data[start:end]),
+	}
+}
@@ -0,0 +1,99 @@
+package jrpc2
+
+import (
+	"bytes"
+	"sync"
+)
+
+// maxPooledBodyBufferSize defines capacity of largest request body buffer returned to pool,
+// larger buffers are dropped so occasional big requests do not keep memory allocated.
+const maxPooledBodyBufferSize = 64 << 10
+
+// Pools of per-request objects, reduce allocations and GC pressure under high request rate.
+// Objects are reset before being returned to pool, so no state leaks between requests.
+var (
+	requestObjectPool = sync.Pool{
+		New: func() interface{} {
+			return new(RequestObject)
+		},
+	}
+
+	responseObjectPool = sync.Pool{
+		New: func() interface{} {
+			return new(ResponseObject)
+		},
+	}
+
+	headersPool = sync.Pool{
+		New: func() interface{} {
+			return make(map[string]string)
+		},
+	}
+
+	bodyBufferPool = sync.Pool{
+		New: func() interface{} {
+			return new(bytes.Buffer)
+		},
+	}
+)
+
+// getRequestObject gets empty request object from pool.
+func getRequestObject() *RequestObject {
+	return requestObjectPool.Get().(*RequestObject)
+}
+
+// putRequestObject resets request object and returns it to pool.
+func putRequestObject(reqObj *RequestObject) {
+	// params are shared with method parameters object, must not be truncated and reused by decoder
+	*reqObj = RequestObject{}
+
+	requestObjectPool.Put(reqObj)
+}
+
+// getResponseObject gets default response object from pool.
+func getResponseObject() *ResponseObject {
+	respObj := responseObjectPool.Get().(*ResponseObject)
+
+	// set JSON-RPC response version
+	respObj.Jsonrpc = JSONRPCVersion
+
+	return respObj
+}
+
+// putResponseObject resets response object and returns it to pool, drops pointer to HTTP request object.
+func putResponseObject(respObj *ResponseObject) {
+	*respObj = ResponseObject{}
+
+	responseObjectPool.Put(respObj)
+}
+
+// getHeaders gets empty headers map from pool.
+func getHeaders() map[string]string {
+	return headersPool.Get().(map[string]string)
+}
+
+// putHeaders clears headers map and returns it to pool.
+func putHeaders(headers map[string]string) {
+	for k := range headers {
+		delete(headers, k)
+	}
+
+	headersPool.Put(headers)
+}
+
+// getBodyBuffer gets empty request body buffer from pool.
+func getBodyBuffer() *bytes.Buffer {
+	return bodyBufferPool.Get().(*bytes.Buffer)
+}
+
+// putBodyBuffer resets request body buffer and returns it to pool, oversized buffers are dropped.
+// Request body bytes must not be referenced after buffer is returned.
+func putBodyBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBodyBufferSize {
+		return
+	}
+
+	buf.Reset()
+
+	bodyBufferPool.Put(buf)
+}
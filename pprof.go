@@ -0,0 +1,46 @@
+package jrpc2
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// ProfilerLabel defines name of profiler label with invoked method name.
+const ProfilerLabel = "rpc_method"
+
+// SetProfilerLabelsFlag sets flag that wraps every method call in pprof.Do with 'rpc_method=<name>' label,
+// so CPU profiles attribute time per method, labels have minor overhead and are disabled by default.
+// Labeled profile can be inspected with tag filters, e.g.:
+//
+//	go tool pprof -tags cpu.pprof
+//	go tool pprof -tagfocus=rpc_method=subtract cpu.pprof
+//
+// Request context passed to method carries labels, so goroutines started with it are attributed too.
+func (s *Service) SetProfilerLabelsFlag(flag bool) {
+	s.profilerLabels = flag
+}
+
+// GetProfilerLabelsFlag gets profiler labels flag from service object.
+func (s *Service) GetProfilerLabelsFlag() bool {
+	return s.profilerLabels
+}
+
+// callWithProfilerLabels invokes method, with profiler labels when enabled.
+func (s *Service) callWithProfilerLabels(name string, data ParametersObject) (interface{}, *ErrorObject) {
+	if !s.profilerLabels || data.r == nil {
+		return s.Call(name, data)
+	}
+
+	var (
+		result interface{}
+		errObj *ErrorObject
+	)
+
+	pprof.Do(data.r.Context(), pprof.Labels(ProfilerLabel, name), func(ctx context.Context) {
+		data.r = data.r.WithContext(ctx)
+
+		result, errObj = s.Call(name, data)
+	})
+
+	return result, errObj
+}
@@ -0,0 +1,108 @@
+package jrpc2
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// SetTrustedProxies sets networks of trusted upstream proxies (gateways), requests from other sources
+// must not be trusted to carry authentication data in headers. Peers connected over unix socket have
+// no network address, see SetTrustUnixSocketPeersFlag.
+func (s *Service) SetTrustedProxies(networks []string) error {
+	// networks as native object
+	netsObj := make([]*net.IPNet, 0, len(networks))
+
+	// process network lists
+	for _, network := range networks {
+		_, netObj, err := net.ParseCIDR(network)
+		if err != nil {
+			return fmt.Errorf("invalid network '%s': %w", network, err)
+		}
+
+		if netObj == nil {
+			return fmt.Errorf("invalid network '%s'", network)
+		}
+
+		netsObj = append(netsObj, netObj)
+	}
+
+	s.trustedProxies = netsObj
+
+	return nil
+}
+
+// GetTrustedProxies gets networks of trusted upstream proxies (gateways).
+func (s *Service) GetTrustedProxies() []string {
+	networks := make([]string, 0, len(s.trustedProxies))
+
+	for _, network := range s.trustedProxies {
+		networks = append(networks, network.String())
+	}
+
+	return networks
+}
+
+// SetTrustUnixSocketPeersFlag sets flag that trusts every peer connected over unix socket (see Start)
+// as upstream proxy, unix socket peers have no network address, so they never match SetTrustedProxies networks.
+// Enable only when socket is accessible solely by proxy (gateway), see SetSocketPermissions.
+func (s *Service) SetTrustUnixSocketPeersFlag(flag bool) {
+	s.trustUnixPeers = flag
+}
+
+// GetTrustUnixSocketPeersFlag gets flag that trusts peers connected over unix socket as upstream proxies.
+func (s *Service) GetTrustUnixSocketPeersFlag() bool {
+	return s.trustUnixPeers
+}
+
+// SetTrustedPrincipalHeader sets HTTP header (e.g. X-Authenticated-User) that contains
+// authenticated principal, set by auth gateway. Header is honored only for requests from trusted proxies,
+// for other sources header is stripped from request. Empty header name (default) disables this mode.
+func (s *Service) SetTrustedPrincipalHeader(header string) {
+	s.principalHeader = strings.TrimSpace(header)
+}
+
+// GetTrustedPrincipalHeader gets HTTP header that contains authenticated principal.
+func (s *Service) GetTrustedPrincipalHeader() string {
+	return s.principalHeader
+}
+
+// isTrustedProxy checks that request was received directly from trusted proxy (gateway).
+func (s *Service) isTrustedProxy(r *http.Request) bool {
+	if s.trustUnixPeers && isUnixSocketRequest(r) {
+		return true
+	}
+
+	return isRemoteNetworkAllowed(s.trustedProxies, GetClientAddressFromRequest(r))
+}
+
+// isUnixSocketRequest checks that request was received over unix socket.
+func isUnixSocketRequest(r *http.Request) bool {
+	addr, ok := r.Context().Value(http.LocalAddrContextKey).(net.Addr)
+
+	return ok && addr.Network() == "unix"
+}
+
+// setPrincipalFromHeader sets authenticated principal from trusted header,
+// strips header from requests of untrusted sources.
+func (s *Service) setPrincipalFromHeader(r *http.Request) *http.Request {
+	if s.principalHeader == "" {
+		return r
+	}
+
+	principal := strings.TrimSpace(r.Header.Get(s.principalHeader))
+
+	if !s.isTrustedProxy(r) {
+		// spoofed header, must not be visible to methods
+		r.Header.Del(s.principalHeader)
+
+		return r
+	}
+
+	if principal == "" {
+		return r
+	}
+
+	return r.WithContext(contextWithPrincipal(r.Context(), principal))
+}
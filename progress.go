@@ -0,0 +1,158 @@
+package jrpc2
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+)
+
+// ProgressMethod defines method name of progress notifications pushed during method call.
+const ProgressMethod = "rpc.progress"
+
+// progress describes progress stream of method call, started by first progress notification.
+type progress struct {
+	mu sync.Mutex
+
+	w       http.ResponseWriter
+	id      *json.RawMessage
+	headers map[string]string
+
+	started bool   // response headers are written, final response is sent as last event
+	done    bool   // final response is sent, later notifications are dropped
+	status  string // final stream status
+}
+
+// progressParamsObject represents params member of progress notification.
+type progressParamsObject struct {
+	// ID contains ID of request that reports progress
+	ID *json.RawMessage `json:"id"`
+	// Progress contains progress data pushed by method
+	Progress interface{} `json:"progress"`
+}
+
+// progressNotificationObject represents JSON-RPC 2.0 progress notification pushed to client.
+type progressNotificationObject struct {
+	// Jsonrpc specifies the version of the JSON-RPC protocol, equals to "2.0"
+	Jsonrpc string `json:"jsonrpc"`
+	// Method contains progress method name
+	Method string `json:"method"`
+	// Params holds request ID and progress data
+	Params progressParamsObject `json:"params"`
+}
+
+// Progress pushes progress of long running method call to client, call result is returned by method as usual.
+// When client accepts 'text/event-stream', first progress notification starts server-sent events stream,
+// every progress value is pushed as JSON-RPC 2.0 notification object
+// `{"jsonrpc": "2.0", "method": "rpc.progress", "params": {"id": <request id>, "progress": <value>}}`
+// and final JSON-RPC 2.0 response object is sent as last event, followed by X-RPC-Status trailer.
+// For other requests (and notifications) progress is not reported. Progress stream can not be combined with Subscribe.
+func (p ParametersObject) Progress(v interface{}) {
+	if p.r == nil {
+		return
+	}
+
+	pr := progressFromContext(p.r.Context())
+	if pr == nil {
+		return
+	}
+
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	if pr.done || !pr.start() {
+		return
+	}
+
+	data, err := json.Marshal(
+		progressNotificationObject{
+			Jsonrpc: JSONRPCVersion,
+			Method:  ProgressMethod,
+			Params: progressParamsObject{
+				ID:       pr.id,
+				Progress: v,
+			},
+		},
+	)
+	if err != nil { // skip values that can not be encoded
+		pr.status = StreamStatusError
+
+		return
+	}
+
+	_ = writeEvent(pr.w, data)
+}
+
+// start writes response headers of progress stream once, returns false when streaming is not supported.
+func (pr *progress) start() bool {
+	if pr.started {
+		return true
+	}
+
+	// streaming requires flushing support from HTTP writer interface
+	if _, ok := pr.w.(http.Flusher); !ok {
+		return false
+	}
+
+	// set custom response headers
+	for header, value := range pr.headers {
+		pr.w.Header().Set(header, value)
+	}
+
+	// set stream response headers
+	pr.w.Header().Set("Content-Type", EventStreamContentType)
+	pr.w.Header().Set("Cache-Control", "no-cache")
+
+	// declare final status trailer before body is written
+	pr.w.Header().Set("Trailer", StatusTrailer)
+
+	// write response code to HTTP writer interface
+	pr.w.WriteHeader(http.StatusOK)
+
+	pr.started = true
+	pr.status = StreamStatusOK
+
+	return true
+}
+
+// isStarted checks that progress stream is started.
+func (pr *progress) isStarted() bool {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	return pr.started
+}
+
+// writeProgressResult writes final response object as last event of progress stream.
+func (s *Service) writeProgressResult(w http.ResponseWriter, respObj *ResponseObject, pr *progress) {
+	pr.mu.Lock()
+	defer pr.mu.Unlock()
+
+	pr.done = true
+
+	// final status is known only after response is written
+	defer func() {
+		w.Header().Set(StatusTrailer, pr.status)
+	}()
+
+	// result and error members are mutually exclusive, error takes precedence
+	if respObj.Error != nil {
+		respObj.Result = nil
+	}
+
+	// set non-fatal warnings added by method
+	setResponseMeta(respObj)
+
+	// get response bytes
+	resp := s.marshalResponse(respObj)
+
+	// run response hook function, status code is already sent
+	if err := s.resp(respObj.r, s.redactResponse(respObj.r, resp)); err != nil {
+		pr.status = StreamStatusError
+
+		return
+	}
+
+	if err := writeEvent(w, resp); err != nil {
+		pr.status = StreamStatusError
+	}
+}
@@ -0,0 +1,119 @@
+package jrpc2
+
+import (
+	"fmt"
+	"sort"
+)
+
+// MethodProvider represents self-contained unit of RPC methods, registered in bulk with RegisterProvider.
+type MethodProvider interface {
+	// Methods returns mapping of method names to handlers provided by unit
+	Methods() map[string]Handler
+}
+
+// RegisterProvider registers all methods of provider, provider is kept to be re-queried by ReloadProviders.
+// Name collision with already registered method (including methods of other providers) is reported as error,
+// no methods of provider are registered in that case.
+func (s *Service) RegisterProvider(p MethodProvider) error {
+	if s.proxy {
+		return fmt.Errorf("method providers can not be registered in proxy mode")
+	}
+
+	methods := p.Methods()
+	current := s.currentRegistry()
+
+	for _, name := range sortedHandlerNames(methods) {
+		if err := s.checkMethodName(name); err != nil {
+			return err
+		}
+
+		if _, ok := current.methods[name]; ok {
+			return fmt.Errorf("method '%s' is already registered", name)
+		}
+	}
+
+	if s.provided == nil {
+		s.provided = make(map[string]struct{})
+	}
+
+	provided := make(map[string]method, len(methods))
+
+	for name, f := range methods {
+		provided[name] = method{
+			Method:   f,
+			Disabled: new(int32),
+		}
+
+		s.provided[name] = struct{}{}
+	}
+
+	s.setMethods(provided)
+
+	s.providers = append(s.providers, p)
+
+	return nil
+}
+
+// ReloadProviders re-queries all registered providers and atomically replaces provided methods
+// (see ReplaceRegistry), methods registered directly are kept. Per-method settings of methods
+// that are still provided are preserved, methods no longer provided are removed.
+// Safe to call while serving, e.g. on SIGHUP with signal.Notify, calls must not overlap.
+func (s *Service) ReloadProviders() error {
+	current := s.currentRegistry()
+
+	reg := NewRegistry()
+
+	for name, m := range current.methods {
+		if _, ok := s.provided[name]; !ok {
+			reg.methods[name] = m
+		}
+	}
+
+	provided := make(map[string]struct{})
+
+	for _, p := range s.providers {
+		methods := p.Methods()
+
+		for _, name := range sortedHandlerNames(methods) {
+			if _, ok := reg.methods[name]; ok {
+				return fmt.Errorf("method '%s' is already registered", name)
+			}
+
+			// keep per-method settings of reloaded method
+			m, ok := current.methods[name]
+			if !ok {
+				m = method{
+					Disabled: new(int32),
+				}
+			}
+
+			m.Method = methods[name]
+
+			reg.methods[name] = m
+			provided[name] = struct{}{}
+		}
+	}
+
+	reg.aliases = current.aliases
+
+	if err := s.ReplaceRegistry(reg); err != nil {
+		return err
+	}
+
+	s.provided = provided
+
+	return nil
+}
+
+// sortedHandlerNames returns sorted method names of handlers mapping, used for deterministic collision errors.
+func sortedHandlerNames(methods map[string]Handler) []string {
+	names := make([]string, 0, len(methods))
+
+	for name := range methods {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
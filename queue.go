@@ -0,0 +1,243 @@
+package jrpc2
+
+import (
+	"container/heap"
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+)
+
+// waiter describes request waiting for free method call slot.
+type waiter struct {
+	priority int
+	seq      uint64
+
+	ch chan struct{} // closed when slot is granted
+
+	granted   bool
+	cancelled bool
+}
+
+// waitQueue implements heap of waiters, higher priority first, FIFO for equal priority.
+type waitQueue []*waiter
+
+func (q waitQueue) Len() int { return len(q) }
+
+func (q waitQueue) Less(i, j int) bool {
+	if q[i].priority != q[j].priority {
+		return q[i].priority > q[j].priority
+	}
+
+	return q[i].seq < q[j].seq
+}
+
+func (q waitQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+
+func (q *waitQueue) Push(x interface{}) {
+	w, _ := x.(*waiter)
+	*q = append(*q, w)
+}
+
+func (q *waitQueue) Pop() interface{} {
+	old := *q
+	n := len(old)
+	w := old[n-1]
+	old[n-1] = nil
+	*q = old[:n-1]
+
+	return w
+}
+
+// limiter limits number of concurrent method calls, queued calls are served by priority.
+type limiter struct {
+	mu sync.Mutex
+
+	max    int
+	active int
+	seq    uint64
+
+	queue waitQueue
+}
+
+// newLimiter creates limiter for provided number of concurrent method calls.
+func newLimiter(max int) *limiter {
+	return &limiter{
+		max:   max,
+		queue: make(waitQueue, 0),
+	}
+}
+
+// acquire waits for free method call slot, returns context error when context is done while waiting.
+func (l *limiter) acquire(ctx context.Context, priority int) error {
+	l.mu.Lock()
+
+	if l.active < l.max && l.queue.Len() == 0 {
+		l.active++
+		l.mu.Unlock()
+
+		return nil
+	}
+
+	l.seq++
+
+	w := &waiter{
+		priority: priority,
+		seq:      l.seq,
+		ch:       make(chan struct{}),
+	}
+
+	heap.Push(&l.queue, w)
+	l.mu.Unlock()
+
+	select {
+	case <-w.ch:
+		return nil
+	case <-ctx.Done():
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if w.granted { // slot was granted concurrently, pass it to next waiter
+			l.releaseLocked()
+		} else {
+			w.cancelled = true
+		}
+
+		return ctx.Err()
+	}
+}
+
+// release frees method call slot, slot is passed to waiter with highest priority.
+func (l *limiter) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.releaseLocked()
+}
+
+func (l *limiter) releaseLocked() {
+	for l.queue.Len() > 0 {
+		w, _ := heap.Pop(&l.queue).(*waiter)
+		if w.cancelled {
+			continue
+		}
+
+		w.granted = true
+		close(w.ch)
+
+		return
+	}
+
+	l.active--
+}
+
+// waiting returns number of queued method calls.
+func (l *limiter) waiting() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	n := 0
+
+	for _, w := range l.queue {
+		if !w.cancelled {
+			n++
+		}
+	}
+
+	return n
+}
+
+// SetMaxConcurrency sets maximal number of concurrent method calls, excess calls are queued.
+// Zero (default) disables limit. Must be set before service is started.
+func (s *Service) SetMaxConcurrency(max int) {
+	if max <= 0 {
+		s.limiter = nil
+
+		return
+	}
+
+	s.limiter = newLimiter(max)
+}
+
+// GetMaxConcurrency gets maximal number of concurrent method calls.
+func (s *Service) GetMaxConcurrency() int {
+	if s.limiter == nil {
+		return 0
+	}
+
+	return s.limiter.max
+}
+
+// SetPriorityFunction defines function that maps request to priority level, used for queued method calls
+// when concurrency limit is reached. Calls with higher priority are served first, equal priorities keep arrival order.
+func (s *Service) SetPriorityFunction(f func(r *http.Request, method string) int) {
+	s.priority = f
+}
+
+// acquireCallSlot waits for free method call slot when concurrency limit is set,
+// returned function must be called to free slot.
+func (s *Service) acquireCallSlot(r *http.Request, name string) (func(), *ErrorObject) {
+	// method own limit is checked first, rejected calls do not occupy service-wide slots
+	releaseMethod, errObj := s.acquireMethodSlot(r, name)
+	if errObj != nil {
+		return nil, errObj
+	}
+
+	if s.limiter == nil {
+		return releaseMethod, nil
+	}
+
+	var priority int
+
+	if s.priority != nil {
+		priority = s.priority(r, name)
+	}
+
+	if err := s.limiter.acquire(r.Context(), priority); err != nil {
+		releaseMethod()
+
+		return nil, &ErrorObject{
+			Code:    InternalErrorCode,
+			Message: InternalErrorMessage,
+			Data:    err.Error(),
+		}
+	}
+
+	return func() {
+		s.limiter.release()
+		releaseMethod()
+	}, nil
+}
+
+// SetMethodConcurrency sets maximal number of concurrent calls of registered method, calls exceeding limit
+// are rejected with Server busy error and 503 HTTP status code while other methods proceed.
+// Zero (default) disables limit. Must be set before service is started.
+func (s *Service) SetMethodConcurrency(name string, limit int) error {
+	return s.updateMethod(name, func(m *method) {
+		m.Semaphore = nil
+
+		if limit > 0 {
+			m.Semaphore = make(chan struct{}, limit)
+		}
+	})
+}
+
+// acquireMethodSlot takes method call slot when method has own concurrency limit,
+// returned function must be called to free slot.
+func (s *Service) acquireMethodSlot(r *http.Request, name string) (func(), *ErrorObject) {
+	m, ok := s.lookupMethod(r, name)
+	if !ok || m.Semaphore == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case m.Semaphore <- struct{}{}:
+		return func() { <-m.Semaphore }, nil
+	default:
+		return nil, &ErrorObject{
+			Code:    ServerBusyCode,
+			Message: ServerBusyMessage,
+			Data:    fmt.Sprintf("method '%s' concurrency limit of %d exceeded", name, cap(m.Semaphore)),
+		}
+	}
+}
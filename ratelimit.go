@@ -0,0 +1,34 @@
+package jrpc2
+
+import (
+	"strconv"
+	"time"
+)
+
+// Rate limit headers advertised to clients.
+const (
+	// RateLimitLimitHeader contains maximal number of requests in current window
+	RateLimitLimitHeader = "X-RateLimit-Limit"
+	// RateLimitRemainingHeader contains number of requests left in current window
+	RateLimitRemainingHeader = "X-RateLimit-Remaining"
+	// RateLimitResetHeader contains time when current window resets, as Unix time in seconds
+	RateLimitResetHeader = "X-RateLimit-Reset"
+)
+
+// SetRateLimitHeaders advertises rate limit state to client in response headers, intended for methods
+// and middlewares that implement rate limiting, cooperative clients throttle calls when remaining is zero
+// until reset time.
+func (p ParametersObject) SetRateLimitHeaders(limit, remaining int, reset time.Time) {
+	if p.r == nil {
+		return
+	}
+
+	headers := headersFromContext(p.r.Context())
+	if headers == nil {
+		return
+	}
+
+	headers[RateLimitLimitHeader] = strconv.Itoa(limit)
+	headers[RateLimitRemainingHeader] = strconv.Itoa(remaining)
+	headers[RateLimitResetHeader] = strconv.FormatInt(reset.Unix(), 10)
+}
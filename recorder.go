@@ -0,0 +1,33 @@
+package jrpc2
+
+import (
+	"bytes"
+	"net/http"
+)
+
+// responseRecorder collects response to internally dispatched request (replay, REST routes).
+type responseRecorder struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+// newResponseRecorder creates response recorder with 200 HTTP status code.
+func newResponseRecorder() *responseRecorder {
+	return &responseRecorder{
+		header: make(http.Header),
+		code:   http.StatusOK,
+	}
+}
+
+func (w *responseRecorder) Header() http.Header {
+	return w.header
+}
+
+func (w *responseRecorder) Write(b []byte) (int, error) {
+	return w.body.Write(b)
+}
+
+func (w *responseRecorder) WriteHeader(code int) {
+	w.code = code
+}
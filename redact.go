@@ -0,0 +1,178 @@
+package jrpc2
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// RedactedValue replaces values of redacted fields.
+const RedactedValue = "***"
+
+// SetRedactedFields sets JSON field paths that are redacted for every method before request/response bytes
+// are passed to request and response hooks, paths are dot separated and start from envelope member,
+// e.g. 'params.password', 'params.users.token' (applied to every element of 'users' array),
+// 'params.0' (first positional parameter) or 'result.token'.
+// Redacted payload is re-encoded, so order of object members may differ from original payload,
+// hooks receive only redacted bytes, request processing uses original payload.
+func (s *Service) SetRedactedFields(paths ...string) {
+	s.redactPaths = append([]string(nil), paths...)
+}
+
+// SetMethodRedactedFields sets JSON field paths that are redacted for registered method,
+// in addition to service-wide fields, see SetRedactedFields.
+func (s *Service) SetMethodRedactedFields(name string, paths ...string) error {
+	if err := s.updateMethod(name, func(m *method) {
+		m.RedactPaths = append([]string(nil), paths...)
+	}); err != nil {
+		return err
+	}
+
+	if len(paths) > 0 {
+		s.methodRedaction = true
+	}
+
+	return nil
+}
+
+// getRedactPaths returns service-wide and per-method paths of redacted fields.
+func (s *Service) getRedactPaths(r *http.Request, name string) []string {
+	paths := s.redactPaths
+
+	if m, ok := s.lookupMethod(r, name); ok && len(m.RedactPaths) > 0 {
+		paths = append(append([]string(nil), paths...), m.RedactPaths...)
+	}
+
+	return paths
+}
+
+// isRedactionEnabled checks that any redacted fields are configured.
+func (s *Service) isRedactionEnabled() bool {
+	return len(s.redactPaths) > 0 || s.methodRedaction
+}
+
+// redactRequest redacts request bytes, method name is taken from request payload.
+func (s *Service) redactRequest(r *http.Request, data []byte) []byte {
+	if !s.isRedactionEnabled() {
+		return data
+	}
+
+	v, ok := decodeRedactable(data)
+	if !ok {
+		return data
+	}
+
+	var name string
+
+	if obj, ok := v.(map[string]interface{}); ok {
+		name, _ = obj["method"].(string)
+	}
+
+	return encodeRedacted(data, v, s.getRedactPaths(r, name))
+}
+
+// redactResponse redacts response bytes, method name is taken from request context.
+func (s *Service) redactResponse(r *http.Request, data []byte) []byte {
+	if !s.isRedactionEnabled() {
+		return data
+	}
+
+	v, ok := decodeRedactable(data)
+	if !ok {
+		return data
+	}
+
+	return encodeRedacted(data, v, s.getRedactPaths(r, methodNameFromContext(r.Context())))
+}
+
+// decodeRedactable decodes JSON payload preserving numbers.
+func decodeRedactable(data []byte) (interface{}, bool) {
+	var v interface{}
+
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+
+	if err := d.Decode(&v); err != nil {
+		return nil, false
+	}
+
+	return v, true
+}
+
+// encodeRedacted redacts field paths in decoded payload, returns original payload when nothing was redacted.
+func encodeRedacted(data []byte, v interface{}, paths []string) []byte {
+	var redacted bool
+
+	for _, path := range paths {
+		if path = strings.TrimSpace(path); path == "" {
+			continue
+		}
+
+		if redactPath(v, strings.Split(path, ".")) {
+			redacted = true
+		}
+	}
+
+	if !redacted {
+		return data
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		return data
+	}
+
+	return b
+}
+
+// redactPath replaces value at field path with RedactedValue, arrays are traversed by numeric index,
+// non-numeric segment is applied to every array element.
+func redactPath(v interface{}, path []string) bool {
+	if len(path) == 0 {
+		return false
+	}
+
+	switch node := v.(type) {
+	case map[string]interface{}:
+		child, ok := node[path[0]]
+		if !ok {
+			return false
+		}
+
+		if len(path) == 1 {
+			node[path[0]] = RedactedValue
+
+			return true
+		}
+
+		return redactPath(child, path[1:])
+	case []interface{}:
+		if i, err := strconv.Atoi(path[0]); err == nil {
+			if i < 0 || i >= len(node) {
+				return false
+			}
+
+			if len(path) == 1 {
+				node[i] = RedactedValue
+
+				return true
+			}
+
+			return redactPath(node[i], path[1:])
+		}
+
+		var redacted bool
+
+		for _, el := range node {
+			if redactPath(el, path) {
+				redacted = true
+			}
+		}
+
+		return redacted
+	default:
+		return false
+	}
+}
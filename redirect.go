@@ -0,0 +1,27 @@
+package jrpc2
+
+// RedirectData represents data member of method moved error, carries new location of method.
+type RedirectData struct {
+	// Location contains URL of service that serves method now
+	Location string `json:"location"`
+}
+
+// Redirect responds that method has moved to another service, new location is sent in 'Location' header
+// and in data member of error object with MethodMovedCode code, so clients can retry call there:
+//
+//	return data.Redirect("https://billing.example.com/rpc")
+func (p ParametersObject) Redirect(url string) (interface{}, *ErrorObject) {
+	if p.r != nil {
+		if headers := headersFromContext(p.r.Context()); headers != nil {
+			headers["Location"] = url
+		}
+	}
+
+	return nil, &ErrorObject{
+		Code:    MethodMovedCode,
+		Message: MethodMovedMessage,
+		Data: RedirectData{
+			Location: url,
+		},
+	}
+}
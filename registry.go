@@ -0,0 +1,175 @@
+package jrpc2
+
+import (
+	"fmt"
+	"net/http"
+	"sync/atomic"
+)
+
+// Registry represents set of registered methods and aliases, used to swap whole method set of service at once.
+type Registry struct {
+	// fields below are intentionally unexported
+	methods map[string]method // mapping of registered methods
+	aliases map[string]string // mapping of method aliases to method names
+}
+
+// NewRegistry creates empty method registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		methods: make(map[string]method),
+	}
+}
+
+// Register maps the given method name to the given function in registry,
+// optional middlewares are applied the same way as for Service.Register.
+func (reg *Registry) Register(name string, f Handler, mws ...Middleware) {
+	reg.methods[name] = method{
+		Method:      f,
+		Middlewares: mws,
+		Disabled:    new(int32),
+	}
+}
+
+// copyRegistry creates copy of methods and aliases, runtime enabled state is not shared.
+func copyRegistry(methods map[string]method, aliases map[string]string) *Registry {
+	reg := &Registry{
+		methods: make(map[string]method, len(methods)),
+	}
+
+	for k, v := range methods {
+		if v.Disabled != nil {
+			disabled := atomic.LoadInt32(v.Disabled)
+			v.Disabled = &disabled
+		}
+
+		// concurrent calls are not shared
+		if v.Semaphore != nil {
+			v.Semaphore = make(chan struct{}, cap(v.Semaphore))
+		}
+
+		reg.methods[k] = v
+	}
+
+	if aliases != nil {
+		reg.aliases = make(map[string]string, len(aliases))
+
+		for k, v := range aliases {
+			reg.aliases[k] = v
+		}
+	}
+
+	return reg
+}
+
+// Registry returns snapshot of methods (with per-method settings) and aliases registered in service,
+// snapshot can be restored later with ReplaceRegistry.
+// Methods of staging service can be configured with per-method setters and then moved to serving service.
+func (s *Service) Registry() *Registry {
+	reg := s.currentRegistry()
+
+	return copyRegistry(reg.methods, reg.aliases)
+}
+
+// ReplaceRegistry atomically replaces whole method set of service with methods and aliases of registry,
+// in-flight calls keep using method set they started with, new calls see new method set.
+// Registry is copied, so later changes of registry do not affect service. Use instead of
+// unregistering and re-registering methods while serving.
+func (s *Service) ReplaceRegistry(reg *Registry) error {
+	if s.proxy {
+		return fmt.Errorf("method registry can not be replaced in proxy mode")
+	}
+
+	for name := range reg.methods {
+		if err := s.checkMethodName(name); err != nil {
+			return err
+		}
+	}
+
+	c := copyRegistry(reg.methods, reg.aliases)
+
+	s.registryMu.Lock()
+	s.methods, s.aliases = c.methods, c.aliases
+	s.registryMu.Unlock()
+
+	return nil
+}
+
+// currentRegistry returns method set currently used by service.
+func (s *Service) currentRegistry() *Registry {
+	s.registryMu.RLock()
+	defer s.registryMu.RUnlock()
+
+	return &Registry{
+		methods: s.methods,
+		aliases: s.aliases,
+	}
+}
+
+// updateMethod applies change to registered method, method set is copied on write under registry lock,
+// so calls in flight keep using method set they started with and per-method setters are safe while serving.
+func (s *Service) updateMethod(name string, update func(m *method)) error {
+	s.registryMu.Lock()
+	defer s.registryMu.Unlock()
+
+	m, ok := s.methods[name]
+	if !ok {
+		return fmt.Errorf("method '%s' is not registered", name)
+	}
+
+	update(&m)
+
+	s.methods = copyMethods(s.methods)
+	s.methods[name] = m
+
+	return nil
+}
+
+// setMethods stores methods in copy of current method set under registry lock.
+func (s *Service) setMethods(methods map[string]method) {
+	s.registryMu.Lock()
+	defer s.registryMu.Unlock()
+
+	c := copyMethods(s.methods)
+
+	for name, m := range methods {
+		c[name] = m
+	}
+
+	s.methods = c
+}
+
+// copyMethods returns shallow copy of method set.
+func copyMethods(methods map[string]method) map[string]method {
+	c := make(map[string]method, len(methods)+1)
+
+	for k, v := range methods {
+		c[k] = v
+	}
+
+	return c
+}
+
+// registryFor returns method set used by HTTP request, current method set when called without request.
+func (s *Service) registryFor(r *http.Request) *Registry {
+	if r != nil {
+		if reg := registryFromContext(r.Context()); reg != nil {
+			return reg
+		}
+	}
+
+	return s.currentRegistry()
+}
+
+// resolveAlias returns canonical method name for alias, other names are returned as is.
+func (reg *Registry) resolveAlias(name string) string {
+	for i := 0; i <= len(reg.aliases); i++ {
+		next, ok := reg.aliases[name]
+		if !ok {
+			return name
+		}
+
+		name = next
+	}
+
+	return name
+}
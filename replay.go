@@ -0,0 +1,168 @@
+package jrpc2
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// CapturedRequest represents raw request captured for debug replay.
+type CapturedRequest struct {
+	// Time contains time when request was received
+	Time time.Time `json:"time"`
+	// RemoteAddr contains network address of request source
+	RemoteAddr string `json:"remote_addr"`
+	// Body contains raw request bytes, redacted the same way as for request hook
+	Body json.RawMessage `json:"body"`
+}
+
+// ReplayResult represents response to replayed request.
+type ReplayResult struct {
+	// Request contains replayed request
+	Request CapturedRequest `json:"request"`
+	// StatusCode contains HTTP status code of response
+	StatusCode int `json:"status_code"`
+	// Body contains raw response bytes
+	Body json.RawMessage `json:"body,omitempty"`
+}
+
+// requestCapture holds last captured requests in ring buffer.
+type requestCapture struct {
+	mu      sync.Mutex
+	entries []CapturedRequest
+	next    int
+	full    bool
+}
+
+// newRequestCapture creates ring buffer for provided number of requests.
+func newRequestCapture(size int) *requestCapture {
+	return &requestCapture{
+		entries: make([]CapturedRequest, size),
+	}
+}
+
+// add stores request, oldest request is overwritten when buffer is full.
+func (c *requestCapture) add(req CapturedRequest) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.entries[c.next] = req
+
+	if c.next++; c.next == len(c.entries) {
+		c.next = 0
+		c.full = true
+	}
+}
+
+// last returns up to n last captured requests, oldest first.
+func (c *requestCapture) last(n int) []CapturedRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	count := c.next
+	if c.full {
+		count = len(c.entries)
+	}
+
+	if n <= 0 || n > count {
+		n = count
+	}
+
+	out := make([]CapturedRequest, 0, n)
+
+	for i := n; i > 0; i-- {
+		idx := (c.next - i + len(c.entries)) % len(c.entries)
+		out = append(out, c.entries[idx])
+	}
+
+	return out
+}
+
+// clear drops all captured requests.
+func (c *requestCapture) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for i := range c.entries {
+		c.entries[i] = CapturedRequest{}
+	}
+
+	c.next, c.full = 0, false
+}
+
+// SetRequestCaptureSize sets number of last requests captured for debug replay (see Replay), zero (default)
+// disables capture. Captured request bytes are redacted the same way as for request hook (see SetRedactedFields),
+// but may still contain sensitive data, enable only for debugging. Must be set before service is started.
+func (s *Service) SetRequestCaptureSize(n int) {
+	if n <= 0 {
+		s.capture = nil
+
+		return
+	}
+
+	s.capture = newRequestCapture(n)
+}
+
+// GetRequestCaptureSize gets number of last requests captured for debug replay.
+func (s *Service) GetRequestCaptureSize() int {
+	if s.capture == nil {
+		return 0
+	}
+
+	return len(s.capture.entries)
+}
+
+// CapturedRequests returns up to n last captured requests (all when n is zero), oldest first.
+func (s *Service) CapturedRequests(n int) []CapturedRequest {
+	if s.capture == nil {
+		return nil
+	}
+
+	return s.capture.last(n)
+}
+
+// Replay re-sends up to n last captured requests (all when n is zero) to service, oldest first,
+// and returns their responses. Replayed requests skip Authorization check and are not captured again.
+func (s *Service) Replay(ctx context.Context, n int) []ReplayResult {
+	requests := s.CapturedRequests(n)
+	results := make([]ReplayResult, 0, len(requests))
+
+	for _, captured := range requests {
+		r, err := http.NewRequest(http.MethodPost, "/", bytes.NewReader(captured.Body))
+		if err != nil {
+			continue
+		}
+
+		r.RemoteAddr = captured.RemoteAddr
+		r.Header.Set("Content-Type", "application/json")
+		r.Header.Set("Accept", "application/json")
+
+		w := newResponseRecorder()
+
+		s.ServeHTTPContext(contextWithReplayFlag(ctx, true), w, r)
+
+		results = append(results, ReplayResult{
+			Request:    captured,
+			StatusCode: w.code,
+			Body:       w.body.Bytes(),
+		})
+	}
+
+	return results
+}
+
+// captureRequest stores redacted request bytes when capture is enabled.
+func (s *Service) captureRequest(r *http.Request, data []byte) {
+	if s.capture == nil || replayFlagFromContext(r.Context()) {
+		return
+	}
+
+	s.capture.add(CapturedRequest{
+		Time:       time.Now(),
+		RemoteAddr: r.RemoteAddr,
+		Body:       append(json.RawMessage(nil), data...),
+	})
+}
@@ -11,7 +11,9 @@ type RequestObject struct {
 	// Method contains the name of the method to be invoked
 	Method string `json:"method"`
 	// Params holds Raw JSON parameter data to be used during the invocation of the method
-	Params json.RawMessage `json:"params"`
+	Params json.RawMessage `json:"params,omitempty"`
 	// ID is a unique identifier established by the client
 	ID *json.RawMessage `json:"id,omitempty"`
+	// Meta holds Raw JSON request metadata (non-standard), used only when enabled by service
+	Meta json.RawMessage `json:"meta,omitempty"`
 }
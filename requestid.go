@@ -0,0 +1,59 @@
+package jrpc2
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"strings"
+)
+
+// RequestIDHeader defines HTTP header used for request correlation.
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength defines maximum accepted length of client provided request ID.
+const maxRequestIDLength = 128
+
+// genRequestID generates new random request ID.
+func genRequestID() string {
+	b := make([]byte, 16)
+
+	if _, err := rand.Read(b); err != nil {
+		return "00000000000000000000000000000000"
+	}
+
+	return hex.EncodeToString(b)
+}
+
+// isValidRequestID checks that request ID contains only visible ASCII characters,
+// so it can be safely echoed in response header and written to logs.
+func isValidRequestID(id string) bool {
+	if len(id) == 0 || len(id) > maxRequestIDLength {
+		return false
+	}
+
+	for i := 0; i < len(id); i++ {
+		if id[i] < '!' || id[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// getRequestIDFromHeader returns request ID from HTTP request header
+// or generates a new one when header is absent or invalid.
+func getRequestIDFromHeader(r *http.Request) string {
+	id := strings.TrimSpace(r.Header.Get(RequestIDHeader))
+
+	if !isValidRequestID(id) {
+		return genRequestID()
+	}
+
+	return id
+}
+
+// GetRequestID returns request correlation ID (X-Request-ID) of HTTP request processed by service,
+// can be used inside Request/Response hook functions.
+func GetRequestID(r *http.Request) string {
+	return requestIDFromContext(r.Context())
+}
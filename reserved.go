@@ -0,0 +1,40 @@
+package jrpc2
+
+import (
+	"fmt"
+	"strings"
+)
+
+// nolint:gochecknoglobals
+var reservedMethodPrefixes = []string{
+	"rpc.",    // reserved by JSON-RPC 2.0 specification
+	"system.", // reserved for package built-ins
+}
+
+// AllowReservedMethods sets flag that permits registration of methods in reserved namespace ('rpc.*', 'system.*'),
+// reserved methods are also callable when flag is set, use with care as they may shadow built-in methods.
+func (s *Service) AllowReservedMethods(flag bool) {
+	s.allowReserved = flag
+}
+
+// isReservedMethod reports whether method name belongs to reserved namespace.
+func isReservedMethod(name string) bool {
+	name = strings.ToLower(name)
+
+	for _, prefix := range reservedMethodPrefixes {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// checkMethodName validates method name at registration time.
+func (s *Service) checkMethodName(name string) error {
+	if isReservedMethod(name) && !s.allowReserved {
+		return fmt.Errorf("method name '%s' is in reserved namespace", name)
+	}
+
+	return nil
+}
@@ -0,0 +1,14 @@
+package jrpc2
+
+// Reset clears runtime state accumulated by service: cached method results (see SetMethodCache)
+// and captured requests (see SetRequestCaptureSize), registered methods and settings are kept.
+// Reset is safe to call while service handles requests, calls in progress may repopulate state.
+func (s *Service) Reset() {
+	if s.cache != nil {
+		s.cache.clear()
+	}
+
+	if s.capture != nil {
+		s.capture.clear()
+	}
+}
@@ -14,8 +14,10 @@ type ResponseObject struct {
 	Error *ErrorObject `json:"error,omitempty"`
 	// Result contains the result of the called method
 	Result interface{} `json:"result,omitempty"`
-	// ID contains the client established request id or null
-	ID *json.RawMessage `json:"id,omitempty"`
+	// ID contains the client established request id or null, always present (null when request id is undetectable)
+	ID *json.RawMessage `json:"id"`
+	// Meta contains reserved non-fatal information about call (warnings)
+	Meta *ResponseMeta `json:"meta,omitempty"`
 
 	r *http.Request // contains pointer to HTTP Request object
 }
@@ -47,3 +49,52 @@ func (responseObject *ResponseObject) Marshal() []byte {
 
 	return b
 }
+
+// MarshalErrorData defines sanitized data of Internal error that replaces response with un-marshalable result.
+const MarshalErrorData = "method result can not be serialized"
+
+// SetMarshalErrorFunction defines function that converts response marshaling failure (e.g. method result with channel field
+// or cyclic structure) to error object sent instead of response, sanitized Internal error is sent when function is not set.
+// Marshaling failures are always logged in full with structured logging hook.
+func (s *Service) SetMarshalErrorFunction(f func(r *http.Request, err error) *ErrorObject) {
+	s.marshalError = f
+}
+
+// marshalResponse creates bytes encoded representation of response object,
+// response that fails to marshal is replaced with error response of the same request.
+func (s *Service) marshalResponse(respObj *ResponseObject) []byte {
+	b, err := json.Marshal(respObj)
+	if err == nil {
+		return b
+	}
+
+	s.logEntry(respObj.r, LogEntry{
+		Level:   LogLevelError,
+		Message: "response marshaling failed",
+		Method:  methodNameFromContext(respObj.r.Context()),
+		Fields: map[string]interface{}{
+			"error": err.Error(),
+		},
+	})
+
+	var errObj *ErrorObject
+
+	if s.marshalError != nil {
+		errObj = s.marshalError(respObj.r, err)
+	}
+
+	if errObj == nil {
+		errObj = &ErrorObject{
+			Code:    InternalErrorCode,
+			Message: InternalErrorMessage,
+			Data:    MarshalErrorData,
+		}
+	}
+
+	errResp := DefaultResponseObject()
+	errResp.Error = errObj
+	errResp.ID = respObj.ID
+	errResp.r = respObj.r
+
+	return errResp.Marshal()
+}
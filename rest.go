@@ -0,0 +1,188 @@
+package jrpc2
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+// restRoute maps HTTP method and path pattern to JSON-RPC 2.0 method.
+type restRoute struct {
+	httpMethod string
+	segments   []string
+	name       string
+}
+
+// match checks that request path matches route pattern, returns extracted path params.
+func (rt restRoute) match(httpMethod, path string) (map[string]string, bool) {
+	if httpMethod != rt.httpMethod {
+		return nil, false
+	}
+
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if len(segments) != len(rt.segments) {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+
+	for i, v := range rt.segments {
+		if strings.HasPrefix(v, "{") && strings.HasSuffix(v, "}") {
+			if segments[i] == "" {
+				return nil, false
+			}
+
+			params[v[1:len(v)-1]] = segments[i]
+
+			continue
+		}
+
+		if v != segments[i] {
+			return nil, false
+		}
+	}
+
+	return params, true
+}
+
+// AddRESTRoute maps HTTP method and path pattern (e.g. GET '/user/{id}') to registered JSON-RPC 2.0 method
+// (e.g. 'user.get'), routes are served by RESTHandler. Path params (e.g. 'id') are passed to method
+// as string members of named params, merged over members of JSON object request body.
+func (s *Service) AddRESTRoute(httpMethod, pattern, name string) error {
+	if _, ok := s.currentRegistry().methods[name]; !ok {
+		return fmt.Errorf("method '%s' is not registered", name)
+	}
+
+	if !strings.HasPrefix(pattern, "/") {
+		return fmt.Errorf("route pattern '%s' must start with '/'", pattern)
+	}
+
+	s.restRoutes = append(s.restRoutes, restRoute{
+		httpMethod: strings.ToUpper(httpMethod),
+		segments:   strings.Split(strings.Trim(pattern, "/"), "/"),
+		name:       name,
+	})
+
+	return nil
+}
+
+// RESTHandler returns HTTP handler that serves routes added with AddRESTRoute, so the same methods serve
+// both REST-ish API and JSON-RPC. Requests are dispatched as JSON-RPC 2.0 calls (Authorization, hooks, limits apply),
+// successful call is answered with method result as response body, failed call with error object
+// and HTTP status code of error, unmatched path is answered with 404 HTTP status code.
+func (s *Service) RESTHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, rt := range s.restRoutes {
+			if params, ok := rt.match(r.Method, r.URL.Path); ok {
+				s.serveREST(w, r, rt.name, params)
+
+				return
+			}
+		}
+
+		w.WriteHeader(http.StatusNotFound)
+	})
+}
+
+// serveREST calls method with path params merged over request body params and writes unwrapped response.
+func (s *Service) serveREST(w http.ResponseWriter, r *http.Request, name string, pathParams map[string]string) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	params := make(map[string]json.RawMessage)
+
+	if len(bytes.TrimSpace(body)) != 0 {
+		if err = json.Unmarshal(body, &params); err != nil {
+			writeJSONResponse(w, http.StatusBadRequest, &ErrorObject{
+				Code:    ParseErrorCode,
+				Message: ParseErrorMessage,
+				Data:    "request body must be JSON object",
+			})
+
+			return
+		}
+	}
+
+	for k, v := range pathParams {
+		params[k], _ = json.Marshal(v)
+	}
+
+	rawParams, _ := json.Marshal(params)
+
+	id := json.RawMessage(`1`)
+
+	reqData, _ := json.Marshal(RequestObject{
+		Jsonrpc: JSONRPCVersion,
+		Method:  name,
+		Params:  rawParams,
+		ID:      &id,
+	})
+
+	// dispatch as JSON-RPC 2.0 request over HTTP/1.1
+	rpcReq := r.Clone(r.Context())
+	rpcReq.Method = http.MethodPost
+	rpcReq.Proto, rpcReq.ProtoMajor, rpcReq.ProtoMinor = "HTTP/1.1", 1, 1
+	rpcReq.Body = ioutil.NopCloser(bytes.NewReader(reqData))
+	rpcReq.ContentLength = int64(len(reqData))
+	rpcReq.TransferEncoding = nil
+	rpcReq.Header.Del("Content-Encoding")
+	rpcReq.Header.Set("Content-Type", "application/json")
+	rpcReq.Header.Set("Accept", "application/json")
+
+	rec := newResponseRecorder()
+
+	s.ServeHTTP(rec, rpcReq)
+
+	// result is kept raw to pass it through unchanged
+	var respObj struct {
+		Result json.RawMessage `json:"result"`
+		Error  *ErrorObject    `json:"error"`
+	}
+
+	// pass response headers set by service and method (rate limit, warnings, etc.), body is rewritten
+	for k, v := range rec.header {
+		switch k {
+		case "Content-Length", "Content-Type", "Trailer":
+			continue
+		}
+
+		w.Header()[k] = v
+	}
+
+	if err = json.Unmarshal(rec.body.Bytes(), &respObj); err != nil {
+		w.WriteHeader(rec.code)
+
+		return
+	}
+
+	if respObj.Error != nil {
+		writeJSONResponse(w, restErrorStatusCode(rec.code, respObj.Error), respObj.Error)
+
+		return
+	}
+
+	writeJSONResponse(w, rec.code, respObj.Result)
+}
+
+// restErrorStatusCode maps error delivered with 200 HTTP status code to REST status code.
+func restErrorStatusCode(code int, errObj *ErrorObject) int {
+	if code != http.StatusOK {
+		return code
+	}
+
+	switch errObj.Code {
+	case MethodNotFoundCode:
+		return http.StatusNotFound
+	case InvalidParamsCode, InvalidRequestCode, ParseErrorCode:
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
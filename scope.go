@@ -0,0 +1,79 @@
+package jrpc2
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// RegisterWithScope registers method that requires permission scope from caller, scopes are checked
+// after authentication (service middlewares, e.g. VerifyJWT, run first), calls without required scope are rejected
+// with Permission denied error and 403 HTTP status code. Scopes granted to caller are taken from
+// 'scope' (space separated) or 'scp' (array) JWT claims, see SetScopesFunction for other sources.
+// Returns error when method name is in reserved namespace, the same way as RegisterE.
+func (s *Service) RegisterWithScope(name, scope string, f Handler, mws ...Middleware) error {
+	if err := s.checkMethodName(name); err != nil {
+		return err
+	}
+
+	m := newMethod(f, mws)
+	m.Scope = scope
+
+	return s.registerMethod(name, m, false)
+}
+
+// SetScopesFunction defines function that returns permission scopes granted to caller (e.g. by principal),
+// used by methods registered with RegisterWithScope.
+func (s *Service) SetScopesFunction(f func(data ParametersObject) []string) {
+	s.scopes = f
+}
+
+// GetScopes returns permission scopes from claims of JWT verified by VerifyJWT middleware.
+func (p ParametersObject) GetScopes() []string {
+	claims := p.GetJWTClaims()
+
+	if v, ok := claims["scope"].(string); ok {
+		return strings.Fields(v)
+	}
+
+	list, _ := claims["scp"].([]interface{})
+
+	scopes := make([]string, 0, len(list))
+
+	for _, el := range list {
+		if v, ok := el.(string); ok {
+			scopes = append(scopes, v)
+		}
+	}
+
+	return scopes
+}
+
+// requireScope returns Middleware that rejects calls without required scope.
+func (s *Service) requireScope(scope string) Middleware {
+	return func(next Handler) Handler {
+		return func(data ParametersObject) (interface{}, *ErrorObject) {
+			var granted []string
+
+			if s.scopes != nil {
+				granted = s.scopes(data)
+			} else {
+				granted = data.GetScopes()
+			}
+
+			for _, v := range granted {
+				if v == scope {
+					return next(data)
+				}
+			}
+
+			data.SetHTTPStatusCode(http.StatusForbidden)
+
+			return nil, &ErrorObject{
+				Code:    PermissionCode,
+				Message: PermissionMessage,
+				Data:    fmt.Sprintf("scope '%s' is required", scope),
+			}
+		}
+	}
+}
@@ -2,8 +2,15 @@ package jrpc2
 
 import (
 	"fmt"
+	"log"
+	"net"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
 )
 
 // Service represents a JSON-RPC 2.0 capable HTTP server.
@@ -24,12 +31,96 @@ type Service struct {
 
 	behindReverseProxy bool // flags that changes behavior of some internal methods (X-Real-IP, X-Client-IP)
 
-	methods map[string]method        // mapping of registered methods
-	headers map[string]string        // custom response headers
-	auth    map[string]authorization // contains mapping of allowed remote network to HTTP Authorization header
+	headProbe bool // enables HEAD requests as availability probe
+	ping      bool // enables built-in 'rpc.ping' method
+
+	options        bool   // enables OPTIONS requests as capabilities discovery
+	descriptionURL string // URL of service description document advertised in OPTIONS response
+
+	restRoutes []restRoute // mapping of HTTP paths to methods, served by RESTHandler
+
+	ndjson bool // enables ND-JSON request streams, every line is processed as separate request
+
+	allowReserved bool // permits methods in reserved namespace ('rpc.*', 'system.*')
+
+	byteAccounting bool // enables per-request accounting of read/written bytes
+
+	strictContentLength bool // enables check of declared 'Content-Length' against read body bytes
+
+	disallowUnknownFields bool // rejects request objects with unknown members
+
+	useNumber bool // decodes params numbers as json.Number instead of float64
+
+	requestMeta bool // enables non-standard 'meta' request member, see ParametersObject.Meta
+
+	decompression   bool                      // enables decompression of request body according to 'Content-Encoding'
+	decoders        map[string]ContentDecoder // request content decoders, built-in decoders when nil
+	maxDecompressed int64                     // limit of decompressed request body size, DefaultMaxDecompressedSize when not set
+
+	profilerLabels bool // enables pprof labels with method name for method calls
+
+	notFound http.Handler // handles requests to paths other than route, see Handler
+
+	errorStatusMode ErrorHTTPStatusMode // mapping of JSON-RPC errors to HTTP status codes
+
+	keyCase KeyCase // naming convention of result member names
+
+	redactPaths     []string // paths of fields redacted before request/response bytes are passed to hooks
+	methodRedaction bool     // flags that some methods have redacted fields
+
+	methodCacheControl int32 // flags (atomic) that some methods have Cache-Control directives
+
+	snippetLength int // maximal length of request body snippet in parse error data, 0 disables snippets
+
+	maxParamsArity int // maximal number of positional params, 0 disables limit
+
+	suggestDistance int // maximal Levenshtein distance for 'did you mean' suggestions, 0 disables suggestions
 
-	req  func(r *http.Request, data []byte) error // defines request function hook, runs just after request body is read
-	resp func(r *http.Request, data []byte) error // defines response function hook, runs just before response is written
+	registryMu *sync.RWMutex            // guards replacement of methods and aliases, see ReplaceRegistry
+	methods    map[string]method        // mapping of registered methods
+	aliases    map[string]string        // mapping of method aliases to method names
+	mws        []Middleware             // service-wide middlewares, wraps every method call
+	headers    map[string]string        // custom response headers
+	auth       map[string]authorization // contains mapping of allowed remote network to HTTP Authorization header
+
+	providers []MethodProvider    // registered method providers, re-queried by ReloadProviders
+	provided  map[string]struct{} // names of methods registered by providers
+
+	limiter  *limiter                                 // limits concurrent method calls, nil when unlimited
+	priority func(r *http.Request, method string) int // maps request to priority level for queued method calls
+
+	flight *singleflight.Group // coalesces identical concurrent calls, nil when not used
+
+	cache           *resultCache                                    // caches results of cacheable methods, nil when not used
+	maxCacheEntries int                                             // maximal number of cached results, DefaultMaxCacheEntries when not set
+	cacheKey        func(name string, data ParametersObject) string // derives cache key of method call, principal-aware key when nil
+
+	lifecycle  *lifecycle // in-flight requests, drain state and uptime, see Drain
+	onStart    []func()   // lifecycle hooks run when server is started, in registration order
+	onShutdown []func()   // lifecycle hooks run when service is drained, in reverse registration order
+
+	capture *requestCapture // ring buffer of captured requests for debug replay, nil when disabled
+
+	insecureAdmin bool // allows admin drain and replay endpoints without configured authorization
+
+	trustedProxies  []*net.IPNet // networks of trusted upstream proxies (gateways)
+	principalHeader string       // trusted HTTP header with authenticated principal, set by auth gateway
+	trustUnixPeers  bool         // trusts peers connected over unix socket as upstream proxies
+
+	requireTLS bool // rejects requests not received over HTTPS, directly or by trusted proxy
+
+	accessLog       *log.Logger     // access log, nil when disabled
+	accessLogFormat AccessLogFormat // format of access log lines
+
+	slowThreshold time.Duration // duration of method call that triggers slow call warning, 0 disables warnings
+
+	logHook   func(r *http.Request, entry LogEntry)    // defines structured logging hook
+	auditHook func(r *http.Request, entry AuditEntry)  // defines audit trail hook for state-changing method calls
+	req       func(r *http.Request, data []byte) error // defines request function hook, runs just after request body is read
+	resp      func(r *http.Request, data []byte) error // defines response function hook, runs just before response is written
+
+	marshalError func(r *http.Request, err error) *ErrorObject // converts response marshaling failure to error object, sanitized Internal error when nil
+	scopes       func(data ParametersObject) []string          // returns scopes granted to caller, JWT scope claims when nil
 }
 
 // Create defines a new service instance over Unix Socket.
@@ -44,7 +135,10 @@ func Create(socket string) *Service {
 
 		headers: make(map[string]string),
 		methods: make(map[string]method),
-		auth:    nil,
+
+		registryMu: new(sync.RWMutex),
+		lifecycle:  newLifecycle(),
+		auth:       nil,
 
 		proxy: false,
 
@@ -76,7 +170,10 @@ func CreateOverTCPWithTLS(address, route, key, cert string) *Service {
 
 		headers: make(map[string]string),
 		methods: make(map[string]method),
-		auth:    nil,
+
+		registryMu: new(sync.RWMutex),
+		lifecycle:  newLifecycle(),
+		auth:       nil,
 
 		proxy: false,
 
@@ -101,7 +198,10 @@ func CreateProxy(socket string) *Service {
 
 		headers: make(map[string]string),
 		methods: nil,
-		auth:    nil,
+
+		registryMu: new(sync.RWMutex),
+		lifecycle:  newLifecycle(),
+		auth:       nil,
 
 		proxy: true,
 
@@ -128,7 +228,10 @@ func CreateProxyOverTCPWithTLS(address, route, key, cert string) *Service {
 
 		headers: make(map[string]string),
 		methods: nil,
-		auth:    nil,
+
+		registryMu: new(sync.RWMutex),
+		lifecycle:  newLifecycle(),
+		auth:       nil,
 
 		proxy: true,
 
@@ -209,6 +312,28 @@ func (s *Service) GetBehindReverseProxyFlag() bool {
 	return s.behindReverseProxy
 }
 
+// SetHeadProbeFlag sets HEAD probe flag in service object,
+// when enabled HEAD request returns 200 with no body, without parsing JSON-RPC request.
+func (s *Service) SetHeadProbeFlag(flag bool) {
+	s.headProbe = flag
+}
+
+// GetHeadProbeFlag gets HEAD probe flag from service object.
+func (s *Service) GetHeadProbeFlag() bool {
+	return s.headProbe
+}
+
+// SetPingMethodFlag sets built-in 'rpc.ping' method flag in service object,
+// when enabled 'rpc.ping' method returns "pong" as result, otherwise Method not found error.
+func (s *Service) SetPingMethodFlag(flag bool) {
+	s.ping = flag
+}
+
+// GetPingMethodFlag gets built-in 'rpc.ping' method flag from service object.
+func (s *Service) GetPingMethodFlag() bool {
+	return s.ping
+}
+
 // SetCertificateFilePath sets path to Certificate file in service object.
 func (s *Service) SetCertificateFilePath(path string) {
 	s.cert = path
@@ -239,24 +364,121 @@ func (s *Service) GetHeaders() map[string]string {
 	return s.headers
 }
 
+// AddMiddleware appends service-wide middlewares that wrap every method call.
+// Middlewares are applied in order they were added, first one is the outermost.
+func (s *Service) AddMiddleware(mws ...Middleware) {
+	s.mws = append(s.mws, mws...)
+}
+
 // Register maps the provided method name to the given function for later method calls.
-func (s *Service) Register(name string, f func(ParametersObject) (interface{}, *ErrorObject)) {
+// Optional middlewares apply only to this method, they wrap the function inside the service-wide
+// middlewares chain: service-wide[0] -> ... -> service-wide[N] -> method[0] -> ... -> method[N] -> function.
+// Register does not validate method name, methods in 'rpc.*' namespace are callable only with AllowReservedMethods,
+// use RegisterE to reject names in reserved namespace at registration time.
+// Alias with the same name (see Alias) is removed, so registered method is reachable.
+func (s *Service) Register(name string, f Handler, mws ...Middleware) {
+	_ = s.registerMethod(name, newMethod(f, mws), true)
+}
+
+// RegisterE registers method the same way as Register, returns error when method name
+// is in reserved namespace ('rpc.*', 'system.*', see AllowReservedMethods) or is alias name.
+func (s *Service) RegisterE(name string, f Handler, mws ...Middleware) error {
+	if err := s.checkMethodName(name); err != nil {
+		return err
+	}
+
+	return s.registerMethod(name, newMethod(f, mws), false)
+}
+
+// newMethod returns enabled method with optional middlewares.
+func newMethod(f Handler, mws []Middleware) method {
+	return method{
+		Method:      f,
+		Middlewares: mws,
+		Disabled:    new(int32),
+	}
+}
+
+// registerMethod publishes method with its settings in single method set update,
+// so concurrent calls never see partially configured method. Aliases are resolved before methods,
+// so alias with the same name is removed when dropAlias is set, otherwise registration fails.
+func (s *Service) registerMethod(name string, m method, dropAlias bool) error {
+	s.registryMu.Lock()
+	defer s.registryMu.Unlock()
+
 	if s.proxy {
 		s.methods = nil
-	} else {
-		s.methods[name] = method{
-			Method: f,
+
+		return nil
+	}
+
+	if _, ok := s.aliases[name]; ok {
+		if !dropAlias {
+			return fmt.Errorf("method '%s' conflicts with alias", name)
 		}
+
+		// aliases are copied on write, calls in flight keep using aliases they started with
+		aliases := make(map[string]string, len(s.aliases))
+
+		for k, v := range s.aliases {
+			if k != name {
+				aliases[k] = v
+			}
+		}
+
+		s.aliases = aliases
+	}
+
+	// cache directives are enabled before method is published
+	if m.CacheControl != "" {
+		atomic.StoreInt32(&s.methodCacheControl, 1)
 	}
+
+	s.methods = copyMethods(s.methods)
+	s.methods[name] = m
+
+	return nil
+}
+
+// RegisterIf registers method only when enabled, convenience for feature-flagged methods.
+func (s *Service) RegisterIf(enabled bool, name string, f Handler, mws ...Middleware) {
+	if enabled {
+		s.Register(name, f, mws...)
+	}
+}
+
+// SetMethodEnabled enables or disables registered method at runtime,
+// disabled method returns Method not found error without being unregistered.
+func (s *Service) SetMethodEnabled(name string, enabled bool) error {
+	m, ok := s.currentRegistry().methods[name]
+	if !ok {
+		return fmt.Errorf("method '%s' is not registered", name)
+	}
+
+	var disabled int32
+
+	if !enabled {
+		disabled = 1
+	}
+
+	// method map is not modified, safe to call while serving requests
+	atomic.StoreInt32(m.Disabled, disabled)
+
+	return nil
 }
 
 // RegisterProxy maps the 'rpc.proxy' method name to the given function for later method calls.
-func (s *Service) RegisterProxy(f func(ParametersObject) (interface{}, *ErrorObject)) {
+// Optional middlewares are applied the same way as for Register.
+func (s *Service) RegisterProxy(f Handler, mws ...Middleware) {
 	if s.proxy {
+		s.registryMu.Lock()
 		s.methods = map[string]method{
 			"rpc.proxy": {
-				Method: f,
+				Method:      f,
+				Middlewares: mws,
+				Disabled:    new(int32),
 			},
 		}
+		s.registryMu.Unlock()
 	}
 }
@@ -8,17 +8,22 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"io/ioutil"
 	"log"
 	"math/rand"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/s3rj1k/jrpc2/client"
+	"github.com/s3rj1k/jrpc2/client/clienttest"
 )
 
 // go test -coverprofile=cover.out && go tool cover -html=cover.out -o cover.html
@@ -141,10 +146,44 @@ func Subtract(data ParametersObject) (interface{}, *ErrorObject) {
 	return *paramObj.X - *paramObj.Y, nil
 }
 
+func ValidateSubtract(data ParametersObject) *ErrorObject {
+	paramObj := new(SubtractParams)
+
+	if err := json.Unmarshal(data.GetRawJSONParams(), paramObj); err != nil || paramObj.X == nil || paramObj.Y == nil {
+		return &ErrorObject{
+			Code:    InvalidParamsCode,
+			Message: InvalidParamsMessage,
+			Data:    "X and Y params are required",
+		}
+	}
+
+	return nil
+}
+
 func Update(_ ParametersObject) (interface{}, *ErrorObject) {
 	return nil, nil
 }
 
+func Subscribe(data ParametersObject) (interface{}, *ErrorObject) {
+	ch := make(chan interface{})
+
+	id, done := data.Subscribe(ch)
+
+	go func() {
+		defer close(ch)
+
+		for i := 1; i <= 3; i++ {
+			select {
+			case ch <- i:
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	return id, nil
+}
+
 //revive:disable:deep-exit
 func TestMain(m *testing.M) {
 	// Seed random
@@ -202,6 +241,12 @@ func TestMain(m *testing.M) {
 		serverService.Register("copy", CopyParamsData)
 		serverService.Register("subtract", Subtract)
 		serverService.Register("nilmethod", nil)
+		serverService.Register("subscribe", Subscribe)
+		serverService.SetPingMethodFlag(true)
+
+		if err := serverService.SetMethodValidator("subtract", ValidateSubtract); err != nil {
+			log.Fatal(err)
+		}
 
 		if err := serverService.Start(); err != nil {
 			log.Fatal(err)
@@ -1794,3 +1839,1861 @@ func TestCheckAuthorization(t *testing.T) {
 
 	defer resp.Body.Close()
 }
+
+func TestExpectContinue(t *testing.T) {
+	body := `{"jsonrpc": "2.0", "method": "update", "id": "ID:42"}`
+
+	conn, err := net.Dial("unix", serverSocket)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer conn.Close()
+
+	// send only request headers, body is sent after interim response
+	_, err = fmt.Fprintf(conn,
+		"POST %s HTTP/1.1\r\nHost: localhost\r\nAccept: application/json\r\nContent-Type: application/json\r\n"+
+			"X-Real-IP: 127.0.0.1\r\nExpect: 100-continue\r\nContent-Length: %d\r\n\r\n",
+		serverRoute, len(body),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader := bufio.NewReader(conn)
+
+	// wait for interim response
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.HasPrefix(line, "HTTP/1.1 100") {
+		t.Fatalf("expected interim response to be '100 Continue', got '%s'", strings.TrimSpace(line))
+	}
+
+	// skip empty line that terminates interim response
+	if _, err = reader.ReadString('\n'); err != nil {
+		t.Fatal(err)
+	}
+
+	// send request body
+	if _, err = io.WriteString(conn, body); err != nil {
+		t.Fatal(err)
+	}
+
+	resp, err := http.ReadResponse(reader, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer resp.Body.Close()
+
+	_verifyequal(t, resp.StatusCode, http.StatusOK)
+
+	var result Result
+
+	err = json.NewDecoder(resp.Body).Decode(&result)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, result.ID, "ID:42")
+
+	if result.Error != nil {
+		t.Fatalf("unexpected error '%v'", result.Error)
+	}
+}
+
+func TestRequestIDHeader(t *testing.T) {
+	headers := map[string]string{
+		"Accept":       "application/json",
+		"Content-Type": "application/json",
+		"X-Real-IP":    "127.0.0.1",
+		"X-Request-ID": "test-request-id",
+	}
+
+	// request ID is echoed when provided by client
+	resp, err := httpPost(
+		serverURL,
+		`{"jsonrpc": "2.0", "method": "update", "id": "ID:42"}`,
+		serverSocket,
+		headers,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer resp.Body.Close()
+
+	_verifyequal(t, resp.Header.Get(RequestIDHeader), "test-request-id")
+
+	// request ID survives for notifications
+	resp, err = httpPost(
+		serverURL,
+		`{"jsonrpc": "2.0", "method": "update"}`,
+		serverSocket,
+		headers,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer resp.Body.Close()
+
+	_verifyequal(t, resp.StatusCode, http.StatusNoContent)
+	_verifyequal(t, resp.Header.Get(RequestIDHeader), "test-request-id")
+
+	// request ID is generated when absent
+	resp, err = httpPost(
+		serverURL,
+		`{"jsonrpc": "2.0", "method": "update", "id": "ID:42"}`,
+		serverSocket,
+		postHeaders,
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer resp.Body.Close()
+
+	if v := resp.Header.Get(RequestIDHeader); len(v) == 0 || v == "test-request-id" {
+		t.Fatalf("expected generated request ID, got '%s'", v)
+	}
+}
+
+func TestSubscription(t *testing.T) {
+	resp, err := httpPost(
+		serverURL,
+		`{"jsonrpc": "2.0", "method": "subscribe", "id": "ID:42"}`,
+		serverSocket,
+		map[string]string{
+			"Accept":       "text/event-stream",
+			"Content-Type": "application/json",
+			"X-Real-IP":    "127.0.0.1",
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer resp.Body.Close()
+
+	_verifyequal(t, resp.StatusCode, http.StatusOK)
+	_verifyequal(t, resp.Header.Get("Content-Type"), EventStreamContentType)
+
+	events := make([]string, 0)
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, "data: ") {
+			events = append(events, strings.TrimPrefix(line, "data: "))
+		}
+	}
+
+	if err = scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(events) != 4 {
+		t.Fatalf("expected 4 events, got %d", len(events))
+	}
+
+	// trailer is available after body is fully read
+	_verifyequal(t, resp.Trailer.Get(StatusTrailer), StreamStatusOK)
+
+	var result Result
+
+	if err = json.Unmarshal([]byte(events[0]), &result); err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, result.ID, "ID:42")
+
+	subID, ok := result.Result.(string)
+	if !ok || len(subID) == 0 {
+		t.Fatal("expected subscription ID as result")
+	}
+
+	for i, event := range events[1:] {
+		var notification map[string]interface{}
+
+		if err = json.Unmarshal([]byte(event), &notification); err != nil {
+			t.Fatal(err)
+		}
+
+		if _, ok = notification["id"]; ok {
+			t.Fatal("expected notification without ID")
+		}
+
+		params, ok := notification["params"].(map[string]interface{})
+		if !ok {
+			t.Fatal("expected notification params to be an object")
+		}
+
+		_verifyequal(t, notification["method"], "subscribe")
+		_verifyequal(t, params["subscription"], subID)
+		_verifyequal(t, params["result"], float64(i+1))
+	}
+}
+
+func TestDryRun(t *testing.T) {
+	headers := map[string]string{
+		"Accept":       "application/json",
+		"Content-Type": "application/json",
+		"X-Real-IP":    "127.0.0.1",
+		DryRunHeader:   "true",
+	}
+
+	testCases := []struct {
+		request string
+		result  interface{}
+		code    int
+	}{
+		{`{"jsonrpc": "2.0", "method": "subtract", "params": {"X": 999, "Y": 999}, "id": 1}`, true, 0},
+		{`{"jsonrpc": "2.0", "method": "subtract", "params": {"X": 42}, "id": 1}`, nil, InvalidParamsCode},
+		{`{"jsonrpc": "2.0", "method": "update", "id": 1}`, nil, InvalidRequestCode},
+	}
+
+	for _, tc := range testCases {
+		var result Result
+
+		resp, err := httpPost(serverURL, tc.request, serverSocket, headers)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_verifyequal(t, result.Result, tc.result)
+
+		if tc.code == 0 && result.Error != nil {
+			t.Fatalf("unexpected error '%v'", result.Error)
+		}
+
+		if tc.code != 0 && (result.Error == nil || result.Error.Code != tc.code) {
+			t.Fatalf("expected Error Code to be '%d'", tc.code)
+		}
+	}
+}
+
+func TestClientLibraryPing(t *testing.T) {
+	c := client.GetSocketConfig(serverSocket, serverRoute)
+
+	latency, err := c.Ping(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if latency <= 0 {
+		t.Fatal("expected latency to be positive")
+	}
+
+	// ping method is not enabled on auth service
+	c = client.GetSocketConfig(authSocket, authRoute)
+	c.SetBasicAuth(username, password)
+
+	_, err = c.Ping(context.Background())
+
+	errObj, ok := err.(*client.ErrorObject)
+	if !ok {
+		t.Fatal("expected error type to be \"*client.ErrorObject\"")
+	}
+
+	_verifyequal(t, errObj.Code, MethodNotFoundCode)
+}
+
+func TestClientLibraryFanOut(t *testing.T) {
+	c := client.GetSocketConfig(serverSocket, serverRoute)
+
+	calls := map[string]client.FanOutCall{
+		"first":  {Config: c, Method: "subtract", Params: []byte(`{"X": 45, "Y": 3}`)},
+		"second": {Config: c, Method: "subtract", Params: []byte(`[10, 4]`)},
+	}
+
+	results, err := client.FanOut(context.Background(), calls)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, string(results["first"]), "42")
+	_verifyequal(t, string(results["second"]), "6")
+
+	// first error fails whole fan-out
+	calls["failed"] = client.FanOutCall{Config: c, Method: "subtract", Params: []byte(`{"X": 999.0, "Y": 999.0}`)}
+
+	_, err = client.FanOut(context.Background(), calls)
+	if err == nil {
+		t.Fatal("expected fan-out error")
+	}
+
+	// errors are collected per label
+	results, errs := client.FanOutAll(context.Background(), calls)
+
+	_verifyequal(t, len(results), 2)
+	_verifyequal(t, len(errs), 1)
+
+	if _, ok := errs["failed"]; !ok {
+		t.Fatal("expected error for 'failed' label")
+	}
+}
+
+func TestClientLibraryProtocolVersion(t *testing.T) {
+	c := client.GetSocketConfig(serverSocket, serverRoute)
+
+	c.SetExactProtocolVersion("2.0")
+
+	if _, err := c.Call("update", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	c.SetExactProtocolVersion("")
+	c.SetMinProtocolVersion("1.0")
+
+	if _, err := c.Call("update", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	c.SetMinProtocolVersion("3.0")
+
+	_, err := c.Call("update", nil)
+
+	errObj, ok := err.(*client.InternalError)
+	if !ok {
+		t.Fatal("expected error type to be \"*client.InternalError\"")
+	}
+
+	_verifyequal(t, *errObj.Returned.Protocol, "2.0")
+	_verifyequal(t, *errObj.Expected.Protocol, ">=3.0")
+
+	c.SetMinProtocolVersion("")
+	c.SetExactProtocolVersion("1.0")
+
+	_, err = c.Call("update", nil)
+
+	errObj, ok = err.(*client.InternalError)
+	if !ok {
+		t.Fatal("expected error type to be \"*client.InternalError\"")
+	}
+
+	_verifyequal(t, *errObj.Expected.Protocol, "1.0")
+}
+
+type resetRoundTripper struct {
+	next   http.RoundTripper
+	err    error
+	resets int
+	calls  int
+}
+
+func (rt *resetRoundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	rt.calls++
+
+	if rt.calls <= rt.resets {
+		return nil, rt.err
+	}
+
+	return rt.next.RoundTrip(req)
+}
+
+func TestClientLibraryRetryConnectionReset(t *testing.T) {
+	c := client.GetSocketConfig(serverSocket, serverRoute)
+
+	rt := &resetRoundTripper{
+		next: &http.Transport{
+			DialContext: func(_ context.Context, _, _ string) (net.Conn, error) {
+				return net.Dial("unix", serverSocket)
+			},
+		},
+		err:    fmt.Errorf("http2: server sent GOAWAY and closed the connection; LastStreamID=1, ErrCode=NO_ERROR"),
+		resets: 1,
+	}
+
+	c.SetTransport(rt)
+
+	// retries are disabled by default
+	if _, err := c.Call("update", nil); err == nil {
+		t.Fatal("expected transport error")
+	}
+
+	_verifyequal(t, rt.calls, 1)
+
+	// connection reset is retried transparently
+	rt.calls = 0
+	c.SetRetryCount(2)
+
+	if _, err := c.Call("update", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, rt.calls, 2)
+
+	// genuine errors are not retried
+	rt.calls = 0
+	rt.err = fmt.Errorf("x509: certificate signed by unknown authority")
+
+	if _, err := c.Call("update", nil); err == nil {
+		t.Fatal("expected transport error")
+	}
+
+	_verifyequal(t, rt.calls, 1)
+}
+
+func TestClientLibrarySequentialIDs(t *testing.T) {
+	const calls = 20
+
+	c := client.GetSocketConfig(serverSocket, serverRoute)
+	c.SetIDGenerator(client.NewSequentialIDGenerator())
+
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		ids = make(map[string]bool, calls)
+	)
+
+	for i := 0; i < calls; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			rawMsg, err := c.Call("copy", []byte("{}"))
+			if err != nil {
+				t.Error(err)
+
+				return
+			}
+
+			result := new(CopyParamsDataResponse)
+
+			if err = json.Unmarshal(rawMsg, result); err != nil {
+				t.Error(err)
+
+				return
+			}
+
+			mu.Lock()
+			ids[result.ID] = true
+			mu.Unlock()
+		}()
+	}
+
+	wg.Wait()
+
+	// every call got unique ID from sequence, responses matched numeric IDs
+	_verifyequal(t, len(ids), calls)
+
+	for i := 1; i <= calls; i++ {
+		if !ids[strconv.Itoa(i)] {
+			t.Fatalf("expected ID '%d' to be used", i)
+		}
+	}
+}
+
+// _fixedIDGenerator generates same request ID.
+type _fixedIDGenerator string
+
+func (g _fixedIDGenerator) NextID() json.RawMessage {
+	return json.RawMessage(g)
+}
+
+func TestClientLibraryLargeNumericIDs(t *testing.T) {
+	var respID string
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"jsonrpc": "2.0", "result": true, "id": %s}`, respID)
+	}))
+	defer srv.Close()
+
+	c := client.GetConfig(srv.URL)
+	c.SetIDGenerator(_fixedIDGenerator("9007199254740993"))
+
+	// equal as float64, different IDs
+	respID = "9007199254740992"
+
+	_, err := c.Call("test", nil)
+	if err == nil {
+		t.Fatal("expected ID mismatch error")
+	}
+
+	// same number written differently
+	respID = "90071992547409930e-1"
+
+	_, err = c.Call("test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+func TestClientLibraryDisableIDValidation(t *testing.T) {
+	// server that does not echo request ID
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"jsonrpc": "2.0", "result": "ok", "id": "mismatched"}`))
+	}))
+	defer ts.Close()
+
+	c := client.GetConfig(ts.URL)
+
+	// strict validation by default
+	_, err := c.Call("update", nil)
+
+	errObj, ok := err.(*client.InternalError)
+	if !ok {
+		t.Fatal("expected error type to be \"*client.InternalError\"")
+	}
+
+	_verifyequal(t, *errObj.Returned.ID, `"mismatched"`)
+
+	c.DisableIDValidation(true)
+
+	rawMsg, err := c.Call("update", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, string(rawMsg), `"ok"`)
+}
+
+func TestClientLibraryNotify(t *testing.T) {
+	c := client.GetSocketConfig(serverSocket, serverRoute)
+
+	if err := c.Notify("update", nil); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestClientLibraryFakeCaller(t *testing.T) {
+	var c client.Caller
+
+	fake := clienttest.NewFakeCaller()
+	fake.SetResponse("subtract", []byte("42"), nil)
+
+	c = fake
+
+	rawMsg, err := c.Call("subtract", []byte(`[45, 3]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, string(rawMsg), "42")
+
+	_, err = c.Call("unknown", nil)
+
+	errObj, ok := err.(*client.ErrorObject)
+	if !ok {
+		t.Fatal("expected error type to be \"*client.ErrorObject\"")
+	}
+
+	_verifyequal(t, errObj.Code, MethodNotFoundCode)
+
+	if err = c.Notify("update", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	invocations := fake.Invocations()
+
+	_verifyequal(t, len(invocations), 3)
+	_verifyequal(t, invocations[0].Method, "subtract")
+	_verifyequal(t, invocations[2].Notification, true)
+}
+
+func TestClientLibrarySignature(t *testing.T) {
+	secret := []byte("shared secret")
+
+	testService := Create("")
+	testService.AddMiddleware(VerifySignature(SignatureConfig{Secret: secret}))
+	testService.Register("update", Update)
+
+	ts := httptest.NewServer(testService)
+	defer ts.Close()
+
+	// signed request
+	c := client.GetConfig(ts.URL)
+	c.SignRequests(secret, nil)
+
+	if _, err := c.Call("update", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	// unsigned and wrongly signed requests
+	for _, secret := range [][]byte{nil, []byte("wrong secret")} {
+		c = client.GetConfig(ts.URL)
+		if secret != nil {
+			c.SignRequests(secret, nil)
+		}
+
+		_, err := c.Call("update", nil)
+
+		errObj, ok := err.(*client.InternalError)
+		if !ok {
+			t.Fatal("expected error type to be \"*client.InternalError\"")
+		}
+
+		_verifyequal(t, *errObj.Returned.Code, http.StatusUnauthorized)
+	}
+
+	// replayed request with stale timestamp
+	body := `{"jsonrpc": "2.0", "method": "update", "id": 1}`
+	timestamp := strconv.FormatInt(time.Now().Add(-time.Hour).Unix(), 10)
+
+	req := _newrpcrequest(body)
+	req.Header.Set(SignatureTimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, SignRequest(nil, secret, timestamp, []byte(body)))
+
+	w, respObj := _serverpc(t, testService, req)
+	_verifyequal(t, w.Code, http.StatusUnauthorized)
+	_verifyerrobj(t, respObj.Error, InvalidSignCode, InvalidSignMessage)
+	_verifyequal(t, respObj.Error.Data, "request signature timestamp is outside of allowed window")
+}
+
+func TestClientLibraryErrorDetails(t *testing.T) {
+	testService := Create("")
+	testService.Register("quota", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		return nil, (&ErrorObject{
+			Code:    InternalErrorCode,
+			Message: InternalErrorMessage,
+		}).WithDetails(ErrorDetail{Type: "QuotaExceeded", Value: 10})
+	})
+
+	ts := httptest.NewServer(testService)
+	defer ts.Close()
+
+	_, err := client.GetConfig(ts.URL).Call("quota", nil)
+
+	errObj, ok := err.(*client.ErrorObject)
+	if !ok {
+		t.Fatal("expected error type to be \"*client.ErrorObject\"")
+	}
+
+	details, err := errObj.Details()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, len(details), 1)
+	_verifyequal(t, details[0].Type, "QuotaExceeded")
+	_verifyequal(t, string(details[0].Value), "10")
+	_verifyequal(t, errObj.HasDetail("QuotaExceeded"), true)
+	_verifyequal(t, errObj.HasDetail("PreconditionFailed"), false)
+}
+
+func TestClientLibraryInterceptors(t *testing.T) {
+	var (
+		order   []string
+		buf     bytes.Buffer
+		metrics []string
+	)
+
+	trace := func(name string) client.Interceptor {
+		return func(next client.RoundTrip) client.RoundTrip {
+			return func(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+				order = append(order, name)
+
+				return next(ctx, method, params)
+			}
+		}
+	}
+
+	c := client.GetSocketConfig(serverSocket, serverRoute)
+	c.AddInterceptor(
+		trace("first"),
+		trace("second"),
+		client.LoggingInterceptor(log.New(&buf, "", 0)),
+		client.MetricsInterceptor(func(method string, _ time.Duration, err error) {
+			metrics = append(metrics, fmt.Sprintf("%s:%t", method, err == nil))
+		}),
+	)
+
+	rawMsg, err := c.Call("subtract", []byte(`[45, 3]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, string(rawMsg), "42")
+	_verifyequal(t, strings.Join(order, ","), "first,second")
+	_verifyequal(t, strings.HasPrefix(buf.String(), "JSON-RPC call 'subtract' succeeded after"), true)
+	_verifyequal(t, strings.Join(metrics, ","), "subtract:true")
+
+	// notifications pass through the same interceptors
+	order = order[:0]
+	metrics = metrics[:0]
+
+	var notifications []bool
+
+	c.AddInterceptor(func(next client.RoundTrip) client.RoundTrip {
+		return func(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+			notifications = append(notifications, client.IsNotification(ctx))
+
+			return next(ctx, method, params)
+		}
+	})
+
+	if err = c.Notify("update", []byte(`[1]`)); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err = c.Call("subtract", []byte(`[45, 3]`)); err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, strings.Join(order, ","), "first,second,first,second")
+	_verifyequal(t, strings.Join(metrics, ","), "update:true,subtract:true")
+	_verifyequal(t, notifications, []bool{true, false})
+
+	// interceptor can modify params and short-circuit calls
+	c = client.GetSocketConfig(serverSocket, serverRoute)
+	c.AddInterceptor(func(next client.RoundTrip) client.RoundTrip {
+		return func(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+			if method == "cached" {
+				return json.RawMessage(`"from cache"`), nil
+			}
+
+			return next(ctx, method, []byte(`[10, 4]`))
+		}
+	})
+
+	rawMsg, err = c.Call("cached", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, string(rawMsg), `"from cache"`)
+
+	rawMsg, err = c.Call("subtract", []byte(`[45, 3]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, string(rawMsg), "6")
+}
+
+func TestClientLibrarySubscribe(t *testing.T) {
+	c := client.GetSocketConfig(serverSocket, serverRoute)
+
+	ch, err := c.Subscribe(context.Background(), "subscribe", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := make([]string, 0)
+
+	for v := range ch {
+		values = append(values, string(v))
+	}
+
+	_verifyequal(t, values, []string{"1", "2", "3"})
+
+	// method that does not open stream
+	_, err = c.Subscribe(context.Background(), "copy", []byte("{}"))
+	_verifyequal(t, err == nil, false) // expecting error
+
+	// broken stream is re-subscribed
+	var calls int32
+
+	testService := Create("")
+	testService.Register("subscribe", func(data ParametersObject) (interface{}, *ErrorObject) {
+		ch := make(chan interface{}, 1)
+
+		id, _ := data.Subscribe(ch)
+
+		ch <- atomic.AddInt32(&calls, 1)
+		close(ch)
+
+		return id, nil
+	})
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// first stream is dropped before final status trailer is sent
+		if atomic.LoadInt32(&calls) == 0 {
+			testService.ServeHTTP(&trailerDropWriter{ResponseWriter: w}, r)
+
+			return
+		}
+
+		testService.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	c = client.GetConfig(srv.URL)
+	c.SetRetryCount(1)
+
+	ch, err = c.Subscribe(context.Background(), "subscribe", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values = values[:0]
+
+	for v := range ch {
+		values = append(values, string(v))
+	}
+
+	_verifyequal(t, values, []string{"1", "2"})
+}
+
+func TestClientLibrarySubscribeEventTooLarge(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", client.EventStreamContentType)
+		w.WriteHeader(http.StatusOK)
+
+		fmt.Fprint(w, "data: {\"jsonrpc\":\"2.0\",\"result\":\"sub-1\",\"id\":1}\n\n")
+		fmt.Fprint(w, "data: {\"method\":\"event\",\"params\":{\"subscription\":\"sub-1\",\"result\":1}}\n\n")
+		fmt.Fprintf(w, "data: %s\n\n", strings.Repeat("x", 2048))
+
+		w.(http.Flusher).Flush()
+
+		// stream never ends
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	errs := make(chan error, 1)
+
+	c := client.GetConfig(srv.URL)
+	c.SetIDGenerator(client.NewSequentialIDGenerator())
+	c.SetMaxResponseBytes(1024)
+	c.SetSubscriptionErrorHandler(func(method string, err error) {
+		_verifyequal(t, method, "subscribe")
+
+		errs <- err
+	})
+
+	ch, err := c.Subscribe(context.Background(), "subscribe", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	values := make([]string, 0)
+
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		for v := range ch {
+			values = append(values, string(v))
+		}
+	}()
+
+	// oversized event ends subscription, endless body is not drained
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("subscription was not closed")
+	}
+
+	_verifyequal(t, values, []string{"1"})
+
+	err = <-errs
+	_verifyequal(t, strings.Contains(err.Error(), "event exceeds size limit"), true)
+}
+
+// trailerDropWriter simulates stream that breaks before final status trailer is sent.
+type trailerDropWriter struct {
+	http.ResponseWriter
+}
+
+func (w *trailerDropWriter) WriteHeader(code int) {
+	w.ResponseWriter.Header().Del("Trailer")
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *trailerDropWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func TestClientLibraryMaxResponseBytes(t *testing.T) {
+	testService := Create("")
+	testService.Register("large", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		return strings.Repeat("x", 1024), nil
+	})
+
+	srv := httptest.NewServer(testService)
+	defer srv.Close()
+
+	c := client.GetConfig(srv.URL)
+
+	// default limit is generous
+	if _, err := c.Call("large", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	c.SetMaxResponseBytes(512)
+
+	_, err := c.Call("large", nil)
+	if err == nil || !strings.Contains(err.Error(), "exceeds 512 bytes limit") {
+		t.Fatalf("expected response size limit error, got '%v'", err)
+	}
+}
+
+func TestClientLibraryFollowRedirects(t *testing.T) {
+	newService := Create("")
+	newService.Register("invoice", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		return "paid", nil
+	})
+
+	newSrv := httptest.NewServer(newService)
+	defer newSrv.Close()
+
+	oldService := Create("")
+	oldService.Register("invoice", func(data ParametersObject) (interface{}, *ErrorObject) {
+		return data.Redirect(newSrv.URL)
+	})
+
+	oldSrv := httptest.NewServer(oldService)
+	defer oldSrv.Close()
+
+	c := client.GetConfig(oldSrv.URL)
+
+	// redirects are not followed by default
+	_, err := c.Call("invoice", nil)
+
+	errObj, ok := err.(*client.ErrorObject)
+	if !ok {
+		t.Fatalf("expected error object, got '%v'", err)
+	}
+
+	_verifyequal(t, errObj.Code, client.MethodMovedCode)
+
+	c.FollowRedirects(1)
+
+	rawMsg, err := c.Call("invoice", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, string(rawMsg), `"paid"`)
+}
+
+func TestClientLibraryFollowRedirectsCredentials(t *testing.T) {
+	newService := Create("")
+	newService.Register("invoice", func(data ParametersObject) (interface{}, *ErrorObject) {
+		return data.GetHeaders().Get("Authorization"), nil
+	})
+
+	newSrv := httptest.NewServer(newService)
+	defer newSrv.Close()
+
+	oldService := Create("")
+	oldService.Register("invoice", func(data ParametersObject) (interface{}, *ErrorObject) {
+		if data.GetHeaders().Get("Authorization") == "" {
+			return nil, &ErrorObject{Code: InvalidRequestCode, Message: InvalidRequestMessage}
+		}
+
+		return data.Redirect(newSrv.URL)
+	})
+
+	oldSrv := httptest.NewServer(oldService)
+	defer oldSrv.Close()
+
+	var calls int
+
+	c := client.GetConfig(oldSrv.URL)
+	c.SetBasicAuth("alice", "secret")
+	c.FollowRedirects(1)
+	c.AddInterceptor(func(next client.RoundTrip) client.RoundTrip {
+		return func(ctx context.Context, method string, params json.RawMessage) (json.RawMessage, error) {
+			calls++
+
+			return next(ctx, method, params)
+		}
+	})
+
+	// location of other origin (port) does not receive credentials
+	rawMsg, err := c.Call("invoice", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, string(rawMsg), `""`)
+	_verifyequal(t, calls, 2) // followed call passes through interceptors
+
+	// original config keeps credentials
+	rawMsg, err = c.Call("invoice", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, string(rawMsg), `""`)
+}
+
+func TestClientLibraryCallTyped(t *testing.T) {
+	testService := Create("")
+	testService.Register("struct", CopyParamsData)
+	testService.Register("map", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		return map[string]int{"one": 1, "two": 2}, nil
+	})
+	testService.Register("null", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		return nil, nil
+	})
+
+	srv := httptest.NewServer(testService)
+	defer srv.Close()
+
+	c := client.GetConfig(srv.URL)
+
+	result, err := c.CallTyped("struct", []byte(`{"message": "hello"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, result.IsNull(), false)
+
+	data := new(CopyParamsDataResponse)
+
+	if err = result.Decode(data); err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, data.Method, "struct")
+	_verifyequal(t, string(data.Params), `{"message":"hello"}`)
+
+	result, err = c.CallTyped("map", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var m map[string]int
+
+	if err = result.Decode(&m); err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, m, map[string]int{"one": 1, "two": 2})
+	_verifyequal(t, string(result.Raw()), `{"one":1,"two":2}`)
+
+	result, err = c.CallTyped("null", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, result.IsNull(), true)
+
+	m = map[string]int{"one": 1}
+
+	// null result resets value the same way as JSON null
+	if err = result.Decode(&m); err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, m == nil, true)
+}
+
+func TestClientLibraryRespectRateLimit(t *testing.T) {
+	var (
+		calls int32
+		reset = time.Now().Truncate(time.Second).Add(time.Second)
+		at    = make(chan time.Time, 2)
+	)
+
+	testService := Create("")
+	testService.Register("limited", func(data ParametersObject) (interface{}, *ErrorObject) {
+		at <- time.Now()
+
+		if atomic.AddInt32(&calls, 1) == 1 {
+			data.SetRateLimitHeaders(1, 0, reset)
+		} else {
+			data.SetRateLimitHeaders(1, 1, reset.Add(time.Second))
+		}
+
+		return "ok", nil
+	})
+
+	srv := httptest.NewServer(testService)
+	defer srv.Close()
+
+	c := client.GetConfig(srv.URL)
+	c.RespectRateLimit(true)
+
+	if _, err := c.Call("limited", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	<-at
+
+	info, ok := c.RateLimit()
+	_verifyequal(t, ok, true)
+	_verifyequal(t, info, client.RateLimitInfo{Limit: 1, Remaining: 0, Reset: reset})
+
+	// next call is delayed until window resets
+	if _, err := c.Call("limited", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if second := <-at; second.Before(reset) {
+		t.Fatalf("expected call after '%s', got call at '%s'", reset, second)
+	}
+
+	info, _ = c.RateLimit()
+	_verifyequal(t, info.Remaining, 1)
+
+	c.RespectRateLimit(false)
+	_, ok = c.RateLimit()
+	_verifyequal(t, ok, false)
+}
+
+func TestClientLibraryRetryClassifier(t *testing.T) {
+	const retryLaterCode = -32050
+
+	var calls int32
+
+	testService := Create("")
+	testService.Register("flaky", func(_ ParametersObject) (interface{}, *ErrorObject) {
+		if atomic.AddInt32(&calls, 1) <= 2 {
+			return nil, &ErrorObject{Code: retryLaterCode, Message: "Retry later"}
+		}
+
+		return "ok", nil
+	})
+
+	srv := httptest.NewServer(testService)
+	defer srv.Close()
+
+	c := client.GetConfig(srv.URL)
+	c.SetRetryCount(2)
+
+	// JSON-RPC errors are terminal by default
+	if _, err := c.Call("flaky", nil); err == nil {
+		t.Fatal("expected error")
+	}
+
+	_verifyequal(t, atomic.LoadInt32(&calls), int32(1))
+
+	c.SetRetryClassifier(func(err error, statusCode int) bool {
+		if errObj, ok := err.(*client.ErrorObject); ok {
+			return errObj.Code == retryLaterCode
+		}
+
+		return client.DefaultRetryClassifier(err, statusCode)
+	})
+
+	atomic.StoreInt32(&calls, 0)
+
+	result, err := c.Call("flaky", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, string(result), `"ok"`)
+	_verifyequal(t, atomic.LoadInt32(&calls), int32(3))
+
+	// retries are limited by retry count
+	atomic.StoreInt32(&calls, -1)
+
+	if _, err = c.Call("flaky", nil); err == nil {
+		t.Fatal("expected error")
+	}
+
+	_verifyequal(t, atomic.LoadInt32(&calls), int32(2))
+}
+
+func TestClientLibraryOutbox(t *testing.T) {
+	delivered := make(chan string, 1)
+
+	testService := Create("")
+	testService.Register("event", func(data ParametersObject) (interface{}, *ErrorObject) {
+		delivered <- string(data.GetRawJSONParams())
+
+		return nil, nil
+	})
+
+	// reserve address of server that is down
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr := l.Addr().String()
+	_ = l.Close()
+
+	store := client.NewMemoryOutboxStore()
+
+	outbox := client.NewOutbox(client.GetConfig("http://"+addr), store)
+	outbox.SetBackoff(10*time.Millisecond, 50*time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- outbox.Run(ctx)
+	}()
+
+	if err = outbox.Notify("event", []byte(`{"id":1}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	// delivery fails while server is down
+	time.Sleep(100 * time.Millisecond)
+	_verifyequal(t, store.Len(), 1)
+
+	l, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewUnstartedServer(testService)
+	srv.Listener = l
+	srv.Start()
+
+	defer srv.Close()
+
+	select {
+	case params := <-delivered:
+		_verifyequal(t, params, `{"id":1}`)
+	case <-time.After(5 * time.Second):
+		t.Fatal("notification was not delivered")
+	}
+
+	// delivered notification is removed from outbox
+	for i := 0; store.Len() != 0 && i < 100; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	_verifyequal(t, store.Len(), 0)
+
+	cancel()
+
+	_verifyequal(t, <-done, context.Canceled)
+}
+
+func TestClientLibraryDump(t *testing.T) {
+	testService := Create("")
+	testService.Register("update", Update)
+
+	srv := httptest.NewServer(testService)
+	defer srv.Close()
+
+	dump := new(bytes.Buffer)
+
+	c := client.GetConfig(srv.URL)
+	c.SetIDGenerator(client.NewSequentialIDGenerator())
+	c.SetBasicAuth("user", "secret")
+	c.SetDump(dump, "Authorization")
+
+	if _, err := c.Call("update", []byte(`[1]`)); err != nil {
+		t.Fatal(err)
+	}
+
+	out := dump.String()
+
+	for _, expected := range []string{
+		"--> POST " + srv.URL,
+		`"method":"update"`,
+		`"id":1`,
+		"Authorization: ***",
+		"Content-Encoding: gzip",
+		"<-- HTTP/1.1 200 OK",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Fatalf("expected dump to contain '%s', got:\n%s", expected, out)
+		}
+	}
+
+	if strings.Contains(out, "secret") {
+		t.Fatalf("expected redacted dump, got:\n%s", out)
+	}
+
+	// dump is disabled by default
+	dump.Reset()
+	c.SetDump(nil)
+
+	if _, err := c.Call("update", []byte(`[1]`)); err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, dump.Len(), 0)
+
+	// credential headers are redacted without explicit list, listed headers are redacted additionally
+	c.SignRequests([]byte("signing-secret"), nil)
+	c.SetHeader("X-Api-Key", "api-key")
+	c.SetDump(dump, "X-Api-Key")
+
+	if _, err := c.Call("update", []byte(`[1]`)); err != nil {
+		t.Fatal(err)
+	}
+
+	out = dump.String()
+
+	for _, expected := range []string{
+		"Authorization: ***",
+		"X-Signature: ***",
+		"X-Api-Key: ***",
+	} {
+		if !strings.Contains(out, expected) {
+			t.Fatalf("expected dump to contain '%s', got:\n%s", expected, out)
+		}
+	}
+
+	if strings.Contains(out, "dXNlcjpzZWNyZXQ") || strings.Contains(out, "api-key") {
+		t.Fatalf("expected redacted dump, got:\n%s", out)
+	}
+}
+
+func TestProgress(t *testing.T) {
+	testService := Create("")
+	testService.Register("compute", func(data ParametersObject) (interface{}, *ErrorObject) {
+		for i := 1; i <= 3; i++ {
+			data.Progress(map[string]int{"done": i, "total": 3})
+		}
+
+		return "result", nil
+	})
+
+	srv := httptest.NewServer(testService)
+	defer srv.Close()
+
+	post := func(accept string) *http.Response {
+		req, err := http.NewRequest("POST", srv.URL, strings.NewReader(`{"jsonrpc": "2.0", "method": "compute", "id": 7}`))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", accept)
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return resp
+	}
+
+	// progress is not reported without streaming
+	resp := post("application/json")
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	_verifyequal(t, resp.Header.Get("Content-Type"), "application/json")
+	_verifyequal(t, string(body), `{"jsonrpc":"2.0","result":"result","id":7}`)
+
+	resp = post(EventStreamContentType)
+	defer resp.Body.Close()
+
+	_verifyequal(t, resp.StatusCode, http.StatusOK)
+	_verifyequal(t, resp.Header.Get("Content-Type"), EventStreamContentType)
+
+	events := make([]string, 0)
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if line := scanner.Text(); strings.HasPrefix(line, "data: ") {
+			events = append(events, strings.TrimPrefix(line, "data: "))
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, events, []string{
+		`{"jsonrpc":"2.0","method":"rpc.progress","params":{"id":7,"progress":{"done":1,"total":3}}}`,
+		`{"jsonrpc":"2.0","method":"rpc.progress","params":{"id":7,"progress":{"done":2,"total":3}}}`,
+		`{"jsonrpc":"2.0","method":"rpc.progress","params":{"id":7,"progress":{"done":3,"total":3}}}`,
+		`{"jsonrpc":"2.0","result":"result","id":7}`,
+	})
+	_verifyequal(t, resp.Trailer.Get(StatusTrailer), StreamStatusOK)
+}
+
+func TestClientLibraryBalancer(t *testing.T) {
+	var hits int64
+
+	testService := Create("")
+	testService.SetPingMethodFlag(true)
+	testService.Register("update", func(data ParametersObject) (interface{}, *ErrorObject) {
+		atomic.AddInt64(&hits, 1)
+
+		return nil, nil
+	})
+
+	srv := httptest.NewServer(testService)
+	defer srv.Close()
+
+	// reserve address of server that is down
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr := l.Addr().String()
+	_ = l.Close()
+
+	balancer := client.NewBalancer(client.RoundRobin, client.GetConfig("http://"+addr), client.GetConfig(srv.URL))
+	balancer.SetHealthCheck(2, 10*time.Millisecond)
+
+	// failed endpoint is skipped transparently
+	for i := 0; i < 10; i++ {
+		if _, err = balancer.Call("update", []byte(`[1]`)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	_verifyequal(t, atomic.LoadInt64(&hits), int64(10))
+
+	status := balancer.Status()
+	_verifyequal(t, status[0].Healthy, false)
+	_verifyequal(t, status[1].Healthy, true)
+
+	// JSON-RPC errors do not mark endpoint unhealthy
+	_, err = balancer.Call("unknown", nil)
+	_verifyequal(t, err == nil, false)
+	_verifyequal(t, balancer.Status()[1].Healthy, true)
+
+	// recovered endpoint becomes healthy after probe
+	l, err = net.Listen("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recovered := httptest.NewUnstartedServer(testService)
+	recovered.Listener = l
+	recovered.Start()
+
+	defer recovered.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go func() {
+		_ = balancer.Run(ctx)
+	}()
+
+	for i := 0; !balancer.Status()[0].Healthy && i < 500; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	_verifyequal(t, balancer.Status()[0].Healthy, true)
+}
+
+func TestClientLibraryTokenProvider(t *testing.T) {
+	var calls int64
+
+	testService := Create("")
+	testService.Register("whoami", func(data ParametersObject) (interface{}, *ErrorObject) {
+		atomic.AddInt64(&calls, 1)
+
+		return data.GetHeaders().Get("Authorization"), nil
+	})
+
+	ts := httptest.NewServer(testService)
+	defer ts.Close()
+
+	var issued int
+
+	c := client.GetConfig(ts.URL)
+	c.SetBasicAuth("user", "password") // overridden by provider
+	c.SetTokenProvider(func(ctx context.Context) (string, error) {
+		issued++
+
+		if issued > 2 {
+			return "", fmt.Errorf("refresh token expired")
+		}
+
+		return fmt.Sprintf("token-%d", issued), nil
+	})
+
+	// refreshed token per call
+	for _, expected := range []string{`"Bearer token-1"`, `"Bearer token-2"`} {
+		result, err := c.Call("whoami", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_verifyequal(t, string(result), expected)
+	}
+
+	// provider error aborts call before request is sent
+	_, err := c.Call("whoami", nil)
+	_verifyequal(t, err != nil && strings.Contains(err.Error(), "token provider failed: refresh token expired"), true)
+	_verifyequal(t, atomic.LoadInt64(&calls), int64(2))
+}
+
+func TestStreamResult(t *testing.T) {
+	const total = 5000
+
+	element := strings.Repeat("x", 100)
+	seen := make(chan struct{})
+
+	testService := Create("")
+	testService.RegisterStream("range", func(data ParametersObject, emit func(v interface{}) error) *ErrorObject {
+		for i := 0; i < total; i++ {
+			// client receives elements while result is still produced
+			if i == total/2 {
+				select {
+				case <-seen:
+				case <-time.After(5 * time.Second):
+					return &ErrorObject{Code: InternalErrorCode, Message: InternalErrorMessage, Data: "result was buffered"}
+				}
+			}
+
+			if err := emit(element); err != nil {
+				return &ErrorObject{Code: InternalErrorCode, Message: InternalErrorMessage}
+			}
+		}
+
+		return nil
+	})
+	testService.RegisterStream("broken", func(data ParametersObject, emit func(v interface{}) error) *ErrorObject {
+		if string(data.GetRawJSONParams()) == `"early"` {
+			return &ErrorObject{Code: InvalidParamsCode, Message: InvalidParamsMessage}
+		}
+
+		for i := 0; i < total; i++ {
+			_ = emit(element)
+		}
+
+		return &ErrorObject{Code: InternalErrorCode, Message: InternalErrorMessage}
+	})
+
+	srv := httptest.NewServer(testService)
+	defer srv.Close()
+
+	post := func(body string) *http.Response {
+		req, err := http.NewRequest("POST", srv.URL, strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		return resp
+	}
+
+	resp := post(`{"jsonrpc": "2.0", "method": "range", "id": 1}`)
+	defer resp.Body.Close()
+
+	_verifyequal(t, resp.StatusCode, http.StatusOK)
+
+	head := make([]byte, 1024)
+	if _, err := io.ReadFull(resp.Body, head); err != nil {
+		t.Fatal(err)
+	}
+
+	close(seen)
+
+	rest, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var respObj struct {
+		Result []string         `json:"result"`
+		Error  *ErrorObject     `json:"error"`
+		ID     *json.RawMessage `json:"id"`
+	}
+
+	if err = json.Unmarshal(append(head, rest...), &respObj); err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, respObj.Error == nil, true)
+	_verifyequal(t, len(respObj.Result), total)
+	_verifyequal(t, string(*respObj.ID), "1")
+	_verifyequal(t, resp.TransferEncoding, []string{"chunked"})
+	_verifyequal(t, resp.Trailer.Get(StatusTrailer), StreamStatusOK)
+
+	// error before streaming started is sent as usual error response
+	resp = post(`{"jsonrpc": "2.0", "method": "broken", "params": "early", "id": 2}`)
+	body, _ := ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	_verifyequal(t, string(body), `{"jsonrpc":"2.0","error":{"code":-32602,"message":"Invalid params"},"id":2}`)
+
+	// error mid-stream switches trailer status
+	resp = post(`{"jsonrpc": "2.0", "method": "broken", "id": 3}`)
+	body, _ = ioutil.ReadAll(resp.Body)
+	resp.Body.Close()
+
+	_verifyequal(t, json.Valid(body), false)
+	_verifyequal(t, resp.Trailer.Get(StatusTrailer), StreamStatusError)
+
+	// result is built as a whole when called directly
+	result, errObj := testService.Call("range", ParametersObject{})
+	_verifyequal(t, errObj == nil, true)
+
+	b, err := json.Marshal(result)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, len(b), 2+total*(len(element)+3)-1)
+}
+
+func TestStreamResultCallSlot(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		entries []AuditEntry
+	)
+
+	release := make(chan struct{})
+
+	testService := Create("")
+	testService.SetResultKeyCase(KeyCaseSnake)
+	testService.SetAuditHook(func(_ *http.Request, entry AuditEntry) {
+		mu.Lock()
+		entries = append(entries, entry)
+		mu.Unlock()
+	})
+	testService.RegisterStream("export", func(data ParametersObject, emit func(v interface{}) error) *ErrorObject {
+		if err := emit(map[string]int{"RowID": 1}); err != nil {
+			return &ErrorObject{Code: InternalErrorCode, Message: InternalErrorMessage}
+		}
+
+		switch string(data.GetRawJSONParams()) {
+		case `"wait"`:
+			<-release
+		case `"fail"`:
+			return &ErrorObject{Code: InternalErrorCode, Message: InternalErrorMessage}
+		}
+
+		return nil
+	})
+
+	err := testService.SetMethodConcurrency("export", 1)
+	if err != nil {
+		t.Fatalf("unexpected error '%s'", err)
+	}
+
+	srv := httptest.NewServer(testService)
+	defer srv.Close()
+
+	post := func(body string) (*http.Response, string) {
+		req, err := http.NewRequest("POST", srv.URL, strings.NewReader(body))
+		if err != nil {
+			t.Error(err)
+
+			return nil, ""
+		}
+
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Error(err)
+
+			return nil, ""
+		}
+
+		b, _ := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		return resp, string(b)
+	}
+
+	done := make(chan string)
+
+	go func() {
+		_, body := post(`{"jsonrpc": "2.0", "method": "export", "params": "wait", "id": 1}`)
+		done <- body
+	}()
+
+	// wait until first stream holds call slot
+	deadline := time.Now().Add(5 * time.Second)
+
+	for {
+		resp, _ := post(`{"jsonrpc": "2.0", "method": "export", "id": 2}`)
+		if resp == nil || resp.StatusCode == http.StatusServiceUnavailable {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatal("expected call slot to be held by stream")
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	close(release)
+
+	// streamed result is not converted to key case
+	_verifyequal(t, <-done, `{"jsonrpc":"2.0","id":1,"result":[{"RowID":1}]}`)
+
+	resp, _ := post(`{"jsonrpc": "2.0", "method": "export", "params": "fail", "id": 3}`)
+	_verifyequal(t, resp.Trailer.Get(StatusTrailer), StreamStatusError)
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	// audit trail records outcome of stream
+	last := entries[len(entries)-1]
+	_verifyequal(t, last.Status, AuditStatusError)
+	_verifyequal(t, last.ErrorCode, InternalErrorCode)
+}
+
+func TestClientLibraryRetrySentRequest(t *testing.T) {
+	var hits int64
+
+	// request reaches server, connection is dropped before response
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = ioutil.ReadAll(r.Body)
+
+		atomic.AddInt64(&hits, 1)
+
+		conn, _, err := w.(http.Hijacker).Hijack()
+		if err == nil {
+			_ = conn.Close()
+		}
+	}))
+	defer srv.Close()
+
+	c := client.GetConfig(srv.URL)
+	c.SetRetryCount(2)
+
+	// sent request of non-idempotent method is not retried
+	_, err := c.Call("update", []byte(`[1]`))
+	_verifyequal(t, err == nil, false)
+	_verifyequal(t, atomic.LoadInt64(&hits), int64(1))
+
+	// idempotent method is retried
+	c.SetIdempotentMethods("get")
+
+	_, err = c.Call("get", nil)
+	_verifyequal(t, err == nil, false)
+	_verifyequal(t, atomic.LoadInt64(&hits), int64(4))
+
+	// balancer does not replay sent request of non-idempotent method on next endpoint
+	atomic.StoreInt64(&hits, 0)
+
+	balancer := client.NewBalancer(client.RoundRobin, client.GetConfig(srv.URL), client.GetConfig(srv.URL))
+
+	_, err = balancer.Call("update", []byte(`[1]`))
+	_verifyequal(t, err == nil, false)
+	_verifyequal(t, atomic.LoadInt64(&hits), int64(1))
+}
+
+func TestClientLibraryRetryAfter(t *testing.T) {
+	var (
+		calls int32
+		delay = "1"
+	)
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) == 1 {
+			w.Header().Set("Retry-After", delay)
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+
+		var reqObj struct {
+			ID json.RawMessage `json:"id"`
+		}
+
+		_ = json.Unmarshal(body, &reqObj)
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"jsonrpc": "2.0", "result": "ok", "id": %s}`, reqObj.ID)
+	}))
+	defer srv.Close()
+
+	c := client.GetConfig(srv.URL)
+	c.SetRetryCount(1)
+	c.SetRetryClassifier(func(_ error, statusCode int) bool {
+		return statusCode == http.StatusServiceUnavailable
+	})
+
+	// retry waits for delay requested by server
+	start := time.Now()
+
+	result, err := c.Call("test", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_verifyequal(t, string(result), `"ok"`)
+	_verifyequal(t, time.Since(start) >= time.Second, true)
+
+	// too long delay is not waited for
+	atomic.StoreInt32(&calls, 0)
+
+	delay = "3600"
+
+	_, err = c.Call("test", nil)
+	_verifyequal(t, err == nil, false)
+	_verifyequal(t, atomic.LoadInt32(&calls), int32(1))
+}
+
+func TestClientLibraryOutboxDeadLetter(t *testing.T) {
+	var calls int32
+
+	// server rejects every notification
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer srv.Close()
+
+	type deadLetter struct {
+		msg client.OutboxMessage
+		err error
+	}
+
+	dead := make(chan deadLetter, 2)
+
+	store := client.NewMemoryOutboxStore()
+
+	outbox := client.NewOutbox(client.GetConfig(srv.URL), store)
+	outbox.SetBackoff(time.Millisecond, 5*time.Millisecond)
+	outbox.SetMaxAttempts(3)
+	outbox.SetDeadLetter(func(msg client.OutboxMessage, err error) {
+		dead <- deadLetter{msg: msg, err: err}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- outbox.Run(ctx)
+	}()
+
+	if err := outbox.Notify("first", []byte(`{"id":1}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := outbox.Notify("second", []byte(`{"id":2}`)); err != nil {
+		t.Fatal(err)
+	}
+
+	// poison messages are dropped after maximal number of attempts, queue is not blocked
+	for _, method := range []string{"first", "second"} {
+		select {
+		case d := <-dead:
+			_verifyequal(t, d.msg.Method, method)
+			_verifyequal(t, d.err == nil, false) // expecting error
+		case <-time.After(5 * time.Second):
+			t.Fatal("notification was not dead-lettered")
+		}
+	}
+
+	for i := 0; store.Len() != 0 && i < 100; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	_verifyequal(t, store.Len(), 0)
+	_verifyequal(t, atomic.LoadInt32(&calls), int32(6))
+
+	cancel()
+
+	_verifyequal(t, <-done, context.Canceled)
+}
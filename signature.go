@@ -0,0 +1,114 @@
+package jrpc2
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"hash"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Request signature headers.
+const (
+	// SignatureHeader defines HTTP header with hex encoded HMAC of request timestamp and body
+	SignatureHeader = "X-Signature"
+	// SignatureTimestampHeader defines HTTP header with request signing time, Unix time in seconds
+	SignatureTimestampHeader = "X-Signature-Timestamp"
+)
+
+// DefaultSignatureMaxSkew defines default allowed difference between request signing time and server time.
+const DefaultSignatureMaxSkew = 5 * time.Minute
+
+// SignatureConfig defines request signature verification settings.
+type SignatureConfig struct {
+	// Secret is shared secret of client and server
+	Secret []byte
+	// Hash defines HMAC hash function, defaults to sha256.New
+	Hash func() hash.Hash
+	// MaxSkew defines allowed difference between request timestamp and server time,
+	// defaults to DefaultSignatureMaxSkew, protects from replay of old requests
+	MaxSkew time.Duration
+}
+
+// SignRequest computes hex encoded HMAC signature of request timestamp and body,
+// signed message is '<timestamp>.<body>'.
+func SignRequest(h func() hash.Hash, secret []byte, timestamp string, body []byte) string {
+	if h == nil {
+		h = sha256.New
+	}
+
+	mac := hmac.New(h, secret)
+
+	_, _ = mac.Write([]byte(timestamp))
+	_, _ = mac.Write([]byte("."))
+	_, _ = mac.Write(body)
+
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifySignature returns Middleware that verifies HMAC signature of request body from X-Signature
+// and X-Signature-Timestamp headers, requests with invalid, missing or stale signature are rejected
+// with Invalid signature error and 401 HTTP status code:
+//
+//	s.AddMiddleware(jrpc2.VerifySignature(jrpc2.SignatureConfig{Secret: secret}))
+func VerifySignature(cfg SignatureConfig) Middleware {
+	if cfg.Hash == nil {
+		cfg.Hash = sha256.New
+	}
+
+	if cfg.MaxSkew <= 0 {
+		cfg.MaxSkew = DefaultSignatureMaxSkew
+	}
+
+	reject := func(data ParametersObject, msg string) (interface{}, *ErrorObject) {
+		data.SetHTTPStatusCode(http.StatusUnauthorized)
+
+		return nil, &ErrorObject{
+			Code:    InvalidSignCode,
+			Message: InvalidSignMessage,
+			Data:    msg,
+		}
+	}
+
+	return func(next Handler) Handler {
+		return func(data ParametersObject) (interface{}, *ErrorObject) {
+			if data.r == nil {
+				return reject(data, "request signature is missing")
+			}
+
+			signature := strings.TrimSpace(data.r.Header.Get(SignatureHeader))
+			timestamp := strings.TrimSpace(data.r.Header.Get(SignatureTimestampHeader))
+
+			if signature == "" || timestamp == "" {
+				return reject(data, "request signature is missing")
+			}
+
+			ts, err := strconv.ParseInt(timestamp, 10, 64)
+			if err != nil {
+				return reject(data, "request signature timestamp is invalid")
+			}
+
+			// replay protection
+			skew := time.Since(time.Unix(ts, 0))
+			if skew < 0 {
+				skew = -skew
+			}
+
+			if skew > cfg.MaxSkew {
+				return reject(data, "request signature timestamp is outside of allowed window")
+			}
+
+			expected := SignRequest(cfg.Hash, cfg.Secret, timestamp, requestBodyFromContext(data.r.Context()))
+
+			// constant-time comparison
+			if !hmac.Equal([]byte(strings.ToLower(signature)), []byte(expected)) {
+				return reject(data, "request signature mismatch")
+			}
+
+			return next(data)
+		}
+	}
+}
@@ -0,0 +1,47 @@
+package jrpc2
+
+import (
+	"net/http"
+	"time"
+)
+
+// SetSlowThreshold sets duration of method call that triggers slow call warning in logging hook,
+// zero duration (default) disables warnings.
+func (s *Service) SetSlowThreshold(d time.Duration) {
+	s.slowThreshold = d
+}
+
+// GetSlowThreshold gets duration of method call that triggers slow call warning.
+func (s *Service) GetSlowThreshold() time.Duration {
+	return s.slowThreshold
+}
+
+// SetMethodSlowThreshold overrides slow call threshold for registered method.
+func (s *Service) SetMethodSlowThreshold(name string, d time.Duration) error {
+	return s.updateMethod(name, func(m *method) {
+		m.SlowThreshold = d
+	})
+}
+
+// checkSlowCall emits warning to logging hook when method call exceeded slow call threshold.
+func (s *Service) checkSlowCall(r *http.Request, name string, d time.Duration) {
+	threshold := s.slowThreshold
+
+	if m, ok := s.lookupMethod(r, name); ok && m.SlowThreshold > 0 {
+		threshold = m.SlowThreshold
+	}
+
+	if threshold <= 0 || d < threshold {
+		return
+	}
+
+	s.logEntry(r, LogEntry{
+		Level:    LogLevelWarning,
+		Message:  "slow method call",
+		Method:   name,
+		Duration: d,
+		Fields: map[string]interface{}{
+			"threshold": threshold,
+		},
+	})
+}
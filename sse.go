@@ -0,0 +1,192 @@
+package jrpc2
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// EventStreamContentType defines Content-Type of server-sent events stream.
+const EventStreamContentType = "text/event-stream"
+
+// StatusTrailer defines HTTP trailer with final status of stream, sent after stream body,
+// requires HTTP/1.1 chunked transfer encoding or HTTP/2.
+const StatusTrailer = "X-RPC-Status"
+
+// Final stream statuses.
+const (
+	// StreamStatusOK indicates that stream ended normally
+	StreamStatusOK = "ok"
+	// StreamStatusError indicates that some of stream values were dropped
+	StreamStatusError = "error"
+)
+
+// subscription describes server-sent events subscription registered by method.
+type subscription struct {
+	id string
+	ch <-chan interface{}
+}
+
+// subscriptionParamsObject represents params member of subscription notification.
+type subscriptionParamsObject struct {
+	// Subscription contains subscription ID returned as result of subscribe method
+	Subscription string `json:"subscription"`
+	// Result contains event data pushed by method
+	Result interface{} `json:"result"`
+}
+
+// subscriptionNotificationObject represents JSON-RPC 2.0 notification pushed to subscriber.
+type subscriptionNotificationObject struct {
+	// Jsonrpc specifies the version of the JSON-RPC protocol, equals to "2.0"
+	Jsonrpc string `json:"jsonrpc"`
+	// Method contains the name of the method that created subscription
+	Method string `json:"method"`
+	// Params holds subscription ID and event data
+	Params subscriptionParamsObject `json:"params"`
+}
+
+// isEventStreamAccepted checks that client accepts server-sent events stream.
+func isEventStreamAccepted(r *http.Request) bool {
+	return strings.EqualFold(r.Header.Get("Accept"), EventStreamContentType)
+}
+
+// Subscribe registers channel as subscription for invoked method, returns subscription ID
+// and channel that is closed when client disconnects or stream ends.
+// When method call succeeds and client accepts 'text/event-stream', response is sent as server-sent events stream:
+// first event contains JSON-RPC 2.0 response object with subscription ID as result,
+// every value received from channel is pushed as JSON-RPC 2.0 notification object
+// `{"jsonrpc": "2.0", "method": <method>, "params": {"subscription": <id>, "result": <value>}}`.
+// Stream ends when channel is closed or client disconnects, producer must stop writing to channel afterwards.
+// Final status of stream is sent in X-RPC-Status trailer, 'error' when some values could not be encoded.
+func (p ParametersObject) Subscribe(ch <-chan interface{}) (string, <-chan struct{}) {
+	sub := subscriptionFromContext(p.r.Context())
+	if sub == nil {
+		return "", p.r.Context().Done()
+	}
+
+	sub.id = genRequestID()
+	sub.ch = ch
+
+	return sub.id, p.r.Context().Done()
+}
+
+// writeEvent writes single server-sent event to HTTP writer interface.
+func writeEvent(w http.ResponseWriter, data []byte) error {
+	_, err := fmt.Fprintf(w, "data: %s\n\n", data)
+	if err != nil {
+		return err
+	}
+
+	if flusher, ok := w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+
+	return nil
+}
+
+// WriteSubscription writes JSON-RPC 2.0 response object followed by subscription notifications
+// as server-sent events stream to HTTP response writer.
+func (s *Service) WriteSubscription(w http.ResponseWriter, respObj *ResponseObject, name string) {
+	sub := subscriptionFromContext(respObj.r.Context())
+
+	// progress stream already sent response headers
+	pr := progressFromContext(respObj.r.Context())
+
+	if sub == nil || sub.ch == nil || (pr != nil && pr.isStarted()) {
+		// write response to HTTP writer
+		s.WriteRespose(w, respObj)
+
+		// end response processing
+		return
+	}
+
+	// streaming requires flushing support from HTTP writer interface
+	if _, ok := w.(http.Flusher); !ok {
+		respObj.Error = &ErrorObject{
+			Code:    InternalErrorCode,
+			Message: InternalErrorMessage,
+			Data:    "streaming is not supported",
+		}
+
+		// write response to HTTP writer
+		s.WriteRespose(w, respObj)
+
+		// end response processing
+		return
+	}
+
+	// set custom response headers
+	for header, value := range s.headers {
+		w.Header().Set(header, value)
+	}
+
+	// set stream response headers
+	w.Header().Set("Content-Type", EventStreamContentType)
+	w.Header().Set("Cache-Control", "no-cache")
+
+	// declare final status trailer before body is written
+	w.Header().Set("Trailer", StatusTrailer)
+
+	// set non-fatal warnings added by method
+	setResponseMeta(respObj)
+
+	// get response bytes
+	resp := s.marshalResponse(respObj)
+
+	// run response hook function
+	if err := s.resp(respObj.r, s.redactResponse(respObj.r, resp)); err != nil { // hook failed
+		// set response header to custom HTTP code from hook error
+		// or fallback to 500, (internal server error)
+		w.WriteHeader(getHTTPCodeFromHookError(err))
+
+		// end response processing
+		return
+	}
+
+	// write response code to HTTP writer interface
+	w.WriteHeader(http.StatusOK)
+
+	status := StreamStatusOK
+
+	// final status is known only after stream ends
+	defer func() {
+		w.Header().Set(StatusTrailer, status)
+	}()
+
+	// first event contains subscription ID
+	if err := writeEvent(w, resp); err != nil {
+		return
+	}
+
+	for {
+		select {
+		case <-respObj.r.Context().Done(): // client disconnected
+			return
+		case v, ok := <-sub.ch:
+			if !ok { // subscription closed by method
+				return
+			}
+
+			data, err := json.Marshal(
+				subscriptionNotificationObject{
+					Jsonrpc: JSONRPCVersion,
+					Method:  name,
+					Params: subscriptionParamsObject{
+						Subscription: sub.id,
+						Result:       v,
+					},
+				},
+			)
+			if err != nil { // skip values that can not be encoded
+				status = StreamStatusError
+
+				continue
+			}
+
+			if err = writeEvent(w, data); err != nil {
+				return
+			}
+		}
+	}
+}
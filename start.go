@@ -42,12 +42,21 @@ func (s *Service) Start() error {
 	mux.Handle(s.route, s)
 
 	defer func() {
-		if err = us.Close(); err != nil {
+		// listener is already closed by server shutdown after drain
+		if err = us.Close(); err != nil && !s.lifecycle.isDraining() {
 			rerr = err
 		}
 	}()
 
-	if err = http.Serve(us, mux); err != nil {
+	srv := &http.Server{Handler: mux}
+
+	// server is shut down after drain
+	s.lifecycle.addServer(srv)
+
+	// open resources tied to service lifecycle
+	s.runStartHooks()
+
+	if err = srv.Serve(us); err != nil && err != http.ErrServerClosed {
 		return err
 	}
 
@@ -75,5 +84,23 @@ func (s *Service) StartTCPTLS() error {
 	mux := http.NewServeMux()
 	mux.Handle(s.route, s)
 
-	return http.ListenAndServeTLS(*s.address, s.cert, s.key, mux)
+	srv := &http.Server{Addr: *s.address, Handler: mux}
+
+	ln, err := net.Listen("tcp", *s.address)
+	if err != nil {
+		return err
+	}
+
+	// server is shut down after drain
+	s.lifecycle.addServer(srv)
+
+	// open resources tied to service lifecycle
+	s.runStartHooks()
+
+	// listener is closed by server
+	if err = srv.ServeTLS(ln, s.cert, s.key); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+
+	return nil
 }
@@ -0,0 +1,23 @@
+package jrpc2
+
+// ErrorHTTPStatusMode defines how JSON-RPC errors are mapped to HTTP status codes of responses.
+type ErrorHTTPStatusMode int
+
+// Error HTTP status modes.
+const (
+	// Mapped sends errors with mapped HTTP status codes (e.g. 400, 405, 413, 415), default mode
+	Mapped ErrorHTTPStatusMode = iota
+	// Always200 sends every response that carries JSON-RPC error object with 200 HTTP status code,
+	// including parse and validation errors, responses without body (authorization failures) are not affected
+	Always200
+)
+
+// SetErrorHTTPStatusMode sets mode of mapping JSON-RPC errors to HTTP status codes in service object.
+func (s *Service) SetErrorHTTPStatusMode(mode ErrorHTTPStatusMode) {
+	s.errorStatusMode = mode
+}
+
+// GetErrorHTTPStatusMode gets mode of mapping JSON-RPC errors to HTTP status codes from service object.
+func (s *Service) GetErrorHTTPStatusMode() ErrorHTTPStatusMode {
+	return s.errorStatusMode
+}
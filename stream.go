@@ -0,0 +1,243 @@
+package jrpc2
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// streamFlushSize defines size of buffered array elements written to client at once.
+const streamFlushSize = 32 << 10
+
+// errStreamClosed is returned by emit function when streamed result can not be delivered.
+var errStreamClosed = errors.New("result stream is closed")
+
+// StreamHandler defines method that produces array result element by element, every element is passed
+// to emit function, emit returns error when element can not be encoded or delivered, method should stop then.
+type StreamHandler func(data ParametersObject, emit func(v interface{}) error) *ErrorObject
+
+// streamResult represents array result that is produced while response is written.
+type streamResult struct {
+	data ParametersObject
+	f    StreamHandler
+}
+
+// MarshalJSON builds whole array, used when result is not streamed (e.g. direct Call, result middlewares).
+func (sr *streamResult) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+
+	buf.WriteByte('[')
+
+	n := 0
+
+	errObj := sr.f(sr.data, func(v interface{}) error {
+		b, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+
+		if n > 0 {
+			buf.WriteByte(',')
+		}
+
+		buf.Write(b)
+		n++
+
+		return nil
+	})
+	if errObj != nil {
+		return nil, fmt.Errorf("%s: %v", errObj.Message, errObj.Data)
+	}
+
+	buf.WriteByte(']')
+
+	return buf.Bytes(), nil
+}
+
+// RegisterStream registers method that streams large array result, elements are written to client
+// (with chunked transfer encoding) as they are emitted, so whole array is never kept in memory.
+// Error object returned before first element is emitted is sent as usual error response, failures after
+// streaming started (method error, element encoding or write failure) end response with incomplete JSON
+// and 'error' X-RPC-Status trailer, completed streams have 'ok' trailer. Stream is produced while method call slot
+// is held (see SetMaxConcurrency), slow call check and audit trail cover whole stream. Response hook and
+// result key case conversion (see SetResultKeyCase) are not applied to streamed results, no elements are produced
+// for notifications.
+func (s *Service) RegisterStream(name string, f StreamHandler, mws ...Middleware) {
+	s.Register(name, func(data ParametersObject) (interface{}, *ErrorObject) {
+		return &streamResult{data: data, f: f}, nil
+	}, mws...)
+}
+
+// resultStreamWriter writes streamed result to client, response head is written with first element.
+type resultStreamWriter struct {
+	w       http.ResponseWriter
+	respObj *ResponseObject
+	code    int
+
+	buf     bytes.Buffer
+	n       int
+	started bool
+	failed  bool
+}
+
+// start writes response headers and response object head.
+func (sw *resultStreamWriter) start() {
+	sw.started = true
+
+	sw.w.Header().Set("Trailer", StatusTrailer)
+	sw.w.WriteHeader(sw.code)
+
+	id := []byte("null")
+	if sw.respObj.ID != nil {
+		id = *sw.respObj.ID
+	}
+
+	fmt.Fprintf(&sw.buf, `{"jsonrpc":"%s","id":%s,"result":[`, JSONRPCVersion, id)
+}
+
+// flush writes buffered data to client.
+func (sw *resultStreamWriter) flush() error {
+	if _, err := sw.w.Write(sw.buf.Bytes()); err != nil {
+		sw.failed = true
+
+		return errStreamClosed
+	}
+
+	sw.buf.Reset()
+
+	if flusher, ok := sw.w.(http.Flusher); ok {
+		flusher.Flush()
+	}
+
+	return nil
+}
+
+// emit writes single array element.
+func (sw *resultStreamWriter) emit(v interface{}) error {
+	if sw.failed {
+		return errStreamClosed
+	}
+
+	b, err := json.Marshal(v)
+	if err != nil {
+		// element is lost, result is incomplete
+		sw.failed = true
+
+		return err
+	}
+
+	if !sw.started {
+		sw.start()
+	}
+
+	if sw.n > 0 {
+		sw.buf.WriteByte(',')
+	}
+
+	sw.buf.Write(b)
+	sw.n++
+
+	if sw.buf.Len() >= streamFlushSize {
+		return sw.flush()
+	}
+
+	return nil
+}
+
+// writeStreamResult produces and writes streamed result, returns false when result is not streamed
+// (notification, progress stream) or method failed before streaming started and error response
+// must be written instead. Returned error object is outcome of method call.
+func (s *Service) writeStreamResult(w http.ResponseWriter, respObj *ResponseObject, sr *streamResult) (bool, *ErrorObject) {
+	r := respObj.r
+
+	// no elements are produced for notifications
+	if notificationFlagFromContext(r.Context()) {
+		return false, nil
+	}
+
+	// method streamed progress, result is sent as last event
+	if pr := progressFromContext(r.Context()); pr != nil && pr.isStarted() {
+		return false, nil
+	}
+
+	// get HTTP Status code from Request Context, status code set by method or middleware takes precedence
+	code := httpStatusCodeFlagFromContext(r.Context())
+	if v := httpStatusOverrideFromContext(r.Context()); v != nil && *v != 0 {
+		code = *v
+	}
+
+	sw := &resultStreamWriter{
+		w:       w,
+		respObj: respObj,
+		code:    code,
+	}
+
+	// response head is written with first element, set headers of successful response
+	s.setCacheControl(r, sr.data.method, nil)
+	s.setResponseHeaders(w, r)
+
+	errObj := sr.f(sr.data, sw.emit)
+
+	if !sw.started {
+		if errObj == nil && sw.failed {
+			errObj = &ErrorObject{
+				Code:    InternalErrorCode,
+				Message: InternalErrorMessage,
+				Data:    MarshalErrorData,
+			}
+		}
+
+		if errObj != nil {
+			return false, errObj
+		}
+
+		// empty result
+		sw.start()
+	}
+
+	if errObj == nil && sw.failed {
+		errObj = &ErrorObject{
+			Code:    InternalErrorCode,
+			Message: InternalErrorMessage,
+			Data:    errStreamClosed.Error(),
+		}
+	}
+
+	if errObj != nil {
+		_ = sw.flush()
+
+		w.Header().Set(StatusTrailer, StreamStatusError)
+
+		return true, errObj
+	}
+
+	sw.buf.WriteByte(']')
+
+	// set non-fatal warnings added by method
+	setResponseMeta(respObj)
+
+	if respObj.Meta != nil {
+		if b, err := json.Marshal(respObj.Meta); err == nil {
+			sw.buf.WriteString(`,"meta":`)
+			sw.buf.Write(b)
+		}
+	}
+
+	sw.buf.WriteByte('}')
+
+	if err := sw.flush(); err != nil {
+		w.Header().Set(StatusTrailer, StreamStatusError)
+
+		return true, &ErrorObject{
+			Code:    InternalErrorCode,
+			Message: InternalErrorMessage,
+			Data:    err.Error(),
+		}
+	}
+
+	w.Header().Set(StatusTrailer, StreamStatusOK)
+
+	return true, nil
+}
@@ -0,0 +1,92 @@
+package jrpc2
+
+import (
+	"fmt"
+	"sort"
+)
+
+// SetMethodSuggestionDistance enables 'did you mean' suggestion for not found methods,
+// suggests closest registered method name within provided Levenshtein distance.
+// Zero distance (default) disables suggestions, enable only in development to avoid exposing method list.
+func (s *Service) SetMethodSuggestionDistance(distance int) {
+	s.suggestDistance = distance
+}
+
+// GetMethodSuggestionDistance gets maximal Levenshtein distance for method suggestions.
+func (s *Service) GetMethodSuggestionDistance() int {
+	return s.suggestDistance
+}
+
+// levenshtein returns edit distance between two strings.
+func levenshtein(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr[0] = i
+
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			curr[j] = prev[j] + 1 // deletion
+
+			if v := curr[j-1] + 1; v < curr[j] { // insertion
+				curr[j] = v
+			}
+
+			if v := prev[j-1] + cost; v < curr[j] { // substitution
+				curr[j] = v
+			}
+		}
+
+		prev, curr = curr, prev
+	}
+
+	return prev[len(rb)]
+}
+
+// suggestMethod returns closest registered method name or empty string when none is close enough.
+func (s *Service) suggestMethod(reg *Registry, name string) string {
+	names := make([]string, 0, len(reg.methods))
+
+	for k, m := range reg.methods {
+		if !m.isDisabled() {
+			names = append(names, k)
+		}
+	}
+
+	// deterministic suggestion for equal distances
+	sort.Strings(names)
+
+	suggestion, best := "", s.suggestDistance+1
+
+	for _, k := range names {
+		if d := levenshtein(name, k); d < best {
+			suggestion, best = k, d
+		}
+	}
+
+	return suggestion
+}
+
+// methodNotFoundData returns error data for not found method.
+func (s *Service) methodNotFoundData(reg *Registry, name string) interface{} {
+	if s.suggestDistance <= 0 || s.proxy {
+		return nil
+	}
+
+	if suggestion := s.suggestMethod(reg, name); suggestion != "" {
+		return fmt.Sprintf("did you mean '%s'?", suggestion)
+	}
+
+	return nil
+}
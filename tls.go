@@ -0,0 +1,57 @@
+package jrpc2
+
+import (
+	"net/http"
+	"strings"
+)
+
+// SetRequireTLSFlag sets flag that rejects requests not received over HTTPS with TLS required error
+// and 426 HTTP status code. Request is secure when received over TLS connection or when trusted proxy
+// (see SetTrustedProxies) reports 'https' in 'X-Forwarded-Proto' header, header from other sources is ignored.
+func (s *Service) SetRequireTLSFlag(flag bool) {
+	s.requireTLS = flag
+}
+
+// GetRequireTLSFlag gets require TLS flag from service object.
+func (s *Service) GetRequireTLSFlag() bool {
+	return s.requireTLS
+}
+
+// isSecureRequest checks that request is received over HTTPS, directly or by trusted proxy.
+func (s *Service) isSecureRequest(r *http.Request) bool {
+	if r.TLS != nil {
+		return true
+	}
+
+	if !s.isTrustedProxy(r) {
+		return false
+	}
+
+	// first value is set by outermost proxy
+	proto := strings.TrimSpace(strings.Split(r.Header.Get("X-Forwarded-Proto"), ",")[0])
+
+	return strings.EqualFold(proto, "https")
+}
+
+// checkTLS rejects request not received over HTTPS when TLS is required.
+func (s *Service) checkTLS(r *http.Request) (*http.Request, *ErrorObject) {
+	if !s.requireTLS || s.isSecureRequest(r) {
+		return r, nil
+	}
+
+	// set Response status code to 426 (upgrade required)
+	r = setHTTPStatusCode(r, http.StatusUpgradeRequired)
+
+	r = setResponseHeaders(
+		r, map[string]string{
+			"Upgrade":    "TLS/1.2, HTTP/1.1",
+			"Connection": "Upgrade",
+		},
+	)
+
+	return r, &ErrorObject{
+		Code:    TLSRequiredCode,
+		Message: TLSRequiredMessage,
+		Data:    "request must be sent over HTTPS",
+	}
+}
@@ -60,7 +60,7 @@ func (responseObject *ResponseObject) ValidateHTTPRequestMethod(r *http.Request)
 // ValidateHTTPRequestHeaders validates HTTP request headers.
 func (responseObject *ResponseObject) ValidateHTTPRequestHeaders(r *http.Request) bool {
 	// check request Content-Type header
-	if !strings.EqualFold(r.Header.Get("Content-Type"), "application/json") {
+	if !strings.EqualFold(r.Header.Get("Content-Type"), "application/json") && uploadFromContext(r.Context()) == nil {
 		responseObject.Error = &ErrorObject{
 			Code:    ParseErrorCode,
 			Message: ParseErrorMessage,
@@ -77,11 +77,11 @@ func (responseObject *ResponseObject) ValidateHTTPRequestHeaders(r *http.Request
 	}
 
 	// check request Accept header
-	if !strings.EqualFold(r.Header.Get("Accept"), "application/json") {
+	if !strings.EqualFold(r.Header.Get("Accept"), "application/json") && !isEventStreamAccepted(r) {
 		responseObject.Error = &ErrorObject{
 			Code:    ParseErrorCode,
 			Message: ParseErrorMessage,
-			Data:    "Accept header must be set to 'application/json'",
+			Data:    "Accept header must be set to 'application/json' or 'text/event-stream'",
 		}
 
 		// set Response status code to 406 (not acceptable)
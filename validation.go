@@ -0,0 +1,50 @@
+package jrpc2
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ValidateRegistration checks whole method set of service for misconfigurations that otherwise fail
+// only at first call: methods without handler, nil middlewares and aliases of unregistered methods.
+// Intended to be called at startup, returns combined error listing all problems.
+func (s *Service) ValidateRegistration() error {
+	reg := s.currentRegistry()
+	problems := make([]string, 0)
+
+	for i, mw := range s.mws {
+		if mw == nil {
+			problems = append(problems, fmt.Sprintf("service-wide middleware #%d is nil", i))
+		}
+	}
+
+	for name, m := range reg.methods {
+		if m.Method == nil {
+			problems = append(problems, fmt.Sprintf("method '%s' has no handler", name))
+		}
+
+		for i, mw := range m.Middlewares {
+			if mw == nil {
+				problems = append(problems, fmt.Sprintf("method '%s' middleware #%d is nil", name, i))
+			}
+		}
+	}
+
+	for alias := range reg.aliases {
+		name := reg.resolveAlias(alias)
+
+		if _, ok := reg.methods[name]; !ok {
+			problems = append(problems, fmt.Sprintf("alias '%s' points to unregistered method '%s'", alias, name))
+		}
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	// deterministic error message
+	sort.Strings(problems)
+
+	return fmt.Errorf("invalid registration: %s", strings.Join(problems, "; "))
+}
@@ -0,0 +1,67 @@
+package jrpc2
+
+import (
+	"sync"
+)
+
+// ResponseMeta represents reserved 'meta' member of response object, carries non-fatal information about call.
+type ResponseMeta struct {
+	// Warnings contains non-fatal warnings of partially successful call
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// warnings holds non-fatal warnings added by method during call.
+type warnings struct {
+	mu   sync.Mutex
+	list []string
+}
+
+// add appends warning message.
+func (w *warnings) add(msg string) {
+	w.mu.Lock()
+	w.list = append(w.list, msg)
+	w.mu.Unlock()
+}
+
+// get returns copy of warning messages.
+func (w *warnings) get() []string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if len(w.list) == 0 {
+		return nil
+	}
+
+	return append([]string(nil), w.list...)
+}
+
+// AddWarning adds non-fatal warning to response, emitted in 'meta.warnings' member of response object.
+// Warnings never change success status of call, method result (or error) is returned as usual.
+func (p ParametersObject) AddWarning(msg string) {
+	if p.r == nil {
+		return
+	}
+
+	if w := warningsFromContext(p.r.Context()); w != nil {
+		w.add(msg)
+	}
+}
+
+// setResponseMeta sets meta member of response object from request context.
+func setResponseMeta(respObj *ResponseObject) {
+	w := warningsFromContext(respObj.r.Context())
+	if w == nil {
+		return
+	}
+
+	list := w.get()
+	if len(list) == 0 {
+		return
+	}
+
+	if respObj.Meta == nil {
+		respObj.Meta = new(ResponseMeta)
+	}
+
+	respObj.Meta.Warnings = list
+}